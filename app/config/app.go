@@ -6,16 +6,19 @@ import (
 )
 
 type App struct {
-	Fiber  *fiber.App
-	Config *Config
+	Fiber   *fiber.App
+	Workers []delivery.Worker
+	Config  *Config
 }
 
 func NewApp(
 	fiber *delivery.RouterConfig,
+	worker *delivery.WorkerConfig,
 	config *Config,
 ) *App {
 	return &App{
-		Fiber:  fiber.App,
-		Config: config,
+		Fiber:   fiber.App,
+		Workers: worker.Workers,
+		Config:  config,
 	}
 }
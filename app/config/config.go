@@ -7,29 +7,57 @@ import (
 )
 
 type Config struct {
-	Database Database
-	Logger   Logger
-	Server   Server
-	Jwt      JWTConfig
-	Redis    Redis
-	SMTP     SMTP
-	Midtrans Midtrans
+	Database  Database
+	Logger    Logger
+	Server    Server
+	Jwt       JWTConfig
+	Auth      Auth
+	Redis     Redis
+	SMTP      SMTP
+	Email     Email
+	Midtrans  Midtrans
+	Push      Push
+	SMS       SMS
+	Storage   Storage
+	Statement Statement
+	AML       AML
+	FX        FX
+	RateLimit RateLimit
 }
 
 type Server struct {
-	Name    string
-	Version string
-	Host    string
-	Port    string
+	Name           string
+	Version        string
+	Host           string
+	Port           string
+	ProxyHeader    string
+	TrustedProxies string
 }
 
 type JWTConfig struct {
-	AccessTokenKey  string
+	Algorithm       string
+	KeyDir          string
+	KeyRotation     string
+	Issuer          string
+	Audience        string
 	AccessTokenExp  string
-	RefreshTokenKey string
 	RefreshTokenExp string
 }
 
+type Auth struct {
+	PasswordResetURL   string
+	VerificationURL    string
+	TwoFactorKey       string
+	OTPLength          string
+	OTPTTL             string
+	OTPMaxAttempts     string
+	OTPResendCooldown  string
+	LoginMaxAttempts   string
+	LoginIPMaxAttempts string
+	LoginWindow        string
+	LoginLockout       string
+}
+
 type Database struct {
 	Host                  string
 	Port                  string
@@ -58,6 +86,17 @@ type SMTP struct {
 	Port     string
 	User     string
 	Password string
+	From     string
+	// AllowPlaintext lets delivery go ahead without TLS when the server
+	// does not offer STARTTLS, for local mail catchers only
+	AllowPlaintext bool
+}
+
+type Email struct {
+	Transport   string
+	FilePath    string
+	Queue       string
+	MaxAttempts string
 }
 
 type Midtrans struct {
@@ -65,6 +104,57 @@ type Midtrans struct {
 	IsProd bool
 }
 
+type Statement struct {
+	BatchEnabled bool
+}
+
+type AML struct {
+	ScanEnabled           bool
+	ReportThreshold       string
+	StructuringMinCount   string
+	DormantDays           string
+	DormantMinAmount      string
+	CircularFlowMinAmount string
+}
+
+type FX struct {
+	Provider          string
+	RatesFile         string
+	Endpoint          string
+	APIKey            string
+	RateTTL           string
+	SpreadBps         string
+	QuoteTTL          string
+	HouseWalletNumber string
+}
+
+type SMS struct {
+	Provider string
+	Endpoint string
+	APIKey   string
+	Sender   string
+}
+
+type Storage struct {
+	Provider  string
+	LocalPath string
+}
+
+type RateLimit struct {
+	Enabled  bool
+	Window   string
+	Read     string
+	Write    string
+	Auth     string
+	Transfer string
+}
+
+type Push struct {
+	Provider  string
+	Endpoint  string
+	ServerKey string
+}
+
 func Get() *Config {
 	if os.Getenv("ENVIRONTMENT") == "" || os.Getenv("ENVIRONTMENT") == "development" {
 		err := godotenv.Load()
@@ -80,10 +170,12 @@ func Get() *Config {
 
 	return &Config{
 		Server: Server{
-			Name:    os.Getenv("APP_NAME"),
-			Host:    os.Getenv("APP_HOST"),
-			Version: os.Getenv("APP_VERSION"),
-			Port:    os.Getenv("APP_PORT"),
+			Name:           os.Getenv("APP_NAME"),
+			Host:           os.Getenv("APP_HOST"),
+			Version:        os.Getenv("APP_VERSION"),
+			Port:           os.Getenv("APP_PORT"),
+			ProxyHeader:    os.Getenv("APP_PROXY_HEADER"),
+			TrustedProxies: os.Getenv("APP_TRUSTED_PROXIES"),
 		},
 		Logger: Logger{
 			Level: os.Getenv("LOG_LEVEL"),
@@ -100,11 +192,27 @@ func Get() *Config {
 			MaxLifeTimeConnection: os.Getenv("DB_POOL_LIFETIME"),
 		},
 		Jwt: JWTConfig{
-			AccessTokenKey:  os.Getenv("JWT_ACCESS_KEY"),
+			Algorithm:       os.Getenv("JWT_ALGORITHM"),
+			KeyDir:          os.Getenv("JWT_KEY_DIR"),
+			KeyRotation:     os.Getenv("JWT_KEY_ROTATION"),
+			Issuer:          os.Getenv("JWT_ISSUER"),
+			Audience:        os.Getenv("JWT_AUDIENCE"),
 			AccessTokenExp:  os.Getenv("JWT_ACCESS_EXP"),
-			RefreshTokenKey: os.Getenv("JWT_REFRESH_KEY"),
 			RefreshTokenExp: os.Getenv("JWT_REFRESH_EXP"),
 		},
+		Auth: Auth{
+			PasswordResetURL:   os.Getenv("AUTH_PASSWORD_RESET_URL"),
+			VerificationURL:    os.Getenv("AUTH_VERIFICATION_URL"),
+			TwoFactorKey:       os.Getenv("AUTH_TWO_FACTOR_KEY"),
+			OTPLength:          os.Getenv("AUTH_OTP_LENGTH"),
+			OTPTTL:             os.Getenv("AUTH_OTP_TTL"),
+			OTPMaxAttempts:     os.Getenv("AUTH_OTP_MAX_ATTEMPTS"),
+			OTPResendCooldown:  os.Getenv("AUTH_OTP_RESEND_COOLDOWN"),
+			LoginMaxAttempts:   os.Getenv("AUTH_LOGIN_MAX_ATTEMPTS"),
+			LoginIPMaxAttempts: os.Getenv("AUTH_LOGIN_IP_MAX_ATTEMPTS"),
+			LoginWindow:        os.Getenv("AUTH_LOGIN_WINDOW"),
+			LoginLockout:       os.Getenv("AUTH_LOGIN_LOCKOUT"),
+		},
 		Redis: Redis{
 			Address: os.Getenv("REDIS_ADDR"),
 			User:    os.Getenv("REDIS_USER"),
@@ -116,10 +224,63 @@ func Get() *Config {
 			Port:     os.Getenv("SMTP_PORT"),
 			User:     os.Getenv("SMTP_USER"),
 			Password: os.Getenv("SMTP_PASS"),
+			From:     os.Getenv("SMTP_FROM"),
+
+			AllowPlaintext: os.Getenv("SMTP_ALLOW_PLAINTEXT") == "true",
+		},
+		Email: Email{
+			Transport:   os.Getenv("EMAIL_TRANSPORT"),
+			FilePath:    os.Getenv("EMAIL_FILE_PATH"),
+			Queue:       os.Getenv("EMAIL_QUEUE"),
+			MaxAttempts: os.Getenv("EMAIL_MAX_ATTEMPTS"),
 		},
 		Midtrans: Midtrans{
 			Key:    os.Getenv("MIDTRANS_KEY"),
 			IsProd: os.Getenv("MIDTRANS_ENV") == "production",
 		},
+		SMS: SMS{
+			Provider: os.Getenv("SMS_PROVIDER"),
+			Endpoint: os.Getenv("SMS_ENDPOINT"),
+			APIKey:   os.Getenv("SMS_API_KEY"),
+			Sender:   os.Getenv("SMS_SENDER"),
+		},
+		Storage: Storage{
+			Provider:  os.Getenv("STORAGE_PROVIDER"),
+			LocalPath: os.Getenv("STORAGE_LOCAL_PATH"),
+		},
+		Statement: Statement{
+			BatchEnabled: os.Getenv("STATEMENT_BATCH_ENABLED") == "true",
+		},
+		AML: AML{
+			ScanEnabled:           os.Getenv("AML_SCAN_ENABLED") == "true",
+			ReportThreshold:       os.Getenv("AML_REPORT_THRESHOLD"),
+			StructuringMinCount:   os.Getenv("AML_STRUCTURING_MIN_COUNT"),
+			DormantDays:           os.Getenv("AML_DORMANT_DAYS"),
+			DormantMinAmount:      os.Getenv("AML_DORMANT_MIN_AMOUNT"),
+			CircularFlowMinAmount: os.Getenv("AML_CIRCULAR_FLOW_MIN_AMOUNT"),
+		},
+		FX: FX{
+			Provider:          os.Getenv("FX_PROVIDER"),
+			RatesFile:         os.Getenv("FX_RATES_FILE"),
+			Endpoint:          os.Getenv("FX_ENDPOINT"),
+			APIKey:            os.Getenv("FX_API_KEY"),
+			RateTTL:           os.Getenv("FX_RATE_TTL"),
+			SpreadBps:         os.Getenv("FX_SPREAD_BPS"),
+			QuoteTTL:          os.Getenv("FX_QUOTE_TTL"),
+			HouseWalletNumber: os.Getenv("FX_HOUSE_WALLET_NUMBER"),
+		},
+		RateLimit: RateLimit{
+			Enabled:  os.Getenv("RATE_LIMIT_ENABLED") != "false",
+			Window:   os.Getenv("RATE_LIMIT_WINDOW"),
+			Read:     os.Getenv("RATE_LIMIT_READ"),
+			Write:    os.Getenv("RATE_LIMIT_WRITE"),
+			Auth:     os.Getenv("RATE_LIMIT_AUTH"),
+			Transfer: os.Getenv("RATE_LIMIT_TRANSFER"),
+		},
+		Push: Push{
+			Provider:  os.Getenv("PUSH_PROVIDER"),
+			Endpoint:  os.Getenv("PUSH_FCM_ENDPOINT"),
+			ServerKey: os.Getenv("PUSH_FCM_KEY"),
+		},
 	}
 }
@@ -1,14 +1,30 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"riz.it/domped/app/dto"
 )
 
 func NewFiber(config *Config) *fiber.App {
+	// Behind a load balancer the client address comes from the proxy
+	// header, which is only believed when the request came through one of
+	// the trusted proxies, if any are listed
+	var trustedProxies []string
+	if config.Server.TrustedProxies != "" {
+		trustedProxies = strings.Split(config.Server.TrustedProxies, ",")
+	}
+
 	var app = fiber.New(fiber.Config{
 		AppName:      config.Server.Name,
 		ErrorHandler: NewErrorHandler(),
+		// Room for the two identity document images of a KYC submission
+		BodyLimit:               12 * 1024 * 1024,
+		ProxyHeader:             config.Server.ProxyHeader,
+		EnableIPValidation:      true,
+		EnableTrustedProxyCheck: len(trustedProxies) > 0,
+		TrustedProxies:          trustedProxies,
 	})
 
 	return app
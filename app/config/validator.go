@@ -1,7 +1,39 @@
 package config
 
-import "github.com/go-playground/validator/v10"
+import (
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
 
 func NewValidator(conf *Config) *validator.Validate {
-	return validator.New()
+	validate := validator.New()
+
+	// Custom rules
+	validate.RegisterValidation("password", validatePassword)
+
+	return validate
+}
+
+// validatePassword requires at least 8 characters with an upper case
+// letter, a lower case letter and a digit.
+func validatePassword(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if len([]rune(value)) < 8 {
+		return false
+	}
+
+	var upper, lower, digit bool
+	for _, r := range value {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+
+	return upper && lower && digit
 }
@@ -0,0 +1,17 @@
+CREATE TABLE public.device_tokens (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    user_id BIGINT NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    platform VARCHAR(16) NOT NULL,
+    device_name VARCHAR(125),
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_failure_at TIMESTAMP,
+    last_success_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES public.users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_device_tokens_user_id ON public.device_tokens (user_id);
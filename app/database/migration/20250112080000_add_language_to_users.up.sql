@@ -0,0 +1 @@
+ALTER TABLE public.users ADD COLUMN language VARCHAR(5) NOT NULL DEFAULT 'id';
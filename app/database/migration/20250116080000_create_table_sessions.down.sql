@@ -0,0 +1,3 @@
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS hashed_rt TEXT;
+DROP TABLE IF EXISTS public.refresh_tokens CASCADE;
+DROP TABLE IF EXISTS public.sessions CASCADE;
@@ -0,0 +1,32 @@
+CREATE TABLE public.sessions (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    user_id BIGINT NOT NULL,
+    device_name VARCHAR(125),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    revoked_reason VARCHAR(50),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES public.users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sessions_user_id ON public.sessions (user_id);
+
+CREATE TABLE public.refresh_tokens (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    session_id BIGINT NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (session_id) REFERENCES public.sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_refresh_tokens_session_id ON public.refresh_tokens (session_id);
+
+ALTER TABLE public.users DROP COLUMN IF EXISTS hashed_rt;
@@ -0,0 +1,2 @@
+ALTER TABLE public.sessions DROP COLUMN IF EXISTS access_expires_at;
+ALTER TABLE public.sessions DROP COLUMN IF EXISTS access_token_id;
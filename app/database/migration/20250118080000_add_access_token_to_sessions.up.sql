@@ -0,0 +1,2 @@
+ALTER TABLE public.sessions ADD COLUMN access_token_id VARCHAR(36);
+ALTER TABLE public.sessions ADD COLUMN access_expires_at TIMESTAMP;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.two_factor_recovery_codes CASCADE;
+ALTER TABLE public.users DROP COLUMN IF EXISTS two_factor_last_counter;
+ALTER TABLE public.users DROP COLUMN IF EXISTS two_factor_enabled_at;
+ALTER TABLE public.users DROP COLUMN IF EXISTS two_factor_secret;
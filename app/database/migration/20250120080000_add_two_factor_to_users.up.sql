@@ -0,0 +1,16 @@
+-- The secret is stored encrypted
+ALTER TABLE public.users ADD COLUMN two_factor_secret VARCHAR(255);
+ALTER TABLE public.users ADD COLUMN two_factor_enabled_at TIMESTAMP;
+ALTER TABLE public.users ADD COLUMN two_factor_last_counter BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE public.two_factor_recovery_codes (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    user_id BIGINT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES public.users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_two_factor_recovery_codes_user_id ON public.two_factor_recovery_codes (user_id);
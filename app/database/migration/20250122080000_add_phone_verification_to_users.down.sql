@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS public.idx_users_phone_verified;
+ALTER TABLE public.users DROP COLUMN IF EXISTS phone_verified_at;
@@ -0,0 +1,4 @@
+ALTER TABLE public.users ADD COLUMN phone_verified_at TIMESTAMP;
+
+-- A phone number can only be verified by one account at a time
+CREATE UNIQUE INDEX idx_users_phone_verified ON public.users (phone) WHERE phone_verified_at IS NOT NULL;
@@ -0,0 +1,15 @@
+CREATE TABLE public.audit_logs (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    user_id BIGINT,
+    actor_id BIGINT,
+    action VARCHAR(64) NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES public.users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_audit_logs_user_id ON public.audit_logs (user_id, created_at);
+CREATE INDEX idx_audit_logs_action ON public.audit_logs (action, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE public.wallets DROP COLUMN IF EXISTS closed_at;
+ALTER TABLE public.users DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,2 @@
+ALTER TABLE public.users ADD COLUMN closed_at TIMESTAMP;
+ALTER TABLE public.wallets ADD COLUMN closed_at TIMESTAMP;
@@ -0,0 +1 @@
+ALTER TABLE public.users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.kyc_submissions CASCADE;
+ALTER TABLE public.users DROP COLUMN IF EXISTS kyc_level;
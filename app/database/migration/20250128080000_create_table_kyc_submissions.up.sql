@@ -0,0 +1,27 @@
+ALTER TABLE public.users ADD COLUMN kyc_level SMALLINT NOT NULL DEFAULT 0;
+
+CREATE TABLE public.kyc_submissions (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    user_id BIGINT NOT NULL,
+    nik VARCHAR(16) NOT NULL,
+    full_name VARCHAR(125) NOT NULL,
+    birth_date DATE NOT NULL,
+    id_card_key VARCHAR(255) NOT NULL,
+    selfie_key VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    rejection_reason VARCHAR(255),
+    reviewed_by BIGINT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES public.users (id) ON DELETE CASCADE,
+    FOREIGN KEY (reviewed_by) REFERENCES public.users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_kyc_submissions_user_id ON public.kyc_submissions (user_id, created_at);
+CREATE INDEX idx_kyc_submissions_status ON public.kyc_submissions (status, created_at);
+-- A user has at most one submission waiting for review, and a NIK can only
+-- back one verified account
+CREATE UNIQUE INDEX idx_kyc_submissions_pending_user ON public.kyc_submissions (user_id) WHERE status = 'pending';
+CREATE UNIQUE INDEX idx_kyc_submissions_approved_nik ON public.kyc_submissions (nik) WHERE status = 'approved';
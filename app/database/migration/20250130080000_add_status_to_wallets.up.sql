@@ -0,0 +1 @@
+ALTER TABLE public.wallets ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active';
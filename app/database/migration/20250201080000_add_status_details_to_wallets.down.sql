@@ -0,0 +1,8 @@
+ALTER TABLE public.wallets DROP CONSTRAINT IF EXISTS wallets_status_check;
+
+UPDATE public.wallets SET status = 'active' WHERE status = 'closed';
+UPDATE public.wallets SET status = 'frozen' WHERE status IN ('frozen_debit', 'frozen_all');
+
+ALTER TABLE public.wallets DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE public.wallets DROP COLUMN IF EXISTS status_changed_by;
+ALTER TABLE public.wallets DROP COLUMN IF EXISTS status_reason;
@@ -0,0 +1,8 @@
+ALTER TABLE public.wallets ADD COLUMN status_reason VARCHAR(255);
+ALTER TABLE public.wallets ADD COLUMN status_changed_by BIGINT REFERENCES public.users (id) ON DELETE SET NULL;
+ALTER TABLE public.wallets ADD COLUMN status_changed_at TIMESTAMP;
+
+UPDATE public.wallets SET status = 'frozen_all' WHERE status = 'frozen';
+UPDATE public.wallets SET status = 'closed', status_changed_at = closed_at WHERE closed_at IS NOT NULL;
+
+ALTER TABLE public.wallets ADD CONSTRAINT wallets_status_check CHECK (status IN ('active', 'frozen_debit', 'frozen_all', 'closed'));
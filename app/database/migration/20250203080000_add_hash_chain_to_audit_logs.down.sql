@@ -0,0 +1,18 @@
+DROP TRIGGER IF EXISTS audit_logs_append_only_truncate ON public.audit_logs;
+DROP TRIGGER IF EXISTS audit_logs_append_only ON public.audit_logs;
+DROP FUNCTION IF EXISTS public.audit_logs_append_only();
+
+ALTER TABLE public.audit_logs DROP CONSTRAINT IF EXISTS audit_logs_user_id_fkey;
+ALTER TABLE public.audit_logs ADD CONSTRAINT audit_logs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users (id) ON DELETE SET NULL;
+
+DROP INDEX IF EXISTS idx_audit_logs_request_id;
+DROP INDEX IF EXISTS idx_audit_logs_target;
+DROP INDEX IF EXISTS idx_audit_logs_actor_id;
+
+ALTER TABLE public.audit_logs DROP COLUMN IF EXISTS hash;
+ALTER TABLE public.audit_logs DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE public.audit_logs DROP COLUMN IF EXISTS after;
+ALTER TABLE public.audit_logs DROP COLUMN IF EXISTS before;
+ALTER TABLE public.audit_logs DROP COLUMN IF EXISTS request_id;
+ALTER TABLE public.audit_logs DROP COLUMN IF EXISTS target_id;
+ALTER TABLE public.audit_logs DROP COLUMN IF EXISTS target_type;
@@ -0,0 +1,29 @@
+ALTER TABLE public.audit_logs ADD COLUMN target_type VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE public.audit_logs ADD COLUMN target_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE public.audit_logs ADD COLUMN request_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE public.audit_logs ADD COLUMN before JSONB;
+ALTER TABLE public.audit_logs ADD COLUMN after JSONB;
+-- Each entry stores the hash of the one before it; entries written before
+-- the chain existed keep a NULL hash
+ALTER TABLE public.audit_logs ADD COLUMN prev_hash CHAR(64);
+ALTER TABLE public.audit_logs ADD COLUMN hash CHAR(64);
+
+CREATE INDEX idx_audit_logs_actor_id ON public.audit_logs (actor_id, created_at);
+CREATE INDEX idx_audit_logs_target ON public.audit_logs (target_type, target_id);
+CREATE INDEX idx_audit_logs_request_id ON public.audit_logs (request_id);
+
+-- Users are anonymised rather than deleted, so the reference no longer
+-- needs to be cleared, which the table would not allow
+ALTER TABLE public.audit_logs DROP CONSTRAINT IF EXISTS audit_logs_user_id_fkey;
+ALTER TABLE public.audit_logs ADD CONSTRAINT audit_logs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users (id);
+
+CREATE FUNCTION public.audit_logs_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON public.audit_logs
+    FOR EACH ROW EXECUTE FUNCTION public.audit_logs_append_only();
+CREATE TRIGGER audit_logs_append_only_truncate BEFORE TRUNCATE ON public.audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION public.audit_logs_append_only();
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.fraud_decisions CASCADE;
+DROP TABLE IF EXISTS public.fraud_rules CASCADE;
@@ -0,0 +1,50 @@
+CREATE TABLE public.fraud_rules (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    type VARCHAR(32) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (updated_by) REFERENCES public.users (id),
+    CONSTRAINT fraud_rules_type_check CHECK (type IN ('velocity', 'new_device_amount', 'new_beneficiary', 'topup_cash_out')),
+    CONSTRAINT fraud_rules_action_check CHECK (action IN ('challenge', 'block'))
+);
+
+-- Decisions are only stored when a rule matched
+CREATE TABLE public.fraud_decisions (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    user_id BIGINT NOT NULL,
+    wallet_id BIGINT NOT NULL,
+    session_id BIGINT,
+    stage VARCHAR(16) NOT NULL,
+    dof_number VARCHAR(100) NOT NULL,
+    amount BIGINT NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    matched_rules JSONB NOT NULL DEFAULT '[]',
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    challenge_passed_at TIMESTAMP,
+    reviewed_by BIGINT,
+    reviewed_at TIMESTAMP,
+    review_note VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES public.users (id),
+    FOREIGN KEY (wallet_id) REFERENCES public.wallets (id),
+    FOREIGN KEY (reviewed_by) REFERENCES public.users (id)
+);
+
+CREATE INDEX idx_fraud_decisions_user_id ON public.fraud_decisions (user_id, created_at);
+CREATE INDEX idx_fraud_decisions_unreviewed ON public.fraud_decisions (created_at) WHERE reviewed_at IS NULL;
+
+INSERT INTO public.fraud_rules (code, type, action, params, description) VALUES
+    ('velocity_10m', 'velocity', 'challenge', '{"max_count": 5, "window_minutes": 10}', 'More than 5 transfers in 10 minutes'),
+    ('velocity_1h', 'velocity', 'block', '{"max_count": 20, "window_minutes": 60}', 'More than 20 transfers in an hour'),
+    ('new_device_large', 'new_device_amount', 'challenge', '{"device_age_hours": 24, "min_amount": 1000000}', 'Large transfer from a device signed in less than a day ago'),
+    ('new_beneficiary_large', 'new_beneficiary', 'challenge', '{"min_amount": 2000000}', 'Large first transfer to a wallet'),
+    ('topup_cash_out', 'topup_cash_out', 'challenge', '{"window_minutes": 30, "min_percent": 80}', 'Most of a recent top-up transferred straight out');
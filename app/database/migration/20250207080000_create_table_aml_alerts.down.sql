@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_transaction_at;
+DROP TABLE IF EXISTS public.aml_alert_notes CASCADE;
+DROP TABLE IF EXISTS public.aml_alerts CASCADE;
@@ -0,0 +1,46 @@
+CREATE TABLE public.aml_alerts (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    -- Identifies the activity an alert is about, so later scans of the same
+    -- window do not raise it again
+    fingerprint VARCHAR(255) NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL,
+    wallet_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    assigned_to BIGINT,
+    resolution VARCHAR(16),
+    closed_by BIGINT,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES public.users (id),
+    FOREIGN KEY (wallet_id) REFERENCES public.wallets (id),
+    FOREIGN KEY (assigned_to) REFERENCES public.users (id),
+    FOREIGN KEY (closed_by) REFERENCES public.users (id),
+    CONSTRAINT aml_alerts_type_check CHECK (type IN ('structuring', 'circular_flow', 'dormant_reactivation')),
+    CONSTRAINT aml_alerts_status_check CHECK (status IN ('open', 'investigating', 'closed')),
+    CONSTRAINT aml_alerts_resolution_check CHECK (resolution IN ('reported', 'no_action', 'false_positive'))
+);
+
+CREATE INDEX idx_aml_alerts_status ON public.aml_alerts (status, created_at);
+CREATE INDEX idx_aml_alerts_user_id ON public.aml_alerts (user_id, created_at);
+CREATE INDEX idx_aml_alerts_assigned_to ON public.aml_alerts (assigned_to, status);
+
+CREATE TABLE public.aml_alert_notes (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    alert_id BIGINT NOT NULL,
+    author_id BIGINT NOT NULL,
+    note TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (alert_id) REFERENCES public.aml_alerts (id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES public.users (id)
+);
+
+CREATE INDEX idx_aml_alert_notes_alert_id ON public.aml_alert_notes (alert_id, created_at);
+
+-- The scans look transactions up by time across all wallets
+CREATE INDEX idx_transactions_transaction_at ON public.transactions (transaction_at);
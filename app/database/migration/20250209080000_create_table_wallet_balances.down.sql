@@ -0,0 +1,11 @@
+ALTER TABLE public.fraud_decisions DROP COLUMN IF EXISTS currency;
+ALTER TABLE public.topup DROP COLUMN IF EXISTS currency;
+ALTER TABLE public.transactions DROP COLUMN IF EXISTS currency;
+
+-- Only the rupiah balance fits back into the wallet
+ALTER TABLE public.wallets ADD COLUMN balance BIGINT DEFAULT 0;
+UPDATE public.wallets w SET balance = b.balance
+FROM public.wallet_balances b
+WHERE b.wallet_id = w.id AND b.currency = 'IDR';
+
+DROP TABLE IF EXISTS public.wallet_balances CASCADE;
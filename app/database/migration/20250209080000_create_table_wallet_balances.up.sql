@@ -0,0 +1,22 @@
+-- A wallet holds one balance per currency, in the currency's minor unit
+CREATE TABLE public.wallet_balances (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    wallet_id BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    balance BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (wallet_id) REFERENCES public.wallets (id) ON DELETE CASCADE,
+    CONSTRAINT wallet_balances_wallet_currency_key UNIQUE (wallet_id, currency)
+);
+
+-- Existing balances were rupiah
+INSERT INTO public.wallet_balances (wallet_id, currency, balance, created_at, updated_at)
+SELECT id, 'IDR', COALESCE(balance, 0), created_at, updated_at FROM public.wallets;
+
+ALTER TABLE public.wallets DROP COLUMN balance;
+
+ALTER TABLE public.transactions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE public.topup ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE public.fraud_decisions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
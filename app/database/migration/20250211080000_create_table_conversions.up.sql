@@ -0,0 +1,21 @@
+CREATE TABLE public.conversions (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    user_id BIGINT NOT NULL,
+    wallet_id BIGINT NOT NULL,
+    from_currency CHAR(3) NOT NULL,
+    to_currency CHAR(3) NOT NULL,
+    -- Amounts are in the minor unit of their currency
+    from_amount BIGINT NOT NULL,
+    to_amount BIGINT NOT NULL,
+    -- The rate the user got, units of to_currency per unit of from_currency
+    rate NUMERIC(24, 12) NOT NULL,
+    -- The spread kept by the house wallet, in to_currency
+    spread_amount BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES public.users (id),
+    FOREIGN KEY (wallet_id) REFERENCES public.wallets (id),
+    CONSTRAINT conversions_currency_check CHECK (from_currency <> to_currency)
+);
+
+CREATE INDEX idx_conversions_wallet_id ON public.conversions (wallet_id, created_at);
@@ -0,0 +1,26 @@
+-- Fold pockets back into their main wallet, adding a balance row for any
+-- currency the main wallet doesn't hold yet
+INSERT INTO public.wallet_balances (wallet_id, currency, balance)
+SELECT w.parent_id, pb.currency, SUM(pb.balance)
+FROM public.wallet_balances pb
+JOIN public.wallets w ON w.id = pb.wallet_id
+WHERE w.parent_id IS NOT NULL
+GROUP BY w.parent_id, pb.currency
+ON CONFLICT (wallet_id, currency) DO UPDATE
+SET balance = public.wallet_balances.balance + EXCLUDED.balance, updated_at = CURRENT_TIMESTAMP;
+
+UPDATE public.transactions t SET wallet_id = w.parent_id FROM public.wallets w WHERE t.wallet_id = w.id AND w.parent_id IS NOT NULL;
+UPDATE public.fraud_decisions d SET wallet_id = w.parent_id FROM public.wallets w WHERE d.wallet_id = w.id AND w.parent_id IS NOT NULL;
+UPDATE public.aml_alerts a SET wallet_id = w.parent_id FROM public.wallets w WHERE a.wallet_id = w.id AND w.parent_id IS NOT NULL;
+
+DELETE FROM public.wallet_balances WHERE wallet_id IN (SELECT id FROM public.wallets WHERE parent_id IS NOT NULL);
+DELETE FROM public.wallets WHERE parent_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_wallets_parent_id;
+DROP INDEX IF EXISTS idx_wallets_main_user_id;
+
+ALTER TABLE public.wallets DROP CONSTRAINT IF EXISTS wallets_target_amount_check;
+ALTER TABLE public.wallets DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE public.wallets DROP COLUMN IF EXISTS target_amount;
+ALTER TABLE public.wallets DROP COLUMN IF EXISTS name;
+ALTER TABLE public.wallets DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,12 @@
+-- A pocket is a wallet under the user's main wallet, holding a single
+-- currency and set aside for a savings goal
+ALTER TABLE public.wallets ADD COLUMN parent_id BIGINT REFERENCES public.wallets (id);
+ALTER TABLE public.wallets ADD COLUMN name VARCHAR(64);
+ALTER TABLE public.wallets ADD COLUMN target_amount BIGINT;
+ALTER TABLE public.wallets ADD COLUMN locked_until TIMESTAMP;
+
+ALTER TABLE public.wallets ADD CONSTRAINT wallets_target_amount_check CHECK (target_amount > 0);
+
+-- Every user still has exactly one main wallet
+CREATE UNIQUE INDEX idx_wallets_main_user_id ON public.wallets (user_id) WHERE parent_id IS NULL;
+CREATE INDEX idx_wallets_parent_id ON public.wallets (parent_id);
@@ -0,0 +1,10 @@
+CREATE OR REPLACE FUNCTION public.audit_logs_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_audit_logs_unchained;
+DROP INDEX IF EXISTS idx_audit_logs_chain_seq;
+
+ALTER TABLE public.audit_logs DROP COLUMN IF EXISTS chain_seq;
@@ -0,0 +1,23 @@
+-- Entries are written without a hash and linked into the chain afterwards
+-- by a single writer, in the order it links them
+ALTER TABLE public.audit_logs ADD COLUMN chain_seq BIGINT;
+
+-- Entries already chained were linked in id order
+ALTER TABLE public.audit_logs DISABLE TRIGGER audit_logs_append_only;
+UPDATE public.audit_logs SET chain_seq = id WHERE hash IS NOT NULL;
+ALTER TABLE public.audit_logs ENABLE TRIGGER audit_logs_append_only;
+
+CREATE UNIQUE INDEX idx_audit_logs_chain_seq ON public.audit_logs (chain_seq);
+CREATE INDEX idx_audit_logs_unchained ON public.audit_logs (id) WHERE hash IS NULL;
+
+-- The only change allowed is linking an entry that has no hash yet
+CREATE OR REPLACE FUNCTION public.audit_logs_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.hash IS NULL AND NEW.hash IS NOT NULL
+        AND to_jsonb(NEW) - 'prev_hash' - 'hash' - 'chain_seq' = to_jsonb(OLD) - 'prev_hash' - 'hash' - 'chain_seq' THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
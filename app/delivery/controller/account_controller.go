@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type AccountController struct {
+	AccountUseCase domain.AccountUseCase
+	Log            *logrus.Logger
+}
+
+func NewAccountController(accountUseCase domain.AccountUseCase, log *logrus.Logger) *AccountController {
+	return &AccountController{
+		AccountUseCase: accountUseCase,
+		Log:            log,
+	}
+}
+
+func (a *AccountController) Close(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the closure request from the request body
+	request := new(dto.CloseAccountRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the Close use case to close and anonymise the account
+	if err := a.AccountUseCase.Close(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the closure response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Account closed successfully",
+	})
+}
+
+func (a *AccountController) Export(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	request := &dto.ExportAccountRequest{
+		IPAddress: ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
+
+	// Call the Export use case to bundle the account data
+	file, err := a.AccountUseCase.Export(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the export as a file download
+	ctx.Attachment(file.Filename)
+	ctx.Set(fiber.HeaderContentType, file.ContentType)
+	return ctx.Send(file.Content)
+}
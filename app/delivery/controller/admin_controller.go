@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type AdminController struct {
+	AdminUseCase domain.AdminUseCase
+	Log          *logrus.Logger
+}
+
+func NewAdminController(adminUseCase domain.AdminUseCase, log *logrus.Logger) *AdminController {
+	return &AdminController{
+		AdminUseCase: adminUseCase,
+		Log:          log,
+	}
+}
+
+func (a *AdminController) SearchUsers(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the search from the query string
+	request := new(dto.AdminUserSearchRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the SearchUsers use case to list the matching users
+	result, paging, err := a.AdminUseCase.SearchUsers(ctx.UserContext(), request, actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the users as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.UserData]{
+		Status:  true,
+		Message: "Users retrieved successfully",
+		Data:    &result,
+		Paging:  paging,
+	})
+}
+
+func (a *AdminController) GetUser(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the user ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the GetUser use case to read the user and wallet
+	result, err := a.AdminUseCase.GetUser(ctx.UserContext(), newAdminRequest(ctx), int64(id), actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the user as a JSON object
+	return ctx.JSON(&dto.ApiResponse[dto.ProfileResponse]{
+		Status:  true,
+		Message: "User retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (a *AdminController) GetWallet(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the user ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the GetWallet use case to read the wallet
+	result, err := a.AdminUseCase.GetWallet(ctx.UserContext(), newAdminRequest(ctx), int64(id), actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the wallet as a JSON object
+	return ctx.JSON(&dto.ApiResponse[dto.WalletData]{
+		Status:  true,
+		Message: "Wallet retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (a *AdminController) FindTransactions(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the user ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the page from the query string
+	request := new(dto.AdminTransactionListRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the FindTransactions use case to list the wallet's transactions
+	result, paging, err := a.AdminUseCase.FindTransactions(ctx.UserContext(), request, int64(id), actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the transactions as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.TransactionData]{
+		Status:  true,
+		Message: "Transactions retrieved successfully",
+		Data:    &result,
+		Paging:  paging,
+	})
+}
+
+func (a *AdminController) FreezeWallet(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the user ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the reason from the request body
+	request := new(dto.FreezeWalletRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the FreezeWallet use case to stop the wallet's transfers
+	if err := a.AdminUseCase.FreezeWallet(ctx.UserContext(), request, int64(id), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the freeze response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Wallet frozen successfully",
+	})
+}
+
+func (a *AdminController) UnfreezeWallet(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the user ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the reason from the request body
+	request := new(dto.UnfreezeWalletRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the UnfreezeWallet use case to allow transfers again
+	if err := a.AdminUseCase.UnfreezeWallet(ctx.UserContext(), request, int64(id), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the unfreeze response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Wallet unfrozen successfully",
+	})
+}
+
+func (a *AdminController) ResendVerification(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the user ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the ResendVerification use case to email a new code
+	if err := a.AdminUseCase.ResendVerification(ctx.UserContext(), newAdminRequest(ctx), int64(id), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// The reference ID only goes to the user
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Verification code sent successfully",
+	})
+}
+
+func (a *AdminController) AssignRole(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the user ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the role from the request body
+	request := new(dto.AssignRoleRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the AssignRole use case to change the user's role
+	if err := a.AdminUseCase.AssignRole(ctx.UserContext(), request, int64(id), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the role response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Role assigned successfully",
+	})
+}
+
+func newAdminRequest(ctx *fiber.Ctx) *dto.AdminRequest {
+	return &dto.AdminRequest{
+		IPAddress: ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
+}
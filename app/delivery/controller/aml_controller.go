@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type AMLController struct {
+	AMLUseCase domain.AMLUseCase
+	Log        *logrus.Logger
+}
+
+func NewAMLController(amlUseCase domain.AMLUseCase, log *logrus.Logger) *AMLController {
+	return &AMLController{
+		AMLUseCase: amlUseCase,
+		Log:        log,
+	}
+}
+
+func (a *AMLController) FindAlerts(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the filter from the query string
+	request := new(dto.AMLAlertListRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the FindAlerts use case to list the alerts
+	result, paging, err := a.AMLUseCase.FindAlerts(ctx.UserContext(), request, actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the alerts as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.AMLAlertData]{
+		Status:  true,
+		Message: "Alerts retrieved successfully",
+		Data:    &result,
+		Paging:  paging,
+	})
+}
+
+func (a *AMLController) GetAlert(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the alert ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the GetAlert use case to load the alert and its notes
+	result, err := a.AMLUseCase.GetAlert(ctx.UserContext(), newAdminRequest(ctx), int64(id), actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the alert as a JSON object
+	return ctx.JSON(&dto.ApiResponse[dto.AMLAlertDetailResponse]{
+		Status:  true,
+		Message: "Alert retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (a *AMLController) Assign(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the alert ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the assignee from the request body
+	request := new(dto.AssignAMLAlertRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the Assign use case to hand the alert over
+	if err := a.AMLUseCase.Assign(ctx.UserContext(), request, int64(id), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the assignment response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Alert assigned successfully",
+	})
+}
+
+func (a *AMLController) AddNote(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the alert ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the note from the request body
+	request := new(dto.AMLAlertNoteRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the AddNote use case to save the note
+	if err := a.AMLUseCase.AddNote(ctx.UserContext(), request, int64(id), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the note response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Note added successfully",
+	})
+}
+
+func (a *AMLController) Close(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the alert ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the resolution from the request body
+	request := new(dto.CloseAMLAlertRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the Close use case to resolve the alert
+	if err := a.AMLUseCase.Close(ctx.UserContext(), request, int64(id), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the close response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Alert closed successfully",
+	})
+}
+
+func (a *AMLController) ExportReports(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the date range from the query string
+	request := new(dto.AMLReportRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the ExportReports use case to render the report
+	file, err := a.AMLUseCase.ExportReports(ctx.UserContext(), request, actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the report as a file download
+	ctx.Attachment(file.Filename)
+	ctx.Set(fiber.HeaderContentType, file.ContentType)
+	return ctx.Send(file.Content)
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type AuditController struct {
+	AuditUseCase domain.AuditUseCase
+	Log          *logrus.Logger
+}
+
+func NewAuditController(auditUseCase domain.AuditUseCase, log *logrus.Logger) *AuditController {
+	return &AuditController{
+		AuditUseCase: auditUseCase,
+		Log:          log,
+	}
+}
+
+func (a *AuditController) FindAll(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the filter from the query string
+	request := new(dto.AuditLogListRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the FindAll use case to list the matching entries
+	result, paging, err := a.AuditUseCase.FindAll(ctx.UserContext(), request, actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the entries as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.AuditLogData]{
+		Status:  true,
+		Message: "Audit logs retrieved successfully",
+		Data:    &result,
+		Paging:  paging,
+	})
+}
+
+func (a *AuditController) Verify(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Call the Verify use case to check the hash chain
+	result, err := a.AuditUseCase.Verify(ctx.UserContext(), newAdminRequest(ctx), actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the verification result as a JSON object
+	return ctx.JSON(&dto.ApiResponse[dto.AuditVerifyResponse]{
+		Status:  true,
+		Message: "Audit log verified successfully",
+		Data:    result,
+	})
+}
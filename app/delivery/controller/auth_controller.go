@@ -26,6 +26,8 @@ func (c *AuthController) Login(ctx *fiber.Ctx) error {
 		// Return a bad request error if parsing fails
 		return fiber.ErrBadRequest
 	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
 
 	// Call the Login use case to authenticate the user
 	response, err := c.AuthUseCase.Login(ctx.UserContext(), request)
@@ -42,6 +44,79 @@ func (c *AuthController) Login(ctx *fiber.Ctx) error {
 	})
 }
 
+func (c *AuthController) RequestLoginOTP(ctx *fiber.Ctx) error {
+	// Parse the OTP request from the request body
+	request := new(dto.PhoneLoginOTPRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the RequestLoginOTP use case to text a sign-in code
+	response, err := c.AuthUseCase.RequestLoginOTP(ctx.UserContext(), request)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the reference ID as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.RegisterResponse]{
+		Status:  true,
+		Message: "If the number is registered, a sign-in code has been sent",
+		Data:    &response,
+	})
+}
+
+func (c *AuthController) LoginWithOTP(ctx *fiber.Ctx) error {
+	// Parse the login request from the request body
+	request := new(dto.PhoneLoginRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the LoginWithOTP use case to open a session
+	response, err := c.AuthUseCase.LoginWithOTP(ctx.UserContext(), request)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the login response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.LoginResponse]{
+		Status:  true,
+		Message: "Login successful",
+		Data:    &response,
+	})
+}
+
+func (c *AuthController) VerifyTwoFactor(ctx *fiber.Ctx) error {
+	// Parse the two-factor request from the request body
+	request := new(dto.VerifyTwoFactorRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the VerifyTwoFactor use case to complete the login
+	response, err := c.AuthUseCase.VerifyTwoFactor(ctx.UserContext(), request)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the login response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.LoginResponse]{
+		Status:  true,
+		Message: "Login successful",
+		Data:    &response,
+	})
+}
+
 func (c *AuthController) Register(ctx *fiber.Ctx) error {
 	// Parse the registration request from the request body
 	request := new(dto.RegisterRequest)
@@ -66,11 +141,12 @@ func (c *AuthController) Register(ctx *fiber.Ctx) error {
 }
 
 func (c *AuthController) Logout(ctx *fiber.Ctx) error {
-	// Extract user ID from the context
+	// Extract user and session ID from the context
 	userID := ctx.Locals("userId").(int64)
+	sessionID := ctx.Locals("sessionId").(int64)
 
-	// Call the Logout use case to log the user out
-	err := c.AuthUseCase.Logout(ctx.UserContext(), userID)
+	// Call the Logout use case to close the current session
+	err := c.AuthUseCase.Logout(ctx.UserContext(), userID, sessionID)
 	if err != nil {
 		// Return the error from the use case
 		return err
@@ -90,6 +166,8 @@ func (c *AuthController) Refresh(ctx *fiber.Ctx) error {
 		// Return a bad request error if parsing fails
 		return fiber.ErrBadRequest
 	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
 
 	// Call the Refresh use case to refresh the tokens
 	response, err := c.AuthUseCase.Refresh(ctx.UserContext(), request)
@@ -113,6 +191,8 @@ func (c *AuthController) EmailVerification(ctx *fiber.Ctx) error {
 		// Return a bad request error if parsing fails
 		return fiber.ErrBadRequest
 	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
 
 	// Call the EmailVerification use case to verify the email
 	response, err := c.AuthUseCase.EmailVerification(ctx.UserContext(), request)
@@ -128,3 +208,170 @@ func (c *AuthController) EmailVerification(ctx *fiber.Ctx) error {
 		Data:    &response,
 	})
 }
+
+func (c *AuthController) ResendOTP(ctx *fiber.Ctx) error {
+	// Parse the resend request from the request body
+	request := new(dto.ResendOTPRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the ResendOTP use case to send a new code
+	response, err := c.AuthUseCase.ResendOTP(ctx.UserContext(), request)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the new reference ID as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.RegisterResponse]{
+		Status:  true,
+		Message: "Verification code sent",
+		Data:    &response,
+	})
+}
+
+func (c *AuthController) SendPhoneVerification(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the SendPhoneVerification use case to text a code
+	if err := c.AuthUseCase.SendPhoneVerification(ctx.UserContext(), userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Verification code sent",
+	})
+}
+
+func (c *AuthController) VerifyPhone(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the verification request from the request body
+	request := new(dto.VerifyPhoneRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the VerifyPhone use case to mark the number as verified
+	if err := c.AuthUseCase.VerifyPhone(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Phone number verified",
+	})
+}
+
+func (c *AuthController) GetSessions(ctx *fiber.Ctx) error {
+	// Extract user and session ID from the context
+	userID := ctx.Locals("userId").(int64)
+	sessionID := ctx.Locals("sessionId").(int64)
+
+	// Call the FindSessions use case to list the active sessions
+	result, err := c.AuthUseCase.FindSessions(ctx.UserContext(), userID, sessionID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the sessions as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.SessionData]{
+		Status:  true,
+		Message: "Sessions retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *AuthController) RevokeSession(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the session ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the RevokeSession use case to sign the device out
+	if err := c.AuthUseCase.RevokeSession(ctx.UserContext(), userID, int64(id)); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the revoke response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Session revoked successfully",
+	})
+}
+
+func (c *AuthController) RevokeAllSessions(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the RevokeAllSessions use case to sign every device out
+	if err := c.AuthUseCase.RevokeAllSessions(ctx.UserContext(), userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the revoke response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "All sessions revoked successfully",
+	})
+}
+
+func (c *AuthController) ForgotPassword(ctx *fiber.Ctx) error {
+	// Parse the forgot password request from the request body
+	request := new(dto.ForgotPasswordRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the ForgotPassword use case to send the reset email
+	if err := c.AuthUseCase.ForgotPassword(ctx.UserContext(), request); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the same response whether or not the account exists
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "If the email is registered, password reset instructions have been sent",
+	})
+}
+
+func (c *AuthController) ResetPassword(ctx *fiber.Ctx) error {
+	// Parse the reset password request from the request body
+	request := new(dto.ResetPasswordRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the ResetPassword use case to set the new password
+	if err := c.AuthUseCase.ResetPassword(ctx.UserContext(), request); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the reset response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Password reset successfully",
+	})
+}
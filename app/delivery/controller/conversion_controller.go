@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type ConversionController struct {
+	ConversionUseCase domain.ConversionUseCase
+	Log               *logrus.Logger
+}
+
+func NewConversionController(conversionUseCase domain.ConversionUseCase, log *logrus.Logger) *ConversionController {
+	return &ConversionController{
+		ConversionUseCase: conversionUseCase,
+		Log:               log,
+	}
+}
+
+func (c *ConversionController) Quote(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the quote request from the request body
+	request := new(dto.ConversionQuoteRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the Quote use case to price the conversion
+	response, err := c.ConversionUseCase.Quote(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the quote as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.ConversionQuoteResponse]{
+		Status:  true,
+		Message: "Conversion quoted successfully",
+		Data:    &response,
+	})
+}
+
+func (c *ConversionController) Execute(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the execute request from the request body
+	request := new(dto.ConversionExecuteRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the Execute use case to convert the amount
+	response, err := c.ConversionUseCase.Execute(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the conversion as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.ConversionData]{
+		Status:  true,
+		Message: "Conversion executed successfully",
+		Data:    &response,
+	})
+}
+
+func (c *ConversionController) FindHistory(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the paging from the query string
+	request := new(dto.ConversionListRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the FindHistory use case to list past conversions
+	result, paging, err := c.ConversionUseCase.FindHistory(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the conversions as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.ConversionData]{
+		Status:  true,
+		Message: "Conversions retrieved successfully",
+		Data:    &result,
+		Paging:  paging,
+	})
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type DeviceTokenController struct {
+	DeviceTokenUseCase domain.DeviceTokenUseCase
+	Log                *logrus.Logger
+}
+
+func NewDeviceTokenController(deviceTokenUseCase domain.DeviceTokenUseCase, log *logrus.Logger) *DeviceTokenController {
+	return &DeviceTokenController{
+		DeviceTokenUseCase: deviceTokenUseCase,
+		Log:                log,
+	}
+}
+
+func (d *DeviceTokenController) Register(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the device token request from the request body
+	request := new(dto.RegisterDeviceTokenRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the Register use case to store the device token
+	response, err := d.DeviceTokenUseCase.Register(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the registered device as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.DeviceTokenData]{
+		Status:  true,
+		Message: "Device registered successfully",
+		Data:    &response,
+	})
+}
+
+func (d *DeviceTokenController) GetUserDevices(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the FindByUserID use case to list the user's devices
+	result, err := d.DeviceTokenUseCase.FindByUserID(ctx.UserContext(), userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the devices as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.DeviceTokenData]{
+		Status:  true,
+		Message: "User devices retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (d *DeviceTokenController) Unregister(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the device ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the Unregister use case to remove the device token
+	if err := d.DeviceTokenUseCase.Unregister(ctx.UserContext(), int64(id), userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the unregister response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Device unregistered successfully",
+	})
+}
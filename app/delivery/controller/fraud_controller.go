@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type FraudController struct {
+	FraudUseCase domain.FraudUseCase
+	Log          *logrus.Logger
+}
+
+func NewFraudController(fraudUseCase domain.FraudUseCase, log *logrus.Logger) *FraudController {
+	return &FraudController{
+		FraudUseCase: fraudUseCase,
+		Log:          log,
+	}
+}
+
+func (f *FraudController) FindRules(ctx *fiber.Ctx) error {
+	// Call the FindRules use case to list the rules
+	result, err := f.FraudUseCase.FindRules(ctx.UserContext())
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the rules as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.FraudRuleData]{
+		Status:  true,
+		Message: "Rules retrieved successfully",
+		Data:    &result,
+	})
+}
+
+func (f *FraudController) UpdateRule(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the rule ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the changes from the request body
+	request := new(dto.UpdateFraudRuleRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the UpdateRule use case to save the rule
+	result, err := f.FraudUseCase.UpdateRule(ctx.UserContext(), request, int64(id), actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the rule as a JSON object
+	return ctx.JSON(&dto.ApiResponse[dto.FraudRuleData]{
+		Status:  true,
+		Message: "Rule updated successfully",
+		Data:    result,
+	})
+}
+
+func (f *FraudController) FindDecisions(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the filter from the query string
+	request := new(dto.FraudDecisionListRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the FindDecisions use case to list the decisions
+	result, paging, err := f.FraudUseCase.FindDecisions(ctx.UserContext(), request, actorID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the decisions as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.FraudDecisionData]{
+		Status:  true,
+		Message: "Decisions retrieved successfully",
+		Data:    &result,
+		Paging:  paging,
+	})
+}
+
+func (f *FraudController) ReviewDecision(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the decision ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the review from the request body
+	request := new(dto.ReviewFraudDecisionRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the ReviewDecision use case to record the review
+	if err := f.FraudUseCase.ReviewDecision(ctx.UserContext(), request, int64(id), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the review response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Decision reviewed successfully",
+	})
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+)
+
+type JWKSController struct {
+	JWT domain.JWT
+	Log *logrus.Logger
+}
+
+func NewJWKSController(jwt domain.JWT, log *logrus.Logger) *JWKSController {
+	return &JWKSController{
+		JWT: jwt,
+		Log: log,
+	}
+}
+
+func (c *JWKSController) GetKeys(ctx *fiber.Ctx) error {
+	// Let verifiers cache the keyset; rotated keys are published well
+	// before they are used for signing elsewhere
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	// Return the key set as a bare JWKS document, as verifiers expect
+	return ctx.JSON(c.JWT.JWKS())
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"context"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+// kycMaxUploadSize bounds how much of an uploaded file is read; the use case
+// rejects anything over its own, smaller, limit.
+const kycMaxUploadSize = 5<<20 + 1
+
+type KYCController struct {
+	KYCUseCase domain.KYCUseCase
+	Log        *logrus.Logger
+}
+
+func NewKYCController(kycUseCase domain.KYCUseCase, log *logrus.Logger) *KYCController {
+	return &KYCController{
+		KYCUseCase: kycUseCase,
+		Log:        log,
+	}
+}
+
+func (k *KYCController) Submit(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the submission from the multipart form
+	request := new(dto.SubmitKYCRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	var err error
+	if request.IDCard, err = readFormFile(ctx, "id_card"); err != nil {
+		return err
+	}
+	if request.Selfie, err = readFormFile(ctx, "selfie"); err != nil {
+		return err
+	}
+
+	// Call the Submit use case to store the submission for review
+	result, err := k.KYCUseCase.Submit(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the submission as a JSON object
+	return ctx.Status(fiber.StatusCreated).JSON(&dto.ApiResponse[dto.KYCSubmissionData]{
+		Status:  true,
+		Message: "Identity verification submitted successfully",
+		Data:    result,
+	})
+}
+
+func (k *KYCController) GetStatus(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the GetStatus use case to read the KYC level and last submission
+	result, err := k.KYCUseCase.GetStatus(ctx.UserContext(), userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the status as a JSON object
+	return ctx.JSON(&dto.ApiResponse[dto.KYCStatusResponse]{
+		Status:  true,
+		Message: "Identity verification status retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (k *KYCController) FindAll(ctx *fiber.Ctx) error {
+	// Parse the filter from the query string
+	request := new(dto.KYCListRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the FindAll use case to list the submissions
+	result, paging, err := k.KYCUseCase.FindAll(ctx.UserContext(), request)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the submissions as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.KYCSubmissionData]{
+		Status:  true,
+		Message: "Submissions retrieved successfully",
+		Data:    &result,
+		Paging:  paging,
+	})
+}
+
+func (k *KYCController) FindByID(ctx *fiber.Ctx) error {
+	// Parse the submission ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the FindByID use case to read the submission
+	result, err := k.KYCUseCase.FindByID(ctx.UserContext(), int64(id))
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the submission as a JSON object
+	return ctx.JSON(&dto.ApiResponse[dto.KYCSubmissionData]{
+		Status:  true,
+		Message: "Submission retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (k *KYCController) GetDocument(ctx *fiber.Ctx) error {
+	// Parse the submission ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the GetDocument use case to read the stored image
+	file, err := k.KYCUseCase.GetDocument(ctx.UserContext(), int64(id), ctx.Params("document"))
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Identity documents must not be cached by the browser
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set(fiber.HeaderContentType, file.ContentType)
+	return ctx.Send(file.Content)
+}
+
+func (k *KYCController) Approve(ctx *fiber.Ctx) error {
+	return k.review(ctx, k.KYCUseCase.Approve, "Submission approved successfully")
+}
+
+func (k *KYCController) Reject(ctx *fiber.Ctx) error {
+	return k.review(ctx, k.KYCUseCase.Reject, "Submission rejected successfully")
+}
+
+func (k *KYCController) review(ctx *fiber.Ctx, decide func(ctx context.Context, req *dto.ReviewKYCRequest, submissionID int64, reviewerID int64) error, message string) error {
+	// Extract the reviewer's user ID from the context
+	reviewerID := ctx.Locals("userId").(int64)
+
+	// Parse the submission ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the decision from the request body, which an approval may omit
+	request := new(dto.ReviewKYCRequest)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(request); err != nil {
+			// Return a bad request error if parsing fails
+			return fiber.ErrBadRequest
+		}
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the use case to record the decision
+	if err := decide(ctx.UserContext(), request, int64(id), reviewerID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the review response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: message,
+	})
+}
+
+// readFormFile reads an uploaded file from the multipart form, leaving an
+// absent field empty for the use case to report.
+func readFormFile(ctx *fiber.Ctx, field string) ([]byte, error) {
+	header, err := ctx.FormFile(field)
+	if err != nil {
+		return nil, nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, fiber.ErrBadRequest
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, kycMaxUploadSize))
+	if err != nil {
+		return nil, fiber.ErrBadRequest
+	}
+	return content, nil
+}
@@ -38,3 +38,48 @@ func (n *NotificationController) GetUserNotifications(ctx *fiber.Ctx) error {
 		Data:    result,
 	})
 }
+
+func (n *NotificationController) GetPreferences(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the notification use case to read the preferences
+	result, err := n.NotificationUseCase.GetPreferences(ctx.UserContext(), userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the preferences as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.NotificationPreferenceData]{
+		Status:  true,
+		Message: "Notification preferences retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (n *NotificationController) UpdatePreferences(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the preferences request from the request body
+	request := new(dto.UpdateNotificationPreferencesRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the notification use case to update the preferences
+	result, err := n.NotificationUseCase.UpdatePreferences(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the updated preferences as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.NotificationPreferenceData]{
+		Status:  true,
+		Message: "Notification preferences updated successfully",
+		Data:    result,
+	})
+}
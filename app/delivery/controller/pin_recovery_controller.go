@@ -27,6 +27,8 @@ func (p *PinRecoveryController) SetupPin(ctx *fiber.Ctx) error {
 		// Return a bad request error if parsing fails
 		return fiber.ErrBadRequest
 	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
 
 	userID := ctx.Locals("userId").(int64)
 	// Call the Refresh use case to refresh the tokens
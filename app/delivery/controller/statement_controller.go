@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type StatementController struct {
+	StatementUseCase domain.StatementUseCase
+	Log              *logrus.Logger
+}
+
+func NewStatementController(statementUseCase domain.StatementUseCase, log *logrus.Logger) *StatementController {
+	return &StatementController{
+		StatementUseCase: statementUseCase,
+		Log:              log,
+	}
+}
+
+func (s *StatementController) Download(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the statement request from the query string
+	request := new(dto.StatementRequest)
+	if err := ctx.QueryParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the Generate use case to render the statement
+	file, err := s.StatementUseCase.Generate(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the statement as a file download
+	ctx.Attachment(file.Filename)
+	ctx.Set(fiber.HeaderContentType, file.ContentType)
+	return ctx.Send(file.Content)
+}
+
+func (s *StatementController) Email(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the statement request from the request body
+	request := new(dto.StatementRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the Email use case to send the statement
+	if err := s.StatementUseCase.Email(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the email response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Statement sent to your email",
+	})
+}
@@ -103,3 +103,29 @@ func (t *TopUpController) Verify(ctx *fiber.Ctx) error {
 		Message: "TopUp successfully",
 	})
 }
+
+func (t *TopUpController) Release(ctx *fiber.Ctx) error {
+	// Extract the staff member's user ID from the context
+	actorID := ctx.Locals("userId").(int64)
+
+	// Parse the reason from the request body
+	request := new(dto.ReleaseTopUpRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the ReleaseTopUp use case to credit the held top-up
+	if err := t.TopUpUseCase.ReleaseTopUp(ctx.UserContext(), request, ctx.Params("id"), actorID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the release response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Top-up released successfully",
+	})
+}
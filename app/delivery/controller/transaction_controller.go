@@ -29,6 +29,9 @@ func (t *TransactionController) Inquiry(ctx *fiber.Ctx) error {
 		// Return a bad request error if parsing fails
 		return fiber.ErrBadRequest
 	}
+	request.SessionID = ctx.Locals("sessionId").(int64)
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
 
 	// Call the Refresh use case to refresh the tokens
 	response, err := t.TransactionUseCase.TransferInquiry(ctx.UserContext(), request, userID)
@@ -55,6 +58,9 @@ func (t *TransactionController) Execute(ctx *fiber.Ctx) error {
 		// Return a bad request error if parsing fails
 		return fiber.ErrBadRequest
 	}
+	request.SessionID = ctx.Locals("sessionId").(int64)
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
 
 	// Call the Refresh use case to refresh the tokens
 	response, err := t.TransactionUseCase.TransferExecute(ctx.UserContext(), request, userID)
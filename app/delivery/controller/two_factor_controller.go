@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type TwoFactorController struct {
+	TwoFactorUseCase domain.TwoFactorUseCase
+	Log              *logrus.Logger
+}
+
+func NewTwoFactorController(twoFactorUseCase domain.TwoFactorUseCase, log *logrus.Logger) *TwoFactorController {
+	return &TwoFactorController{
+		TwoFactorUseCase: twoFactorUseCase,
+		Log:              log,
+	}
+}
+
+func (t *TwoFactorController) Enroll(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the Enroll use case to create the secret and recovery codes
+	response, err := t.TwoFactorUseCase.Enroll(ctx.UserContext(), userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the enrolment data as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.EnrollTwoFactorResponse]{
+		Status:  true,
+		Message: "Scan the code with your authenticator app, then confirm it",
+		Data:    &response,
+	})
+}
+
+func (t *TwoFactorController) Confirm(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the confirm request from the request body
+	request := new(dto.ConfirmTwoFactorRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the Confirm use case to turn two-factor authentication on
+	if err := t.TwoFactorUseCase.Confirm(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the confirm response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Two-factor authentication enabled",
+	})
+}
+
+func (t *TwoFactorController) Disable(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the disable request from the request body
+	request := new(dto.DisableTwoFactorRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the Disable use case to turn two-factor authentication off
+	if err := t.TwoFactorUseCase.Disable(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the disable response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Two-factor authentication disabled",
+	})
+}
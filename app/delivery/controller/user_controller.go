@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type UserController struct {
+	UserUseCase domain.UserUseCase
+	Log         *logrus.Logger
+}
+
+func NewUserController(userUseCase domain.UserUseCase, log *logrus.Logger) *UserController {
+	return &UserController{
+		UserUseCase: userUseCase,
+		Log:         log,
+	}
+}
+
+func (u *UserController) GetProfile(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the GetProfile use case to load the profile and wallet
+	response, err := u.UserUseCase.GetProfile(ctx.UserContext(), userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the profile as a JSON object
+	return ctx.JSON(&dto.ApiResponse[*dto.ProfileResponse]{
+		Status:  true,
+		Message: "Profile retrieved successfully",
+		Data:    &response,
+	})
+}
+
+func (u *UserController) UpdateProfile(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the profile request from the request body
+	request := new(dto.UpdateProfileRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the UpdateProfile use case to store the changes
+	if err := u.UserUseCase.UpdateProfile(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the update response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Profile updated successfully",
+	})
+}
+
+func (u *UserController) UpdateLanguage(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the language request from the request body
+	request := new(dto.UpdateLanguageRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the UpdateLanguage use case to store the preference
+	if err := u.UserUseCase.UpdateLanguage(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the update response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Language updated successfully",
+	})
+}
+
+func (u *UserController) ChangeEmail(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the email request from the request body
+	request := new(dto.ChangeEmailRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the ChangeEmail use case to send a code to the new address
+	if err := u.UserUseCase.ChangeEmail(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "A verification code has been sent to the new email",
+	})
+}
+
+func (u *UserController) ConfirmEmailChange(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the confirmation request from the request body
+	request := new(dto.ConfirmEmailChangeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the ConfirmEmailChange use case to switch the email
+	if err := u.UserUseCase.ConfirmEmailChange(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Email changed successfully",
+	})
+}
+
+func (u *UserController) ChangePassword(ctx *fiber.Ctx) error {
+	// Extract user and session ID from the context
+	userID := ctx.Locals("userId").(int64)
+	sessionID := ctx.Locals("sessionId").(int64)
+
+	// Parse the password request from the request body
+	request := new(dto.ChangePasswordRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the ChangePassword use case to store the new password
+	if err := u.UserUseCase.ChangePassword(ctx.UserContext(), request, userID, sessionID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Password changed successfully",
+	})
+}
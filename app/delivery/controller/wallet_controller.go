@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+)
+
+type WalletController struct {
+	WalletUseCase domain.WalletUseCase
+	Log           *logrus.Logger
+}
+
+func NewWalletController(walletUseCase domain.WalletUseCase, log *logrus.Logger) *WalletController {
+	return &WalletController{
+		WalletUseCase: walletUseCase,
+		Log:           log,
+	}
+}
+
+func (w *WalletController) GetBalances(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the FindBalances use case to list the wallet's balances
+	result, err := w.WalletUseCase.FindBalances(ctx.UserContext(), userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the balances as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.WalletBalanceData]{
+		Status:  true,
+		Message: "Wallet balances retrieved successfully",
+		Data:    &result,
+	})
+}
+
+func (w *WalletController) OpenBalance(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the open balance request from the request body
+	request := new(dto.OpenBalanceRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Call the OpenBalance use case to add the currency to the wallet
+	response, err := w.WalletUseCase.OpenBalance(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the new balance as a JSON object
+	return ctx.Status(fiber.StatusCreated).JSON(&dto.ApiResponse[*dto.WalletBalanceData]{
+		Status:  true,
+		Message: "Wallet balance opened successfully",
+		Data:    &response,
+	})
+}
+
+func (w *WalletController) GetPockets(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Call the FindPockets use case to list the user's pockets
+	result, err := w.WalletUseCase.FindPockets(ctx.UserContext(), userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the pockets as a JSON object
+	return ctx.JSON(&dto.ApiResponse[[]dto.PocketData]{
+		Status:  true,
+		Message: "Pockets retrieved successfully",
+		Data:    &result,
+	})
+}
+
+func (w *WalletController) OpenPocket(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the pocket request from the request body
+	request := new(dto.PocketRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the OpenPocket use case to create the pocket
+	result, err := w.WalletUseCase.OpenPocket(ctx.UserContext(), request, userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the new pocket as a JSON object
+	return ctx.Status(fiber.StatusCreated).JSON(&dto.ApiResponse[dto.PocketData]{
+		Status:  true,
+		Message: "Pocket opened successfully",
+		Data:    result,
+	})
+}
+
+func (w *WalletController) UpdatePocket(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the pocket ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	// Parse the pocket request from the request body
+	request := new(dto.PocketRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the UpdatePocket use case to edit the pocket
+	result, err := w.WalletUseCase.UpdatePocket(ctx.UserContext(), request, int64(id), userID)
+	if err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the pocket as a JSON object
+	return ctx.JSON(&dto.ApiResponse[dto.PocketData]{
+		Status:  true,
+		Message: "Pocket updated successfully",
+		Data:    result,
+	})
+}
+
+func (w *WalletController) ClosePocket(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the pocket ID from the route
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+
+	request := &dto.ClosePocketRequest{
+		IPAddress: ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
+
+	// Call the ClosePocket use case to close the pocket
+	if err := w.WalletUseCase.ClosePocket(ctx.UserContext(), request, int64(id), userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the close response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Pocket closed successfully",
+	})
+}
+
+func (w *WalletController) MovePocketMoney(ctx *fiber.Ctx) error {
+	// Extract user ID from the context
+	userID := ctx.Locals("userId").(int64)
+
+	// Parse the move request from the request body
+	request := new(dto.MovePocketMoneyRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		// Return a bad request error if parsing fails
+		return fiber.ErrBadRequest
+	}
+	request.IPAddress = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// Call the MovePocketMoney use case to move the money
+	if err := w.WalletUseCase.MovePocketMoney(ctx.UserContext(), request, userID); err != nil {
+		// Return the error from the use case
+		return err
+	}
+
+	// Return the move response as a JSON object
+	return ctx.JSON(&dto.ApiResponse[string]{
+		Status:  true,
+		Message: "Money moved successfully",
+	})
+}
@@ -7,7 +7,7 @@ import (
 	"riz.it/domped/app/domain"
 )
 
-func NewAuthMiddleware(auth domain.JWT) fiber.Handler {
+func NewAuthMiddleware(auth domain.JWT, denylist domain.TokenDenylist) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		splitToken := strings.Split(ctx.Get("Authorization"), "Bearer ")
 		if len(splitToken) < 2 {
@@ -16,12 +16,24 @@ func NewAuthMiddleware(auth domain.JWT) fiber.Handler {
 
 		accessToken := splitToken[1]
 
-		userId, err := auth.ValidateAccessToken(accessToken)
+		claims, err := auth.ValidateAccessToken(accessToken)
 		if err != nil {
 			return fiber.ErrUnauthorized
 		}
 
-		ctx.Locals("userId", userId)
+		// Reject tokens revoked before their expiry, failing closed when
+		// the denylist cannot be reached
+		denied, err := denylist.IsDenied(ctx.UserContext(), claims)
+		if err != nil {
+			return fiber.ErrServiceUnavailable
+		}
+		if denied {
+			return fiber.ErrUnauthorized
+		}
+
+		ctx.Locals("userId", claims.UserID)
+		ctx.Locals("sessionId", claims.SessionID)
+		ctx.Locals("tokenId", claims.ID)
 
 		return ctx.Next()
 	}
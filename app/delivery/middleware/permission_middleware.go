@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type PermissionMiddleware struct {
+	DB             *gorm.DB
+	UserRepository domain.UserRepository
+	Log            *logrus.Logger
+}
+
+func NewPermissionMiddleware(db *gorm.DB, userRepository domain.UserRepository, log *logrus.Logger) *PermissionMiddleware {
+	return &PermissionMiddleware{
+		DB:             db,
+		UserRepository: userRepository,
+		Log:            log,
+	}
+}
+
+// RequirePermission only lets through users whose role grants the
+// permission. The role is loaded on every request, so a change takes effect
+// without waiting for the access token to expire. It must be mounted after
+// the auth middleware.
+func (p *PermissionMiddleware) RequirePermission(permission string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		userID, ok := ctx.Locals("userId").(int64)
+		if !ok {
+			return fiber.ErrUnauthorized
+		}
+
+		user := new(domain.UserEntity)
+		if err := p.UserRepository.FindByID(p.DB.WithContext(ctx.UserContext()), user, userID); err != nil {
+			p.Log.WithError(err).Warnf("Failed to load user role: %+v", err)
+			return fiber.ErrUnauthorized
+		}
+
+		if user.ClosedAt != nil || !domain.HasPermission(user.Role, permission) {
+			return fiber.ErrForbidden
+		}
+
+		ctx.Locals("role", user.Role)
+
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/domain"
+)
+
+type RateLimitMiddleware struct {
+	Limiter domain.RateLimiter
+	JWT     domain.JWT
+	Log     *logrus.Logger
+}
+
+func NewRateLimitMiddleware(limiter domain.RateLimiter, jwt domain.JWT, log *logrus.Logger) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		Limiter: limiter,
+		JWT:     jwt,
+		Log:     log,
+	}
+}
+
+// Global limits every request by client, lenient for reads and tighter for
+// writes. It runs before the auth middleware, so a request is counted per
+// user when it carries a valid access token and per client address
+// otherwise.
+func (r *RateLimitMiddleware) Global() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		policy := domain.RateLimitWrite
+		if ctx.Method() == fiber.MethodGet || ctx.Method() == fiber.MethodHead {
+			policy = domain.RateLimitRead
+		}
+
+		return r.limit(ctx, policy)
+	}
+}
+
+// Limit applies the named policy, counting per user or client address like
+// Global.
+func (r *RateLimitMiddleware) Limit(policy string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		return r.limit(ctx, policy)
+	}
+}
+
+func (r *RateLimitMiddleware) limit(ctx *fiber.Ctx, policy string) error {
+	result, err := r.Limiter.Allow(ctx.UserContext(), policy, r.key(ctx))
+	if err != nil {
+		// Fail open so a Redis outage does not take the API down with it
+		r.Log.WithError(err).Warn("Failed to check rate limit")
+		return ctx.Next()
+	}
+	if result == nil {
+		return ctx.Next()
+	}
+
+	reset := strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+	ctx.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	ctx.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	ctx.Set("RateLimit-Reset", reset)
+
+	if !result.Allowed {
+		ctx.Set(fiber.HeaderRetryAfter, reset)
+		return ctx.Status(fiber.StatusTooManyRequests).JSON(domain.NewError(fiber.StatusTooManyRequests, "Too many requests, please try again later"))
+	}
+
+	return ctx.Next()
+}
+
+// key identifies the client a request is counted against. Users behind a
+// shared address, like a carrier NAT, each get their own count once signed
+// in; the token is only checked for its signature here, the auth middleware
+// still decides whether it is accepted.
+func (r *RateLimitMiddleware) key(ctx *fiber.Ctx) string {
+	if userID, ok := ctx.Locals("userId").(int64); ok {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+
+	if token, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+		if claims, err := r.JWT.ValidateAccessToken(token); err == nil {
+			return "user:" + strconv.FormatInt(claims.UserID, 10)
+		}
+	}
+
+	return "ip:" + ctx.IP()
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"riz.it/domped/app/domain"
+)
+
+const requestIDMaxLength = 64
+
+// NewRequestIDMiddleware tags every request with an ID, reusing the
+// client's X-Request-ID when it is safe to log and generating one
+// otherwise. The ID is echoed in the response and passed on through the
+// user context.
+func NewRequestIDMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		requestID := ctx.Get(fiber.HeaderXRequestID)
+		if !isSafeRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		ctx.Set(fiber.HeaderXRequestID, requestID)
+		ctx.Locals("requestId", requestID)
+		ctx.SetUserContext(domain.WithRequestID(ctx.UserContext(), requestID))
+
+		return ctx.Next()
+	}
+}
+
+func isSafeRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > requestIDMaxLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
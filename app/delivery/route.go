@@ -5,15 +5,20 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"riz.it/domped/app/delivery/controller"
+	"riz.it/domped/app/delivery/middleware"
+	"riz.it/domped/app/domain"
 )
 
 type RouterConfig struct {
 	App *fiber.App
 }
 
-func NewRouter(r *fiber.App, auth fiber.Handler, authController *controller.AuthController, transactionController *controller.TransactionController, pinRecoveryController *controller.PinRecoveryController, notificationController *controller.NotificationController, topUpController *controller.TopUpController, mainController *controller.MainController) *RouterConfig {
+func NewRouter(r *fiber.App, auth fiber.Handler, rateLimit *middleware.RateLimitMiddleware, permission *middleware.PermissionMiddleware, authController *controller.AuthController, jwksController *controller.JWKSController, twoFactorController *controller.TwoFactorController, userController *controller.UserController, accountController *controller.AccountController, kycController *controller.KYCController, adminController *controller.AdminController, auditController *controller.AuditController, fraudController *controller.FraudController, amlController *controller.AMLController, transactionController *controller.TransactionController, pinRecoveryController *controller.PinRecoveryController, notificationController *controller.NotificationController, deviceTokenController *controller.DeviceTokenController, statementController *controller.StatementController, walletController *controller.WalletController, conversionController *controller.ConversionController, topUpController *controller.TopUpController, mainController *controller.MainController) *RouterConfig {
+	// Middleware request ID, first so every log line and audit entry has it
+	r.Use(middleware.NewRequestIDMiddleware())
+
 	// Logger configure
-	logFormat := `{"time": "${time}", "status": "${status}", "latency": "${latency}", "ip": "${ip}", "method": "${method}", "path": "${path}", "error": "${error}"}` + "\n"
+	logFormat := `{"time": "${time}", "status": "${status}", "latency": "${latency}", "request_id": "${respHeader:X-Request-ID}", "ip": "${ip}", "method": "${method}", "path": "${path}", "error": "${error}"}` + "\n"
 
 	logConfig := logger.Config{
 		Format: logFormat,
@@ -24,28 +29,116 @@ func NewRouter(r *fiber.App, auth fiber.Handler, authController *controller.Auth
 	// Middleware CORS
 	r.Use(cors.New())
 
+	// The payment gateway's callbacks come from a few addresses and must
+	// never be refused, so the webhook is routed ahead of the rate limits
+	r.Post("/topup/callback", topUpController.Verify)
+
+	// Middleware rate limit
+	r.Use(rateLimit.Global())
+	r.Use("/auth", rateLimit.Limit(domain.RateLimitAuth))
+	transferLimit := rateLimit.Limit(domain.RateLimitTransfer)
+
 	// Route
 	r.Get("/", mainController.Main)
+	r.Get("/.well-known/jwks.json", jwksController.GetKeys)
 	/// Auth
 	r.Post("/auth/login", authController.Login)
+	r.Post("/auth/login/otp/request", authController.RequestLoginOTP)
+	r.Post("/auth/login/otp", authController.LoginWithOTP)
 	r.Post("/auth/register", authController.Register)
 	r.Post("/auth/refresh", authController.Refresh)
 	r.Delete("/auth/logout", auth, authController.Logout)
 	r.Post("/auth/verify", authController.EmailVerification)
+	r.Post("/auth/verify/resend", authController.ResendOTP)
+	r.Post("/auth/phone/send", auth, authController.SendPhoneVerification)
+	r.Post("/auth/phone/verify", auth, authController.VerifyPhone)
+	r.Post("/auth/password/forgot", authController.ForgotPassword)
+	r.Post("/auth/password/reset", authController.ResetPassword)
+	r.Post("/auth/2fa/verify", authController.VerifyTwoFactor)
+	r.Post("/auth/2fa/enroll", auth, twoFactorController.Enroll)
+	r.Post("/auth/2fa/confirm", auth, twoFactorController.Confirm)
+	r.Post("/auth/2fa/disable", auth, twoFactorController.Disable)
+	r.Get("/auth/sessions", auth, authController.GetSessions)
+	r.Delete("/auth/sessions", auth, authController.RevokeAllSessions)
+	r.Delete("/auth/sessions/:id", auth, authController.RevokeSession)
+
+	/// User
+	r.Get("/me", auth, userController.GetProfile)
+	r.Put("/me", auth, userController.UpdateProfile)
+	r.Put("/me/language", auth, userController.UpdateLanguage)
+	r.Post("/me/email", auth, userController.ChangeEmail)
+	r.Post("/me/email/confirm", auth, userController.ConfirmEmailChange)
+	r.Put("/me/password", auth, userController.ChangePassword)
+	r.Get("/me/export", auth, accountController.Export)
+	r.Post("/me/close", auth, accountController.Close)
+
+	/// KYC
+	r.Post("/kyc", auth, kycController.Submit)
+	r.Get("/kyc", auth, kycController.GetStatus)
+
+	/// Admin
+	admin := r.Group("/admin", auth)
+	can := permission.RequirePermission
+	admin.Get("/users", can(domain.PermissionUserRead), adminController.SearchUsers)
+	admin.Get("/users/:id", can(domain.PermissionUserRead), adminController.GetUser)
+	admin.Put("/users/:id/role", can(domain.PermissionUserRole), adminController.AssignRole)
+	admin.Post("/users/:id/verification/resend", can(domain.PermissionUserVerify), adminController.ResendVerification)
+	admin.Get("/users/:id/wallet", can(domain.PermissionWalletRead), adminController.GetWallet)
+	admin.Post("/users/:id/wallet/freeze", can(domain.PermissionWalletFreeze), adminController.FreezeWallet)
+	admin.Post("/users/:id/wallet/unfreeze", can(domain.PermissionWalletFreeze), adminController.UnfreezeWallet)
+	admin.Get("/users/:id/transactions", can(domain.PermissionTransactionRead), adminController.FindTransactions)
+	admin.Post("/topups/:id/release", can(domain.PermissionTopUpRelease), topUpController.Release)
+	admin.Get("/kyc", can(domain.PermissionKYCReview), kycController.FindAll)
+	admin.Get("/kyc/:id", can(domain.PermissionKYCReview), kycController.FindByID)
+	admin.Get("/kyc/:id/documents/:document", can(domain.PermissionKYCReview), kycController.GetDocument)
+	admin.Post("/kyc/:id/approve", can(domain.PermissionKYCReview), kycController.Approve)
+	admin.Post("/kyc/:id/reject", can(domain.PermissionKYCReview), kycController.Reject)
+	admin.Get("/audit-logs", can(domain.PermissionAuditRead), auditController.FindAll)
+	admin.Get("/audit-logs/verify", can(domain.PermissionAuditRead), auditController.Verify)
+	admin.Get("/fraud/rules", can(domain.PermissionFraudReview), fraudController.FindRules)
+	admin.Put("/fraud/rules/:id", can(domain.PermissionFraudRules), fraudController.UpdateRule)
+	admin.Get("/fraud/decisions", can(domain.PermissionFraudReview), fraudController.FindDecisions)
+	admin.Post("/fraud/decisions/:id/review", can(domain.PermissionFraudReview), fraudController.ReviewDecision)
+	admin.Get("/aml/alerts", can(domain.PermissionAMLReview), amlController.FindAlerts)
+	admin.Get("/aml/alerts/:id", can(domain.PermissionAMLReview), amlController.GetAlert)
+	admin.Post("/aml/alerts/:id/assign", can(domain.PermissionAMLReview), amlController.Assign)
+	admin.Post("/aml/alerts/:id/notes", can(domain.PermissionAMLReview), amlController.AddNote)
+	admin.Post("/aml/alerts/:id/close", can(domain.PermissionAMLReview), amlController.Close)
+	admin.Get("/aml/reports", can(domain.PermissionAMLReview), amlController.ExportReports)
+
+	/// Wallet
+	r.Get("/wallet/balances", auth, walletController.GetBalances)
+	r.Post("/wallet/balances", auth, walletController.OpenBalance)
+	r.Get("/wallet/conversions", auth, conversionController.FindHistory)
+	r.Post("/wallet/conversions/quote", auth, conversionController.Quote)
+	r.Post("/wallet/conversions/execute", auth, transferLimit, conversionController.Execute)
+	r.Get("/wallet/pockets", auth, walletController.GetPockets)
+	r.Post("/wallet/pockets", auth, walletController.OpenPocket)
+	r.Post("/wallet/pockets/move", auth, walletController.MovePocketMoney)
+	r.Put("/wallet/pockets/:id", auth, walletController.UpdatePocket)
+	r.Delete("/wallet/pockets/:id", auth, walletController.ClosePocket)
 
 	/// Pin Recovery
 	r.Post("/wallet/pin/recovery", auth, pinRecoveryController.SetupPin)
 
 	/// Notification
 	r.Get("/notifications", auth, notificationController.GetUserNotifications)
+	r.Get("/notifications/preferences", auth, notificationController.GetPreferences)
+	r.Put("/notifications/preferences", auth, notificationController.UpdatePreferences)
+	r.Get("/notifications/devices", auth, deviceTokenController.GetUserDevices)
+	r.Post("/notifications/devices", auth, deviceTokenController.Register)
+	r.Delete("/notifications/devices/:id", auth, deviceTokenController.Unregister)
 
 	/// Transaction
-	r.Post("/transaction/transfer/inquiry", auth, transactionController.Inquiry)
-	r.Post("/transaction/transfer/execute", auth, transactionController.Execute)
+	r.Post("/transaction/transfer/inquiry", auth, transferLimit, transactionController.Inquiry)
+	r.Post("/transaction/transfer/execute", auth, transferLimit, transactionController.Execute)
+
+	/// Statement
+	r.Get("/wallet/statements", auth, statementController.Download)
+	r.Post("/wallet/statements/email", auth, statementController.Email)
 
 	/// TopUp
 	r.Post("/topup/initialize", auth, topUpController.Initialize)
-	r.Post("/topup/callback", topUpController.Verify)
 
 	// Mengembalikan RouterConfig
 	return &RouterConfig{
@@ -0,0 +1,51 @@
+package delivery
+
+import (
+	"context"
+	"time"
+
+	"riz.it/domped/app/domain"
+)
+
+// Worker is a long-running background process started alongside the HTTP
+// server.
+type Worker interface {
+	Run(ctx context.Context)
+}
+
+type WorkerConfig struct {
+	Workers []Worker
+}
+
+func NewWorker(emailQueue domain.EmailQueue, statementUseCase domain.StatementUseCase, amlUseCase domain.AMLUseCase, auditUseCase domain.AuditUseCase, jwt domain.JWT) *WorkerConfig {
+	return &WorkerConfig{
+		Workers: []Worker{
+			emailQueue,
+			&ScheduledWorker{Interval: time.Hour, Job: statementUseCase.SendMonthlyStatements},
+			&ScheduledWorker{Interval: time.Hour, Job: amlUseCase.Scan},
+			&ScheduledWorker{Interval: 5 * time.Second, Job: auditUseCase.Chain},
+			&ScheduledWorker{Interval: time.Hour, Job: jwt.RotateKeys},
+		},
+	}
+}
+
+// ScheduledWorker runs Job immediately and then once every Interval.
+type ScheduledWorker struct {
+	Interval time.Duration
+	Job      func(ctx context.Context)
+}
+
+func (s *ScheduledWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.Job(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+
+	"riz.it/domped/app/dto"
+)
+
+// Interface
+type AccountUseCase interface {
+	Close(ctx context.Context, req *dto.CloseAccountRequest, userID int64) error
+	Export(ctx context.Context, req *dto.ExportAccountRequest, userID int64) (*dto.AccountExportFile, error)
+}
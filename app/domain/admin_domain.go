@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+
+	"riz.it/domped/app/dto"
+)
+
+// Interface
+// AdminUseCase backs the staff endpoints. Every call is recorded in the
+// audit log with the staff member as the actor, reads included.
+type AdminUseCase interface {
+	SearchUsers(ctx context.Context, req *dto.AdminUserSearchRequest, actorID int64) ([]dto.UserData, *dto.PageMetadata, error)
+	GetUser(ctx context.Context, req *dto.AdminRequest, userID int64, actorID int64) (*dto.ProfileResponse, error)
+	GetWallet(ctx context.Context, req *dto.AdminRequest, userID int64, actorID int64) (*dto.WalletData, error)
+	FindTransactions(ctx context.Context, req *dto.AdminTransactionListRequest, userID int64, actorID int64) ([]dto.TransactionData, *dto.PageMetadata, error)
+	FreezeWallet(ctx context.Context, req *dto.FreezeWalletRequest, userID int64, actorID int64) error
+	UnfreezeWallet(ctx context.Context, req *dto.UnfreezeWalletRequest, userID int64, actorID int64) error
+	ResendVerification(ctx context.Context, req *dto.AdminRequest, userID int64, actorID int64) error
+	AssignRole(ctx context.Context, req *dto.AssignRoleRequest, userID int64, actorID int64) error
+}
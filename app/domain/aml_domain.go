@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// AML alert types
+const (
+	// AMLStructuring is a run of amounts kept just below the reporting
+	// threshold
+	AMLStructuring = "structuring"
+	// AMLCircularFlow is money that travels through other wallets back to
+	// where it started
+	AMLCircularFlow = "circular_flow"
+	// AMLDormantReactivation is a large movement on a wallet that had been
+	// idle for a long time
+	AMLDormantReactivation = "dormant_reactivation"
+)
+
+// AML alert statuses. An alert is open until someone is assigned to it and
+// stays under investigation until it is closed with a resolution.
+const (
+	AMLStatusOpen          = "open"
+	AMLStatusInvestigating = "investigating"
+	AMLStatusClosed        = "closed"
+)
+
+// AML alert resolutions. Only reported alerts go into the suspicious
+// activity report export.
+const (
+	AMLResolutionReported      = "reported"
+	AMLResolutionNoAction      = "no_action"
+	AMLResolutionFalsePositive = "false_positive"
+)
+
+// Entity
+type AMLAlertEntity struct {
+	ID          int64      `gorm:"column:id;primaryKey"`
+	Type        string     `gorm:"column:type"`
+	Fingerprint string     `gorm:"column:fingerprint"`
+	UserID      int64      `gorm:"column:user_id"`
+	WalletID    int64      `gorm:"column:wallet_id"`
+	Amount      int64      `gorm:"column:amount"`
+	Details     string     `gorm:"column:details;type:jsonb"`
+	Status      string     `gorm:"column:status"`
+	AssignedTo  *int64     `gorm:"column:assigned_to"`
+	Resolution  *string    `gorm:"column:resolution"`
+	ClosedBy    *int64     `gorm:"column:closed_by"`
+	ClosedAt    *time.Time `gorm:"column:closed_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (AMLAlertEntity) TableName() string {
+	return "public.aml_alerts"
+}
+
+type AMLAlertNoteEntity struct {
+	ID        int64     `gorm:"column:id;primaryKey"`
+	AlertID   int64     `gorm:"column:alert_id"`
+	AuthorID  int64     `gorm:"column:author_id"`
+	Note      string    `gorm:"column:note"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (AMLAlertNoteEntity) TableName() string {
+	return "public.aml_alert_notes"
+}
+
+// AMLAlertFilter narrows an alert query; zero fields match everything.
+type AMLAlertFilter struct {
+	Status     string
+	Type       string
+	AssignedTo int64
+	UserID     int64
+}
+
+// SuspiciousActivityReport is one row of the report export: a reported
+// alert together with who it is about.
+type SuspiciousActivityReport struct {
+	Alert        AMLAlertEntity
+	FullName     string
+	Email        string
+	Phone        string
+	NIK          string
+	WalletNumber string
+	Notes        []string
+}
+
+// Interface
+type AMLAlertRepository interface {
+	FindByID(db *gorm.DB, alert *AMLAlertEntity, id int64) error
+	Update(db *gorm.DB, alert *AMLAlertEntity) error
+
+	// Custom functions
+	// CreateIfNew stores the alert unless one with the same fingerprint
+	// exists, reporting whether it was stored.
+	CreateIfNew(db *gorm.DB, alert *AMLAlertEntity) (bool, error)
+	FindPage(db *gorm.DB, alerts *[]AMLAlertEntity, filter *AMLAlertFilter, page int, size int) (total int64, err error)
+	FindClosedBetween(db *gorm.DB, alerts *[]AMLAlertEntity, resolution string, from, to time.Time) error
+}
+
+type AMLAlertNoteRepository interface {
+	Create(db *gorm.DB, note *AMLAlertNoteEntity) error
+
+	// Custom functions
+	FindByAlertID(db *gorm.DB, notes *[]AMLAlertNoteEntity, alertID int64) error
+}
+
+type AMLUseCase interface {
+	// Scan looks for suspicious activity and raises alerts, at most once an
+	// hour across all instances.
+	Scan(ctx context.Context)
+
+	// Case management
+	FindAlerts(ctx context.Context, req *dto.AMLAlertListRequest, actorID int64) ([]dto.AMLAlertData, *dto.PageMetadata, error)
+	GetAlert(ctx context.Context, req *dto.AdminRequest, alertID int64, actorID int64) (*dto.AMLAlertDetailResponse, error)
+	Assign(ctx context.Context, req *dto.AssignAMLAlertRequest, alertID int64, actorID int64) error
+	AddNote(ctx context.Context, req *dto.AMLAlertNoteRequest, alertID int64, actorID int64) error
+	Close(ctx context.Context, req *dto.CloseAMLAlertRequest, alertID int64, actorID int64) error
+	ExportReports(ctx context.Context, req *dto.AMLReportRequest, actorID int64) (*dto.AMLReportFile, error)
+}
@@ -0,0 +1,217 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// Audit actions
+const (
+	AuditProfileUpdated       = "profile.updated"
+	AuditEmailChangeRequested = "email.change_requested"
+	AuditEmailChanged         = "email.changed"
+	AuditPasswordChanged      = "password.changed"
+	AuditAccountClosed        = "account.closed"
+	AuditDataExported         = "account.data_exported"
+	AuditKYCSubmitted         = "kyc.submitted"
+	AuditKYCApproved          = "kyc.approved"
+	AuditKYCRejected          = "kyc.rejected"
+	AuditWalletFrozen         = "wallet.frozen"
+	AuditWalletUnfrozen       = "wallet.unfrozen"
+	AuditRoleAssigned         = "user.role_assigned"
+	AuditFraudRuleUpdated     = "fraud.rule_updated"
+	AuditFraudReviewed        = "fraud.decision_reviewed"
+	AuditAMLAlertAssigned     = "aml.alert_assigned"
+	AuditAMLNoteAdded         = "aml.note_added"
+	AuditAMLAlertClosed       = "aml.alert_closed"
+
+	// Staff access to a user's data
+	AuditAdminUsersSearched      = "admin.users_searched"
+	AuditAdminUserViewed         = "admin.user_viewed"
+	AuditAdminWalletViewed       = "admin.wallet_viewed"
+	AuditAdminTransactionsViewed = "admin.transactions_viewed"
+	AuditAdminVerificationSent   = "admin.verification_resent"
+	AuditAdminAuditSearched      = "admin.audit_searched"
+	AuditAdminAuditVerified      = "admin.audit_verified"
+	AuditAdminFraudSearched      = "admin.fraud_decisions_searched"
+	AuditAdminAMLSearched        = "admin.aml_alerts_searched"
+	AuditAdminAMLViewed          = "admin.aml_alert_viewed"
+	AuditAdminAMLReportExported  = "admin.aml_report_exported"
+
+	// Security and money events
+	AuditLogin          = "auth.login"
+	AuditLoginFailed    = "auth.login_failed"
+	AuditPinChanged     = "wallet.pin_changed"
+	AuditTransfer       = "transfer.executed"
+	AuditConversion     = "conversion.executed"
+	AuditPocketOpened   = "pocket.opened"
+	AuditPocketUpdated  = "pocket.updated"
+	AuditPocketClosed   = "pocket.closed"
+	AuditPocketMoved    = "pocket.money_moved"
+	AuditTopUpConfirmed = "topup.confirmed"
+	AuditTopUpHeld      = "topup.held"
+	AuditTopUpReleased  = "topup.released"
+)
+
+// Audit targets, the kind of object an entry is about
+const (
+	AuditTargetUser        = "user"
+	AuditTargetSession     = "session"
+	AuditTargetWallet      = "wallet"
+	AuditTargetTransaction = "transaction"
+	AuditTargetTopUp       = "topup"
+	AuditTargetKYC         = "kyc_submission"
+	AuditTargetFraudRule   = "fraud_rule"
+	AuditTargetFraudCase   = "fraud_decision"
+	AuditTargetAMLAlert    = "aml_alert"
+	AuditTargetConversion  = "conversion"
+)
+
+// Entity
+// AuditLogEntity records a change to an account. UserID is the account the
+// change applies to and ActorID whoever made it; they differ when staff act
+// on a user's behalf. Entries are never updated or deleted. Once linked into
+// the chain, each one carries the hash of the entry before it so a change to
+// the history shows; ChainSeq is its position there.
+type AuditLogEntity struct {
+	ID         int64     `gorm:"column:id;primaryKey"`
+	UserID     *int64    `gorm:"column:user_id"`
+	ActorID    *int64    `gorm:"column:actor_id"`
+	Action     string    `gorm:"column:action"`
+	TargetType string    `gorm:"column:target_type"`
+	TargetID   string    `gorm:"column:target_id"`
+	RequestID  string    `gorm:"column:request_id"`
+	Metadata   string    `gorm:"column:metadata;type:jsonb"`
+	Before     *string   `gorm:"column:before;type:jsonb"`
+	After      *string   `gorm:"column:after;type:jsonb"`
+	IPAddress  string    `gorm:"column:ip_address"`
+	UserAgent  string    `gorm:"column:user_agent"`
+	PrevHash   *string   `gorm:"column:prev_hash"`
+	Hash       *string   `gorm:"column:hash"`
+	ChainSeq   *int64    `gorm:"column:chain_seq"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (AuditLogEntity) TableName() string {
+	return "public.audit_logs"
+}
+
+// ComputeHash returns the hash linking the entry to prevHash. JSON columns
+// are hashed in a canonical form, since the database does not keep them as
+// written.
+func (e *AuditLogEntity) ComputeHash(prevHash string) (string, error) {
+	metadata, err := canonicalJSON(&e.Metadata)
+	if err != nil {
+		return "", err
+	}
+	before, err := canonicalJSON(e.Before)
+	if err != nil {
+		return "", err
+	}
+	after, err := canonicalJSON(e.After)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal([]any{
+		prevHash,
+		e.UserID,
+		e.ActorID,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.RequestID,
+		metadata,
+		before,
+		after,
+		e.IPAddress,
+		e.UserAgent,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON re-encodes a JSON document with sorted keys and no
+// whitespace, keeping numbers exactly as written.
+func canonicalJSON(raw *string) (string, error) {
+	if raw == nil || *raw == "" {
+		return "", nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(*raw))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// AuditEvent describes a change to record. Before and After are snapshots
+// of the target around the change.
+type AuditEvent struct {
+	UserID     int64
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   string
+	Metadata   map[string]any
+	Before     any
+	After      any
+	IPAddress  string
+	UserAgent  string
+}
+
+// AuditLogFilter narrows an audit log query; zero fields match everything.
+type AuditLogFilter struct {
+	UserID     int64
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// Interface
+type AuditLogRepository interface {
+	Create(db *gorm.DB, log *AuditLogEntity) error
+
+	// Custom functions
+	FindLatest(db *gorm.DB, log *AuditLogEntity) error
+	FindPage(db *gorm.DB, logs *[]AuditLogEntity, filter *AuditLogFilter, page int, size int) (total int64, err error)
+	FindChainAfter(db *gorm.DB, logs *[]AuditLogEntity, afterSeq int64, limit int) error
+	FindUnchained(db *gorm.DB, logs *[]AuditLogEntity, limit int) error
+	Link(db *gorm.DB, log *AuditLogEntity) error
+}
+
+type AuditUseCase interface {
+	// Record stores the event using db, so it can be written in the same
+	// transaction as the change it describes. The entry is linked into the
+	// hash chain later by Chain.
+	Record(ctx context.Context, db *gorm.DB, event *AuditEvent) error
+	// Chain links the entries recorded since the last run into the hash
+	// chain. Runs on several instances wait for each other.
+	Chain(ctx context.Context)
+	FindAll(ctx context.Context, req *dto.AuditLogListRequest, actorID int64) ([]dto.AuditLogData, *dto.PageMetadata, error)
+	Verify(ctx context.Context, req *dto.AdminRequest, actorID int64) (*dto.AuditVerifyResponse, error)
+}
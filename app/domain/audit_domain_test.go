@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditLogComputeHash(t *testing.T) {
+	userID := int64(7)
+	newEntry := func() *AuditLogEntity {
+		before := `{"balance": 100, "status": "active"}`
+		return &AuditLogEntity{
+			UserID:    &userID,
+			ActorID:   &userID,
+			Action:    AuditTransfer,
+			Metadata:  `{"amount": 50, "dof_number": "1234567890"}`,
+			Before:    &before,
+			IPAddress: "127.0.0.1",
+			UserAgent: "test",
+			CreatedAt: time.Date(2025, time.February, 1, 8, 30, 0, 123456000, time.UTC),
+		}
+	}
+
+	want, err := newEntry().ComputeHash("")
+	if err != nil {
+		t.Fatalf("ComputeHash() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*AuditLogEntity)
+		prevHash string
+		same     bool
+	}{
+		{"unchanged", func(*AuditLogEntity) {}, "", true},
+		{"reordered metadata", func(e *AuditLogEntity) { e.Metadata = `{"dof_number":"1234567890","amount":50}` }, "", true},
+		{"created at in another zone", func(e *AuditLogEntity) { e.CreatedAt = e.CreatedAt.In(time.FixedZone("WIB", 7*60*60)) }, "", true},
+		{"other predecessor", func(*AuditLogEntity) {}, "0000000000000000000000000000000000000000000000000000000000000000", false},
+		{"changed metadata", func(e *AuditLogEntity) { e.Metadata = `{"amount": 51, "dof_number": "1234567890"}` }, "", false},
+		{"changed snapshot", func(e *AuditLogEntity) { e.Before = nil }, "", false},
+		{"changed action", func(e *AuditLogEntity) { e.Action = AuditLogin }, "", false},
+		{"changed actor", func(e *AuditLogEntity) { e.ActorID = nil }, "", false},
+		{"changed time", func(e *AuditLogEntity) { e.CreatedAt = e.CreatedAt.Add(time.Microsecond) }, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := newEntry()
+			tt.modify(entry)
+
+			got, err := entry.ComputeHash(tt.prevHash)
+			if err != nil {
+				t.Fatalf("ComputeHash() error = %v", err)
+			}
+			if (got == want) != tt.same {
+				t.Errorf("ComputeHash() = %s, original %s, want same = %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestAuditLogComputeHashInvalidJSON(t *testing.T) {
+	entry := &AuditLogEntity{Action: AuditTransfer, Metadata: `{"amount":`}
+	if _, err := entry.ComputeHash(""); err == nil {
+		t.Error("ComputeHash() accepted unreadable metadata")
+	}
+}
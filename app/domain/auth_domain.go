@@ -10,7 +10,27 @@ import (
 type AuthUseCase interface {
 	Register(ctx context.Context, req *dto.RegisterRequest) (*dto.RegisterResponse, error)
 	EmailVerification(ctx context.Context, req *dto.EmailVerificationRequest) (*dto.LoginResponse, error)
+	ResendOTP(ctx context.Context, req *dto.ResendOTPRequest) (*dto.RegisterResponse, error)
+	// SendVerification emails a new verification code for an unverified
+	// account, for staff acting on a user's behalf. The reference ID is
+	// only ever given to the user.
+	SendVerification(ctx context.Context, userID int64) error
 	Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error)
-	Logout(ctx context.Context, userID int64) error
+	RequestLoginOTP(ctx context.Context, req *dto.PhoneLoginOTPRequest) (*dto.RegisterResponse, error)
+	LoginWithOTP(ctx context.Context, req *dto.PhoneLoginRequest) (*dto.LoginResponse, error)
+	VerifyTwoFactor(ctx context.Context, req *dto.VerifyTwoFactorRequest) (*dto.LoginResponse, error)
+	Logout(ctx context.Context, userID int64, sessionID int64) error
 	Refresh(ctx context.Context, req *dto.RefreshTokenRequest) (*dto.LoginResponse, error)
+	FindSessions(ctx context.Context, userID int64, currentSessionID int64) (*[]dto.SessionData, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID int64) error
+	RevokeAllSessions(ctx context.Context, userID int64) error
+	ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error
+	SendPhoneVerification(ctx context.Context, userID int64) error
+	VerifyPhone(ctx context.Context, req *dto.VerifyPhoneRequest, userID int64) error
+	// CheckLockout, RecordFailure and ClearFailures hold a signed-in user
+	// re-entering a password or second factor to the login lockout.
+	CheckLockout(ctx context.Context, user *UserEntity, ipAddress string) error
+	RecordFailure(ctx context.Context, user *UserEntity, ipAddress string, userAgent string)
+	ClearFailures(ctx context.Context, user *UserEntity)
 }
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// ConversionNumber is the source- or destination-of-fund number recorded
+// for the two legs of a currency conversion.
+const ConversionNumber = "01"
+
+// Entity
+type ConversionEntity struct {
+	ID           int64     `gorm:"column:id;primaryKey"`
+	UserID       int64     `gorm:"column:user_id"`
+	WalletID     int64     `gorm:"column:wallet_id"`
+	FromCurrency string    `gorm:"column:from_currency"`
+	ToCurrency   string    `gorm:"column:to_currency"`
+	FromAmount   int64     `gorm:"column:from_amount"`
+	ToAmount     int64     `gorm:"column:to_amount"`
+	Rate         string    `gorm:"column:rate"`
+	SpreadAmount int64     `gorm:"column:spread_amount"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (ConversionEntity) TableName() string {
+	return "public.conversions"
+}
+
+// FXRates looks exchange rates up.
+type FXRates interface {
+	// Rate returns how many units of quote one unit of base is worth at the
+	// mid-market rate, both in major units.
+	Rate(ctx context.Context, base, quote string) (*big.Rat, error)
+}
+
+// Interface
+type ConversionRepository interface {
+	Create(db *gorm.DB, conversion *ConversionEntity) error
+
+	// Custom functions
+	FindPageByWalletID(db *gorm.DB, conversions *[]ConversionEntity, walletID int64, page int, size int) (total int64, err error)
+}
+
+type ConversionUseCase interface {
+	Quote(ctx context.Context, req *dto.ConversionQuoteRequest, userID int64) (*dto.ConversionQuoteResponse, error)
+	Execute(ctx context.Context, req *dto.ConversionExecuteRequest, userID int64) (*dto.ConversionData, error)
+	FindHistory(ctx context.Context, req *dto.ConversionListRequest, userID int64) ([]dto.ConversionData, *dto.PageMetadata, error)
+}
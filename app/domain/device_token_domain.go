@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// Entity
+type DeviceTokenEntity struct {
+	ID            int64      `gorm:"column:id;primaryKey"`
+	UserID        int64      `gorm:"column:user_id"`
+	Token         string     `gorm:"column:token"`
+	Platform      string     `gorm:"column:platform"`
+	DeviceName    string     `gorm:"column:device_name"`
+	FailureCount  int        `gorm:"column:failure_count"`
+	LastError     string     `gorm:"column:last_error"`
+	LastFailureAt *time.Time `gorm:"column:last_failure_at"`
+	LastSuccessAt *time.Time `gorm:"column:last_success_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+
+	// Relation
+	User UserEntity `gorm:"foreignKey:UserID;reference:ID"`
+}
+
+func (DeviceTokenEntity) TableName() string {
+	return "public.device_tokens"
+}
+
+// Interface
+type DeviceTokenRepository interface {
+	Create(db *gorm.DB, token *DeviceTokenEntity) error
+	FindByID(db *gorm.DB, token *DeviceTokenEntity, id int64) error
+	Update(db *gorm.DB, token *DeviceTokenEntity) error
+	Delete(db *gorm.DB, token *DeviceTokenEntity) error
+
+	// Custom functions
+	FindByUserID(db *gorm.DB, tokens *[]DeviceTokenEntity, userID int64) error
+	FindByToken(db *gorm.DB, token *DeviceTokenEntity, value string) error
+	DeleteByUserID(db *gorm.DB, userID int64) error
+}
+
+type DeviceTokenUseCase interface {
+	Register(ctx context.Context, req *dto.RegisterDeviceTokenRequest, userID int64) (*dto.DeviceTokenData, error)
+	FindByUserID(ctx context.Context, userID int64) (*[]dto.DeviceTokenData, error)
+	Unregister(ctx context.Context, id int64, userID int64) error
+}
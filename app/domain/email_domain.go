@@ -1,5 +1,33 @@
 package domain
 
+import "context"
+
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+type EmailMessage struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []EmailAttachment
+}
+
+// Email accepts messages for delivery. Implementations may queue the message
+// and deliver it later.
 type Email interface {
-	Send(to, subject, body string) error
+	Send(ctx context.Context, msg *EmailMessage) error
+}
+
+// EmailQueue delivers queued messages until the context is cancelled.
+type EmailQueue interface {
+	Run(ctx context.Context)
+}
+
+// EmailTransport hands a message over to a mail server or sink.
+type EmailTransport interface {
+	Deliver(ctx context.Context, from string, msg *EmailMessage) error
 }
@@ -67,6 +67,10 @@ func translateValidationError(err ValidationError) string {
 		"unique":   "%s has already been taken",
 		"oneof":    "%s must be one of %s",
 		"url":      "%s must be a valid URL",
+		"password": "%s must be at least 8 characters with upper case, lower case and a digit",
+
+		"required_with":    "%s is required",
+		"required_without": "%s is required",
 	}
 
 	if msg, exists := messages[err.Tag]; exists {
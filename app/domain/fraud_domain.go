@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// Fraud rule types, each reading its own subset of FraudRuleParams
+const (
+	// FraudRuleVelocity matches more than MaxCount transfers within
+	// WindowMinutes
+	FraudRuleVelocity = "velocity"
+	// FraudRuleNewDeviceAmount matches transfers of at least MinAmount from
+	// a session signed in less than DeviceAgeHours ago
+	FraudRuleNewDeviceAmount = "new_device_amount"
+	// FraudRuleNewBeneficiary matches a first transfer of at least MinAmount
+	// to a wallet
+	FraudRuleNewBeneficiary = "new_beneficiary"
+	// FraudRuleTopUpCashOut matches transfers of at least MinPercent of the
+	// money topped up in the last WindowMinutes
+	FraudRuleTopUpCashOut = "topup_cash_out"
+)
+
+// Fraud outcomes, in increasing severity. A challenge lets the transfer
+// through once the user enters a code sent to them.
+const (
+	FraudAllow     = "allow"
+	FraudChallenge = "challenge"
+	FraudBlock     = "block"
+)
+
+// Stages of a transfer at which the rules are evaluated
+const (
+	FraudStageInquiry = "inquiry"
+	FraudStageExecute = "execute"
+)
+
+// Entity
+type FraudRuleEntity struct {
+	ID          int64     `gorm:"column:id;primaryKey"`
+	Code        string    `gorm:"column:code"`
+	Type        string    `gorm:"column:type"`
+	Action      string    `gorm:"column:action"`
+	Params      string    `gorm:"column:params;type:jsonb"`
+	Description string    `gorm:"column:description"`
+	Enabled     bool      `gorm:"column:enabled"`
+	UpdatedBy   *int64    `gorm:"column:updated_by"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (FraudRuleEntity) TableName() string {
+	return "public.fraud_rules"
+}
+
+// FraudRuleParams holds the thresholds of a rule, stored as JSON.
+type FraudRuleParams struct {
+	MaxCount       int64 `json:"max_count,omitempty"`
+	WindowMinutes  int   `json:"window_minutes,omitempty"`
+	MinAmount      int64 `json:"min_amount,omitempty"`
+	DeviceAgeHours int   `json:"device_age_hours,omitempty"`
+	MinPercent     int64 `json:"min_percent,omitempty"`
+}
+
+// FraudDecisionEntity records a transfer that matched at least one rule, for
+// staff to review.
+type FraudDecisionEntity struct {
+	ID                int64      `gorm:"column:id;primaryKey"`
+	UserID            int64      `gorm:"column:user_id"`
+	WalletID          int64      `gorm:"column:wallet_id"`
+	SessionID         *int64     `gorm:"column:session_id"`
+	Stage             string     `gorm:"column:stage"`
+	DofNumber         string     `gorm:"column:dof_number"`
+	Amount            int64      `gorm:"column:amount"`
+	Currency          string     `gorm:"column:currency"`
+	Outcome           string     `gorm:"column:outcome"`
+	MatchedRules      string     `gorm:"column:matched_rules;type:jsonb"`
+	IPAddress         string     `gorm:"column:ip_address"`
+	UserAgent         string     `gorm:"column:user_agent"`
+	ChallengePassedAt *time.Time `gorm:"column:challenge_passed_at"`
+	ReviewedBy        *int64     `gorm:"column:reviewed_by"`
+	ReviewedAt        *time.Time `gorm:"column:reviewed_at"`
+	ReviewNote        string     `gorm:"column:review_note"`
+	CreatedAt         time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (FraudDecisionEntity) TableName() string {
+	return "public.fraud_decisions"
+}
+
+// FraudCheck describes the transfer being evaluated.
+type FraudCheck struct {
+	Stage     string
+	UserID    int64
+	WalletID  int64
+	SessionID int64
+	DofNumber string
+	Amount    int64
+	Currency  string
+	IPAddress string
+	UserAgent string
+}
+
+// FraudResult is the outcome of evaluating a transfer. DecisionID is zero
+// when no rule matched and nothing was recorded.
+type FraudResult struct {
+	Outcome      string
+	MatchedRules []string
+	DecisionID   int64
+}
+
+// FraudDecisionFilter narrows a decision query; zero fields match
+// everything.
+type FraudDecisionFilter struct {
+	UserID   int64
+	Outcome  string
+	Reviewed *bool
+}
+
+// Interface
+type FraudRuleRepository interface {
+	FindAll(db *gorm.DB, rules *[]FraudRuleEntity) error
+	FindByID(db *gorm.DB, rule *FraudRuleEntity, id int64) error
+	Update(db *gorm.DB, rule *FraudRuleEntity) error
+
+	// Custom functions
+	FindEnabled(db *gorm.DB, rules *[]FraudRuleEntity) error
+}
+
+type FraudDecisionRepository interface {
+	Create(db *gorm.DB, decision *FraudDecisionEntity) error
+	FindByID(db *gorm.DB, decision *FraudDecisionEntity, id int64) error
+	Update(db *gorm.DB, decision *FraudDecisionEntity) error
+
+	// Custom functions
+	FindPage(db *gorm.DB, decisions *[]FraudDecisionEntity, filter *FraudDecisionFilter, page int, size int) (total int64, err error)
+}
+
+type FraudUseCase interface {
+	// Evaluate runs the enabled rules against a transfer and records the
+	// decision when any of them matched.
+	Evaluate(ctx context.Context, check *FraudCheck) (*FraudResult, error)
+	// IssueChallenge sends the user a code to confirm the transfer held
+	// under key. A challenge already pending is reported, not resent, and
+	// the transfer is dropped once it has used up its codes.
+	IssueChallenge(ctx context.Context, key string, userID int64) (*dto.TransferChallengeData, error)
+	// VerifyChallenge checks the code for the transfer held under key and
+	// marks the decision that asked for it as passed. Too many wrong codes
+	// drop the transfer.
+	VerifyChallenge(ctx context.Context, key string, code string, decisionID int64) error
+
+	// Review
+	FindRules(ctx context.Context) ([]dto.FraudRuleData, error)
+	UpdateRule(ctx context.Context, req *dto.UpdateFraudRuleRequest, ruleID int64, actorID int64) (*dto.FraudRuleData, error)
+	FindDecisions(ctx context.Context, req *dto.FraudDecisionListRequest, actorID int64) ([]dto.FraudDecisionData, *dto.PageMetadata, error)
+	ReviewDecision(ctx context.Context, req *dto.ReviewFraudDecisionRequest, decisionID int64, actorID int64) error
+}
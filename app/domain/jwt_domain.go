@@ -1,7 +1,40 @@
 package domain
 
+import (
+	"context"
+	"time"
+
+	"riz.it/domped/app/dto"
+)
+
+type TokenClaims struct {
+	ID        string
+	UserID    int64
+	SessionID int64
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type TokenPair struct {
+	AccessToken   string
+	AccessClaims  TokenClaims
+	RefreshToken  string
+	RefreshClaims TokenClaims
+}
+
 type JWT interface {
-	GenerateToken(userID int64) (string, string, error)
-	ValidateAccessToken(tokenString string) (int64, error)
-	ValidateRefreshToken(tokenString string) (int64, error)
+	GenerateToken(userID int64, sessionID int64) (*TokenPair, error)
+	ValidateAccessToken(tokenString string) (*TokenClaims, error)
+	ValidateRefreshToken(tokenString string) (*TokenClaims, error)
+	JWKS() *dto.JWKSResponse
+	RotateKeys(ctx context.Context)
+}
+
+// TokenDenylist revokes access tokens before they expire, either one at a
+// time by their ID or for a user as a whole by rejecting every token issued
+// up to a watermark.
+type TokenDenylist interface {
+	Deny(ctx context.Context, tokenID string, expiresAt time.Time) error
+	DenyUserTokensBefore(ctx context.Context, userID int64, before time.Time) error
+	IsDenied(ctx context.Context, claims *TokenClaims) (bool, error)
 }
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// KYC submission statuses. A submission starts pending and is either
+// approved or rejected by a reviewer; a rejected user may submit again.
+const (
+	KYCStatusPending  = "pending"
+	KYCStatusApproved = "approved"
+	KYCStatusRejected = "rejected"
+)
+
+// KYC documents
+const (
+	KYCDocumentIDCard = "id_card"
+	KYCDocumentSelfie = "selfie"
+)
+
+// Entity
+type KYCSubmissionEntity struct {
+	ID        int64     `gorm:"column:id;primaryKey"`
+	UserID    int64     `gorm:"column:user_id"`
+	NIK       string    `gorm:"column:nik"`
+	FullName  string    `gorm:"column:full_name"`
+	BirthDate time.Time `gorm:"column:birth_date;type:date"`
+	// Documents are kept in the object store under these keys
+	IDCardKey       string     `gorm:"column:id_card_key"`
+	SelfieKey       string     `gorm:"column:selfie_key"`
+	Status          string     `gorm:"column:status"`
+	RejectionReason string     `gorm:"column:rejection_reason"`
+	ReviewedBy      *int64     `gorm:"column:reviewed_by"`
+	ReviewedAt      *time.Time `gorm:"column:reviewed_at"`
+	CreatedAt       time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (KYCSubmissionEntity) TableName() string {
+	return "public.kyc_submissions"
+}
+
+// Interface
+type KYCSubmissionRepository interface {
+	Create(db *gorm.DB, submission *KYCSubmissionEntity) error
+	FindByID(db *gorm.DB, submission *KYCSubmissionEntity, id int64) error
+	Update(db *gorm.DB, submission *KYCSubmissionEntity) error
+
+	// Custom functions
+	// FindByIDForUpdate finds the submission and locks the row until the
+	// transaction ends.
+	FindByIDForUpdate(db *gorm.DB, submission *KYCSubmissionEntity, id int64) error
+	FindLatestByUserID(db *gorm.DB, submission *KYCSubmissionEntity, userID int64) error
+	FindApprovedByUserID(db *gorm.DB, submission *KYCSubmissionEntity, userID int64) error
+	CountPendingByUserID(db *gorm.DB, userID int64) (count int64, err error)
+	CountApprovedByNIK(db *gorm.DB, nik string) (count int64, err error)
+	FindPage(db *gorm.DB, submissions *[]KYCSubmissionEntity, status string, page int, size int) (total int64, err error)
+}
+
+type KYCUseCase interface {
+	Submit(ctx context.Context, req *dto.SubmitKYCRequest, userID int64) (*dto.KYCSubmissionData, error)
+	GetStatus(ctx context.Context, userID int64) (*dto.KYCStatusResponse, error)
+
+	// Review
+	FindAll(ctx context.Context, req *dto.KYCListRequest) ([]dto.KYCSubmissionData, *dto.PageMetadata, error)
+	FindByID(ctx context.Context, submissionID int64) (*dto.KYCSubmissionData, error)
+	GetDocument(ctx context.Context, submissionID int64, document string) (*dto.KYCDocumentFile, error)
+	Approve(ctx context.Context, req *dto.ReviewKYCRequest, submissionID int64, reviewerID int64) error
+	Reject(ctx context.Context, req *dto.ReviewKYCRequest, submissionID int64, reviewerID int64) error
+}
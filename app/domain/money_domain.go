@@ -0,0 +1,43 @@
+package domain
+
+// DefaultCurrency is the currency every wallet opens with. Top-ups are paid
+// in it, and the fraud and AML amount thresholds are expressed in it.
+const DefaultCurrency = "IDR"
+
+// Currency describes how amounts of a currency are stored and shown.
+// Amounts are kept as integers in the currency's minor unit, MinorUnits
+// digits below the major one. Rupiah are handled in whole units, as the
+// payment gateway does.
+type Currency struct {
+	Code       string
+	MinorUnits int
+	Symbol     string
+	// Separators used when formatting an amount
+	Thousands string
+	Decimal   string
+}
+
+// Currencies lists the currencies a wallet can hold.
+var Currencies = map[string]Currency{
+	"IDR": {Code: "IDR", MinorUnits: 0, Symbol: "Rp", Thousands: ".", Decimal: ","},
+	"USD": {Code: "USD", MinorUnits: 2, Symbol: "US$", Thousands: ",", Decimal: "."},
+	"SGD": {Code: "SGD", MinorUnits: 2, Symbol: "S$", Thousands: ",", Decimal: "."},
+	"EUR": {Code: "EUR", MinorUnits: 2, Symbol: "€", Thousands: ".", Decimal: ","},
+	"JPY": {Code: "JPY", MinorUnits: 0, Symbol: "¥", Thousands: ",", Decimal: "."},
+}
+
+// FindCurrency looks a supported currency up by its ISO 4217 code.
+func FindCurrency(code string) (Currency, bool) {
+	currency, ok := Currencies[code]
+	return currency, ok
+}
+
+// Money is an amount in the minor unit of its currency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
@@ -2,12 +2,64 @@ package domain
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 	"riz.it/domped/app/dto"
 )
 
+// Notification events, each rendered from the template of the same name.
+// Events prefixed with "security_" are security alerts.
+const (
+	NotificationTransferSent     = "transfer_sent"
+	NotificationTransferReceived = "transfer_received"
+	NotificationTopUpSuccess     = "topup_success"
+	NotificationSecurityAlert    = "security_alert"
+	NotificationKYCApproved      = "kyc_approved"
+	NotificationKYCRejected      = "kyc_rejected"
+
+	NotificationSecurityPinChanged   = "security_pin_changed"
+	NotificationSecurityTokenReuse   = "security_token_reuse"
+	NotificationSecurityPassword     = "security_password_changed"
+	NotificationSecurityTwoFactorOn  = "security_two_factor_enabled"
+	NotificationSecurityTwoFactorOff = "security_two_factor_disabled"
+	NotificationSecurityLockout      = "security_account_locked"
+	NotificationSecurityProfile      = "security_profile_updated"
+	NotificationSecurityEmailRequest = "security_email_change_requested"
+	NotificationSecurityEmail        = "security_email_changed"
+	NotificationSecurityWalletStatus = "security_wallet_status_changed"
+)
+
+// Notification channels
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+// NotificationEventTypes lists the event types a user can set preferences for.
+var NotificationEventTypes = []string{
+	NotificationTransferSent,
+	NotificationTransferReceived,
+	NotificationTopUpSuccess,
+	NotificationSecurityAlert,
+}
+
+// NotificationEventType returns the preference event type governing an event.
+func NotificationEventType(event string) string {
+	if strings.HasPrefix(event, "security_") {
+		return NotificationSecurityAlert
+	}
+	return event
+}
+
+// IsMandatoryNotification reports whether the event type is delivered on
+// every channel regardless of the user's preferences.
+func IsMandatoryNotification(eventType string) bool {
+	return eventType == NotificationSecurityAlert
+}
+
 type NotificationEntity struct {
 	ID        int64     `gorm:"column:id;primaryKey"`
 	UserID    int64     `gorm:"column:user_id"`
@@ -26,6 +78,33 @@ func (NotificationEntity) TableName() string {
 	return "public.notifications"
 }
 
+type NotificationPreferenceEntity struct {
+	ID        int64     `gorm:"column:id;primaryKey"`
+	UserID    int64     `gorm:"column:user_id"`
+	EventType string    `gorm:"column:event_type"`
+	InApp     bool      `gorm:"column:in_app"`
+	Email     bool      `gorm:"column:email"`
+	Push      bool      `gorm:"column:push"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (NotificationPreferenceEntity) TableName() string {
+	return "public.notification_preferences"
+}
+
+// DefaultNotificationPreference returns the channels used for an event type
+// the user has not configured.
+func DefaultNotificationPreference(userID int64, eventType string) NotificationPreferenceEntity {
+	return NotificationPreferenceEntity{
+		UserID:    userID,
+		EventType: eventType,
+		InApp:     true,
+		Email:     IsMandatoryNotification(eventType),
+		Push:      true,
+	}
+}
+
 // Interface
 type NotificationRepository interface {
 	Create(db *gorm.DB, n *NotificationEntity) error
@@ -36,8 +115,23 @@ type NotificationRepository interface {
 
 	// Custom functions
 	FindByUserID(db *gorm.DB, notifications *[]NotificationEntity, userID int64) error
+	DeleteByUserID(db *gorm.DB, userID int64) error
+}
+
+type NotificationPreferenceRepository interface {
+	Create(db *gorm.DB, p *NotificationPreferenceEntity) error
+	Update(db *gorm.DB, p *NotificationPreferenceEntity) error
+
+	// Custom functions
+	FindByUserID(db *gorm.DB, preferences *[]NotificationPreferenceEntity, userID int64) error
+	FindByUserIDAndEventType(db *gorm.DB, preference *NotificationPreferenceEntity, userID int64, eventType string) error
+	DeleteByUserID(db *gorm.DB, userID int64) error
 }
 
 type NotificationUseCase interface {
 	FindByUserID(ctx context.Context, userID int64) (*[]dto.NotificationData, error)
+	GetPreferences(ctx context.Context, userID int64) (*[]dto.NotificationPreferenceData, error)
+	UpdatePreferences(ctx context.Context, req *dto.UpdateNotificationPreferencesRequest, userID int64) (*[]dto.NotificationPreferenceData, error)
+	Notify(ctx context.Context, userID int64, event string, data any) error
+	Push(notifications ...NotificationEntity)
 }
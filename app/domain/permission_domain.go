@@ -0,0 +1,72 @@
+package domain
+
+import "slices"
+
+// User roles. Every account starts as RoleUser; the others are staff roles
+// assigned by an admin.
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleFinance = "finance"
+	RoleAdmin   = "admin"
+)
+
+// Permissions guarding the admin endpoints
+const (
+	PermissionUserRead        = "users:read"
+	PermissionUserVerify      = "users:resend_verification"
+	PermissionUserRole        = "users:assign_role"
+	PermissionWalletRead      = "wallets:read"
+	PermissionWalletFreeze    = "wallets:freeze"
+	PermissionTransactionRead = "transactions:read"
+	PermissionTopUpRelease    = "topups:release"
+	PermissionKYCReview       = "kyc:review"
+	PermissionAuditRead       = "audit:read"
+	PermissionFraudReview     = "fraud:review"
+	PermissionFraudRules      = "fraud:manage_rules"
+	PermissionAMLReview       = "aml:review"
+)
+
+// Roles lists the roles that can be assigned.
+var Roles = []string{RoleUser, RoleSupport, RoleFinance, RoleAdmin}
+
+// RolePermissions maps each role to the permissions it grants. Support
+// handles customers, finance handles money, admin can do everything.
+var RolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleSupport: {
+		PermissionUserRead,
+		PermissionUserVerify,
+		PermissionWalletRead,
+		PermissionTransactionRead,
+		PermissionKYCReview,
+	},
+	RoleFinance: {
+		PermissionUserRead,
+		PermissionWalletRead,
+		PermissionWalletFreeze,
+		PermissionTransactionRead,
+		PermissionTopUpRelease,
+		PermissionFraudReview,
+		PermissionAMLReview,
+	},
+	RoleAdmin: {
+		PermissionUserRead,
+		PermissionUserVerify,
+		PermissionUserRole,
+		PermissionWalletRead,
+		PermissionWalletFreeze,
+		PermissionTransactionRead,
+		PermissionTopUpRelease,
+		PermissionKYCReview,
+		PermissionAuditRead,
+		PermissionFraudReview,
+		PermissionFraudRules,
+		PermissionAMLReview,
+	},
+}
+
+// HasPermission reports whether the role grants the permission.
+func HasPermission(role string, permission string) bool {
+	return slices.Contains(RolePermissions[role], permission)
+}
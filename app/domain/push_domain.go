@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidPushToken is returned by a push provider when the device token is
+// no longer registered, so the token can be pruned.
+var ErrInvalidPushToken = errors.New("push token is invalid or unregistered")
+
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+type Push interface {
+	Send(ctx context.Context, token string, msg *PushMessage) error
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Rate limit policies
+const (
+	RateLimitRead     = "read"
+	RateLimitWrite    = "write"
+	RateLimitAuth     = "auth"
+	RateLimitTransfer = "transfer"
+)
+
+// RateLimitPolicy allows Limit requests per Window for each client.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitResult is the outcome of counting one request against a policy.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the current window ends
+	Reset time.Duration
+}
+
+// Interface
+type RateLimiter interface {
+	// Allow counts a request by key against the named policy. It returns a
+	// nil result when rate limiting is disabled.
+	Allow(ctx context.Context, policy string, key string) (*RateLimitResult, error)
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SessionRevokedLogout = "logout"
+	SessionRevokedByUser = "revoked_by_user"
+	SessionRevokedReuse  = "refresh_token_reuse"
+	SessionRevokedReset  = "password_reset"
+	SessionRevokedChange = "password_changed"
+	SessionRevokedClosed = "account_closed"
+)
+
+// Entity
+type SessionEntity struct {
+	ID         int64  `gorm:"column:id;primaryKey"`
+	UserID     int64  `gorm:"column:user_id"`
+	DeviceName string `gorm:"column:device_name"`
+	IPAddress  string `gorm:"column:ip_address"`
+	UserAgent  string `gorm:"column:user_agent"`
+	// The session's latest access token, denylisted when it is superseded
+	// or the session is revoked
+	AccessTokenID   string     `gorm:"column:access_token_id"`
+	AccessExpiresAt *time.Time `gorm:"column:access_expires_at"`
+	LastUsedAt      time.Time  `gorm:"column:last_used_at"`
+	ExpiresAt       time.Time  `gorm:"column:expires_at"`
+	RevokedAt       *time.Time `gorm:"column:revoked_at"`
+	RevokedReason   string     `gorm:"column:revoked_reason"`
+	CreatedAt       time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (SessionEntity) TableName() string {
+	return "public.sessions"
+}
+
+// IsActive reports whether the session can still be refreshed.
+func (s *SessionEntity) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshTokenEntity is one link in a session's rotation chain. Only the
+// SHA-256 digest of the token is stored; a token that is presented again
+// after UsedAt is set has been replayed.
+type RefreshTokenEntity struct {
+	ID        int64      `gorm:"column:id;primaryKey"`
+	SessionID int64      `gorm:"column:session_id"`
+	TokenHash string     `gorm:"column:token_hash"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (RefreshTokenEntity) TableName() string {
+	return "public.refresh_tokens"
+}
+
+// Interface
+type SessionRepository interface {
+	Create(db *gorm.DB, session *SessionEntity) error
+	FindByID(db *gorm.DB, session *SessionEntity, id int64) error
+	Update(db *gorm.DB, session *SessionEntity) error
+	Delete(db *gorm.DB, session *SessionEntity) error
+
+	// Custom functions
+	FindActiveByUserID(db *gorm.DB, sessions *[]SessionEntity, userID int64) error
+	// RevokeByUserID revokes every open session of the user and loads the
+	// sessions it revoked into sessions.
+	RevokeByUserID(db *gorm.DB, sessions *[]SessionEntity, userID int64, reason string) error
+}
+
+type RefreshTokenRepository interface {
+	Create(db *gorm.DB, token *RefreshTokenEntity) error
+
+	// Custom functions
+	FindByTokenHash(db *gorm.DB, token *RefreshTokenEntity, hash string) error
+	MarkUsed(db *gorm.DB, token *RefreshTokenEntity) (bool, error)
+}
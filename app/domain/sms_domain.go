@@ -0,0 +1,13 @@
+package domain
+
+import "context"
+
+type SMSMessage struct {
+	// To is the recipient's number in E.164 format
+	To   string
+	Body string
+}
+
+type SMS interface {
+	Send(ctx context.Context, msg *SMSMessage) error
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"riz.it/domped/app/dto"
+)
+
+// Statement directions
+const (
+	StatementIn  = "in"
+	StatementOut = "out"
+)
+
+type Statement struct {
+	WalletNumber   string
+	OwnerName      string
+	Currency       string
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	OpeningBalance int64
+	ClosingBalance int64
+	TotalIn        int64
+	TotalOut       int64
+	Entries        []StatementEntry
+	GeneratedAt    time.Time
+}
+
+type StatementEntry struct {
+	TransactionID    int64
+	TransactionAt    time.Time
+	Description      string
+	Direction        string
+	Counterparty     string
+	CounterpartyName string
+	Amount           int64
+	Balance          int64
+}
+
+// Interface
+type StatementUseCase interface {
+	Generate(ctx context.Context, req *dto.StatementRequest, userID int64) (*dto.StatementFile, error)
+	Email(ctx context.Context, req *dto.StatementRequest, userID int64) error
+	SendMonthlyStatements(ctx context.Context)
+}
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrObjectNotFound is returned by an object store for a key it does not hold.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStore keeps uploaded files under opaque keys.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, content []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
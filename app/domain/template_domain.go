@@ -0,0 +1,24 @@
+package domain
+
+const (
+	LocaleID      = "id"
+	LocaleEN      = "en"
+	DefaultLocale = LocaleID
+)
+
+type RenderedNotification struct {
+	Title string
+	Body  string
+}
+
+type RenderedEmail struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Template interface {
+	RenderNotification(locale, name string, data any) (*RenderedNotification, error)
+	RenderEmail(locale, name string, data any) (*RenderedEmail, error)
+	RenderSMS(locale, name string, data any) (string, error)
+}
@@ -8,10 +8,19 @@ import (
 	"riz.it/domped/app/dto"
 )
 
+// Top-up statuses. A paid top-up is held instead of credited when the wallet
+// cannot receive money, to be refunded or released by staff.
+const (
+	TopUpStatusPending int8 = 0
+	TopUpStatusSuccess int8 = 1
+	TopUpStatusHeld    int8 = 2
+)
+
 type TopUpEntity struct {
 	ID        string    `gorm:"column:id;primaryKey;type:uuid"`
 	UserID    int64     `gorm:"column:user_id;"`
 	Amount    int64     `gorm:"column:amount"`
+	Currency  string    `gorm:"column:currency"`
 	Status    int8      `gorm:"column:status"`
 	SnapURL   string    `gorm:"column:snap_url"`
 	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
@@ -35,9 +44,13 @@ type TopUpRepository interface {
 	Delete(db *gorm.DB, topup *TopUpEntity) error
 
 	// Custom functions
+	FindByUserID(db *gorm.DB, topups *[]TopUpEntity, userID int64) error
+	FindPaidByAmountRangeSince(db *gorm.DB, topups *[]TopUpEntity, minAmount, maxAmount int64, since time.Time) error
 }
 
 type TopUpUseCase interface {
 	InitializeTopUp(ctx context.Context, req *dto.TopUpRequest, userID int64) (*dto.TopUpResponse, error)
 	TopUpConfirmed(ctx context.Context, id string) error
+	// ReleaseTopUp credits a held top-up once its wallet can take it again.
+	ReleaseTopUp(ctx context.Context, req *dto.ReleaseTopUpRequest, id string, actorID int64) error
 }
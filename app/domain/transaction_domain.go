@@ -8,6 +8,24 @@ import (
 	"riz.it/domped/app/dto"
 )
 
+// Transaction types. Every movement is recorded against the wallet it
+// affects: TransactionIn adds to the balance, TransactionOut subtracts.
+const (
+	TransactionIn  = "D"
+	TransactionOut = "C"
+)
+
+// TopUpSofNumber is the source-of-fund number recorded for top-ups.
+const TopUpSofNumber = "00"
+
+// PocketMoveNumber is the source- or destination-of-fund number recorded
+// for the two legs of a move between a user's main wallet and pockets.
+const PocketMoveNumber = "02"
+
+// InternalNumbers mark movements within a user's own wallet. They are left
+// out of the fraud rules and AML scans, which look at money leaving a user.
+var InternalNumbers = []string{ConversionNumber, PocketMoveNumber}
+
 // Entity
 type TransactionEntity struct {
 	ID              int64     `gorm:"column:id;primaryKey"`
@@ -15,6 +33,7 @@ type TransactionEntity struct {
 	SofNumber       string    `gorm:"column:sof_number"`
 	DofNumber       string    `gorm:"column:dof_number"`
 	Amount          int64     `gorm:"column:amount"`
+	Currency        string    `gorm:"column:currency"`
 	TransactionType string    `gorm:"column:transaction_type"`
 	TransactionAt   time.Time `gorm:"column:transaction_at;autoCreateTime"`
 
@@ -35,6 +54,20 @@ type TransactionRepository interface {
 	Delete(db *gorm.DB, transaction *TransactionEntity) error
 
 	// Custom functions
+	FindByWalletID(db *gorm.DB, transactions *[]TransactionEntity, walletID int64) error
+	FindPageByWalletID(db *gorm.DB, transactions *[]TransactionEntity, walletID int64, page int, size int) (total int64, err error)
+	FindByWalletIDBetween(db *gorm.DB, transactions *[]TransactionEntity, walletID int64, currency string, from, to time.Time) error
+	SumNetByWalletIDSince(db *gorm.DB, walletID int64, currency string, since time.Time) (int64, error)
+	// The fraud rule queries take a main wallet and include its pockets'
+	// rows; internal movements are left out
+	CountOutByWalletIDSince(db *gorm.DB, walletID int64, since time.Time) (count int64, err error)
+	CountOutByWalletIDAndDofNumber(db *gorm.DB, walletID int64, dofNumber string) (count int64, err error)
+	SumInBySofNumberSince(db *gorm.DB, walletID int64, sofNumber string, since time.Time) (int64, error)
+	// The AML queries leave internal movements out
+	FindOutByAmountRangeSince(db *gorm.DB, transactions *[]TransactionEntity, currency string, minAmount, maxAmount int64, since time.Time) error
+	FindOutByMinAmountSince(db *gorm.DB, transactions *[]TransactionEntity, currency string, minAmount int64, since time.Time) error
+	FindByMinAmountSince(db *gorm.DB, transactions *[]TransactionEntity, currency string, minAmount int64, since time.Time) error
+	CountByWalletIDBetween(db *gorm.DB, walletID int64, from, to time.Time) (count int64, err error)
 }
 
 type TransactionUseCase interface {
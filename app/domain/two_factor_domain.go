@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// Entity
+type RecoveryCodeEntity struct {
+	ID        int64      `gorm:"column:id;primaryKey"`
+	UserID    int64      `gorm:"column:user_id"`
+	CodeHash  string     `gorm:"column:code_hash"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (RecoveryCodeEntity) TableName() string {
+	return "public.two_factor_recovery_codes"
+}
+
+// TOTP generates and checks RFC 6238 time-based one-time passwords.
+type TOTP interface {
+	GenerateSecret() (string, error)
+	URI(secret string, issuer string, account string) string
+	// Validate reports whether code is valid for secret now, returning the
+	// time step it matched so callers can reject replays.
+	Validate(secret string, code string) (int64, bool)
+	// Seal encrypts a secret for storage and Open decrypts it again.
+	Seal(secret string) (string, error)
+	Open(sealed string) (string, error)
+}
+
+// Interface
+type RecoveryCodeRepository interface {
+	Create(db *gorm.DB, code *RecoveryCodeEntity) error
+
+	// Custom functions
+	DeleteByUserID(db *gorm.DB, userID int64) error
+	MarkUsed(db *gorm.DB, userID int64, codeHash string) (bool, error)
+}
+
+type TwoFactorUseCase interface {
+	Enroll(ctx context.Context, userID int64) (*dto.EnrollTwoFactorResponse, error)
+	Confirm(ctx context.Context, req *dto.ConfirmTwoFactorRequest, userID int64) error
+	Disable(ctx context.Context, req *dto.DisableTwoFactorRequest, userID int64) error
+}
@@ -1,9 +1,17 @@
 package domain
 
 import (
+	"context"
 	"time"
 
 	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// KYC levels
+const (
+	KYCLevelNone     int16 = 0
+	KYCLevelVerified int16 = 1
 )
 
 // Entity
@@ -13,11 +21,25 @@ type UserEntity struct {
 	Email           string     `gorm:"column:email"`
 	Phone           string     `gorm:"column:phone"`
 	Password        string     `gorm:"column:password"`
-	HashedRt        string     `gorm:"column:hashed_rt"`
 	IsActive        bool       `gorm:"column:is_active"`
+	Language        string     `gorm:"column:language"`
 	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at"`
-	CreatedAt       time.Time  `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt       time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	// The phone number is stored in E.164 format and can be used to log in
+	// once PhoneVerifiedAt is set
+	PhoneVerifiedAt *time.Time `gorm:"column:phone_verified_at"`
+	// Two-factor authentication is on once TwoFactorEnabledAt is set; the
+	// last accepted time step keeps a code from being used twice
+	TwoFactorSecret      string     `gorm:"column:two_factor_secret"`
+	TwoFactorEnabledAt   *time.Time `gorm:"column:two_factor_enabled_at"`
+	TwoFactorLastCounter int64      `gorm:"column:two_factor_last_counter"`
+	// Role decides access to the admin endpoints and KYCLevel the wallet
+	// tier, raised once an identity verification is approved
+	Role     string `gorm:"column:role"`
+	KYCLevel int16  `gorm:"column:kyc_level"`
+	// A closed account is kept, anonymised, for the ledger it belongs to
+	ClosedAt  *time.Time `gorm:"column:closed_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
 
 	// Relation
 	Wallet WalletEntity  `gorm:"foreignKey:UserID;reference:ID"`
@@ -37,6 +59,21 @@ type UserRepository interface {
 	Delete(db *gorm.DB, user *UserEntity) error
 
 	// Custom functions
+	// FindByIDForUpdate finds the user and locks the row until the
+	// transaction ends.
+	FindByIDForUpdate(db *gorm.DB, user *UserEntity, id int64) error
 	FindByEmail(db *gorm.DB, user *UserEntity, email string) error
 	CountByEmail(db *gorm.DB, email string) (count int64, err error)
+	FindByVerifiedPhone(db *gorm.DB, user *UserEntity, phone string) error
+	CountByVerifiedPhone(db *gorm.DB, phone string) (count int64, err error)
+	Search(db *gorm.DB, users *[]UserEntity, query string, page int, size int) (total int64, err error)
+}
+
+type UserUseCase interface {
+	GetProfile(ctx context.Context, userID int64) (*dto.ProfileResponse, error)
+	UpdateProfile(ctx context.Context, req *dto.UpdateProfileRequest, userID int64) error
+	UpdateLanguage(ctx context.Context, req *dto.UpdateLanguageRequest, userID int64) error
+	ChangeEmail(ctx context.Context, req *dto.ChangeEmailRequest, userID int64) error
+	ConfirmEmailChange(ctx context.Context, req *dto.ConfirmEmailChangeRequest, userID int64) error
+	ChangePassword(ctx context.Context, req *dto.ChangePasswordRequest, userID int64, sessionID int64) error
 }
@@ -1,31 +1,97 @@
 package domain
 
 import (
+	"context"
 	"time"
 
 	"gorm.io/gorm"
+	"riz.it/domped/app/dto"
+)
+
+// Wallet statuses. Staff can freeze a wallet for outgoing money only or for
+// all movements; a closed wallet accepts nothing and cannot be reopened.
+const (
+	WalletStatusActive      = "active"
+	WalletStatusFrozenDebit = "frozen_debit"
+	WalletStatusFrozenAll   = "frozen_all"
+	WalletStatusClosed      = "closed"
 )
 
 // Entity
 type WalletEntity struct {
-	ID           int64     `gorm:"column:id;primaryKey"`
-	UserID       int64     `gorm:"column:user_id"`
-	WalletNumber string    `gorm:"column:wallet_number"`
-	WalletPin    string    `gorm:"column:wallet_pin"`
-	Balance      int64     `gorm:"column:balance"`
-	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt    time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	ID           int64  `gorm:"column:id;primaryKey"`
+	UserID       int64  `gorm:"column:user_id"`
+	WalletNumber string `gorm:"column:wallet_number"`
+	WalletPin    string `gorm:"column:wallet_pin"`
+	// The reason, actor and time of the last status change
+	Status          string     `gorm:"column:status"`
+	StatusReason    string     `gorm:"column:status_reason"`
+	StatusChangedBy *int64     `gorm:"column:status_changed_by"`
+	StatusChangedAt *time.Time `gorm:"column:status_changed_at"`
+	// A closed wallet keeps its transactions but accepts no new ones
+	ClosedAt *time.Time `gorm:"column:closed_at"`
+	// Pockets are wallets under the user's main wallet. They have no pin
+	// and follow the main wallet's status.
+	ParentID     *int64     `gorm:"column:parent_id"`
+	Name         string     `gorm:"column:name"`
+	TargetAmount *int64     `gorm:"column:target_amount"`
+	LockedUntil  *time.Time `gorm:"column:locked_until"`
+	CreatedAt    time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
 
 	// Relation
-	Transaction []TransactionEntity `gorm:"foreignKey:WalletID;reference:ID"`
-	User        *UserEntity         `gorm:"foreignKey:UserID;reference:ID"`
-	PinRecovery []PinRecoveryEntity `gorm:"foreignKey:WalletID;reference:ID"`
+	Transaction []TransactionEntity   `gorm:"foreignKey:WalletID;reference:ID"`
+	User        *UserEntity           `gorm:"foreignKey:UserID;reference:ID"`
+	PinRecovery []PinRecoveryEntity   `gorm:"foreignKey:WalletID;reference:ID"`
+	Balances    []WalletBalanceEntity `gorm:"foreignKey:WalletID;reference:ID"`
 }
 
 func (WalletEntity) TableName() string {
 	return "public.wallets"
 }
 
+// WalletBalanceEntity is the balance a wallet holds in one currency, in the
+// currency's minor unit.
+type WalletBalanceEntity struct {
+	ID        int64     `gorm:"column:id;primaryKey"`
+	WalletID  int64     `gorm:"column:wallet_id"`
+	Currency  string    `gorm:"column:currency"`
+	Balance   int64     `gorm:"column:balance"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (WalletBalanceEntity) TableName() string {
+	return "public.wallet_balances"
+}
+
+// Money returns the balance as an amount of its currency.
+func (b *WalletBalanceEntity) Money() Money {
+	return NewMoney(b.Balance, b.Currency)
+}
+
+// CanDebit reports whether money can leave the wallet.
+func (w *WalletEntity) CanDebit() bool {
+	return w.Status == WalletStatusActive
+}
+
+// CanCredit reports whether money can enter the wallet. A wallet frozen for
+// debits still receives.
+func (w *WalletEntity) CanCredit() bool {
+	return w.Status == WalletStatusActive || w.Status == WalletStatusFrozenDebit
+}
+
+// IsPocket reports whether the wallet is a pocket of a main wallet.
+func (w *WalletEntity) IsPocket() bool {
+	return w.ParentID != nil
+}
+
+// IsLocked reports whether a pocket's money is locked at the given time.
+// Money can still be moved into a locked pocket.
+func (w *WalletEntity) IsLocked(at time.Time) bool {
+	return w.LockedUntil != nil && at.Before(*w.LockedUntil)
+}
+
 // Interface
 type WalletRepository interface {
 	Create(db *gorm.DB, wallet *WalletEntity) error
@@ -35,7 +101,35 @@ type WalletRepository interface {
 	Delete(db *gorm.DB, wallet *WalletEntity) error
 
 	// Custom functions
+	// FindByUserID finds the user's main wallet.
 	FindByUserID(db *gorm.DB, user *WalletEntity, userID int64) error
+	// FindMainAfterID finds up to limit main wallets with an ID above
+	// afterID, in ID order, to page through every wallet.
+	FindMainAfterID(db *gorm.DB, wallets *[]WalletEntity, afterID int64, limit int) error
+	FindPocketsByParentID(db *gorm.DB, wallets *[]WalletEntity, parentID int64) error
+	FindPocketByID(db *gorm.DB, wallet *WalletEntity, parentID int64, id int64) error
 	FindByWalletNumber(db *gorm.DB, wallet *WalletEntity, walletNumber string) error
 	CountByWalletNumber(db *gorm.DB, walletNumber string) (count int64, err error)
+	FindByWalletNumbers(db *gorm.DB, wallets *[]WalletEntity, walletNumbers []string) error
+}
+
+type WalletBalanceRepository interface {
+	Create(db *gorm.DB, balance *WalletBalanceEntity) error
+	Update(db *gorm.DB, balance *WalletBalanceEntity) error
+
+	// Custom functions
+	FindByWalletID(db *gorm.DB, balances *[]WalletBalanceEntity, walletID int64) error
+	FindByWalletIDAndCurrency(db *gorm.DB, balance *WalletBalanceEntity, walletID int64, currency string) error
+}
+
+type WalletUseCase interface {
+	FindBalances(ctx context.Context, userID int64) ([]dto.WalletBalanceData, error)
+	OpenBalance(ctx context.Context, req *dto.OpenBalanceRequest, userID int64) (*dto.WalletBalanceData, error)
+
+	// Pockets
+	FindPockets(ctx context.Context, userID int64) ([]dto.PocketData, error)
+	OpenPocket(ctx context.Context, req *dto.PocketRequest, userID int64) (*dto.PocketData, error)
+	UpdatePocket(ctx context.Context, req *dto.PocketRequest, pocketID int64, userID int64) (*dto.PocketData, error)
+	ClosePocket(ctx context.Context, req *dto.ClosePocketRequest, pocketID int64, userID int64) error
+	MovePocketMoney(ctx context.Context, req *dto.MovePocketMoneyRequest, userID int64) error
 }
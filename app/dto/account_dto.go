@@ -0,0 +1,21 @@
+package dto
+
+// Request
+type CloseAccountRequest struct {
+	Password  string `json:"password" validate:"required"`
+	Reason    string `json:"reason" validate:"max=255"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ExportAccountRequest struct {
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// Response
+type AccountExportFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
@@ -0,0 +1,45 @@
+package dto
+
+// Request
+// AdminRequest carries the client details recorded in the audit log for
+// staff actions that take no other input.
+type AdminRequest struct {
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type AdminUserSearchRequest struct {
+	Query     string `query:"q" validate:"max=125"`
+	Page      int    `query:"page" validate:"min=0"`
+	Size      int    `query:"size" validate:"min=0,max=100"`
+	IPAddress string `query:"-"`
+	UserAgent string `query:"-"`
+}
+
+type AdminTransactionListRequest struct {
+	Page      int    `query:"page" validate:"min=0"`
+	Size      int    `query:"size" validate:"min=0,max=100"`
+	IPAddress string `query:"-"`
+	UserAgent string `query:"-"`
+}
+
+// FreezeWalletRequest freezes a wallet for outgoing money only ("debit") or
+// for all movements ("all", the default).
+type FreezeWalletRequest struct {
+	Scope     string `json:"scope" validate:"omitempty,oneof=debit all"`
+	Reason    string `json:"reason" validate:"required,max=255"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type UnfreezeWalletRequest struct {
+	Reason    string `json:"reason" validate:"required,max=255"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type AssignRoleRequest struct {
+	Role      string `json:"role" validate:"required,oneof=user support finance admin"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
@@ -0,0 +1,80 @@
+package dto
+
+import "encoding/json"
+
+// Request
+type AMLAlertListRequest struct {
+	Status     string `query:"status" validate:"omitempty,oneof=open investigating closed"`
+	Type       string `query:"type" validate:"omitempty,oneof=structuring circular_flow dormant_reactivation"`
+	AssignedTo int64  `query:"assigned_to" validate:"min=0"`
+	UserID     int64  `query:"user_id" validate:"min=0"`
+	Page       int    `query:"page" validate:"min=0"`
+	Size       int    `query:"size" validate:"min=0,max=100"`
+	IPAddress  string `query:"-"`
+	UserAgent  string `query:"-"`
+}
+
+type AssignAMLAlertRequest struct {
+	AssigneeID int64  `json:"assignee_id" validate:"required,min=1"`
+	IPAddress  string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+type AMLAlertNoteRequest struct {
+	Note      string `json:"note" validate:"required,max=2000"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// CloseAMLAlertRequest closes an alert; the note explains the resolution.
+type CloseAMLAlertRequest struct {
+	Resolution string `json:"resolution" validate:"required,oneof=reported no_action false_positive"`
+	Note       string `json:"note" validate:"required,max=2000"`
+	IPAddress  string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+// AMLReportRequest selects the alerts reported between two dates, both
+// included.
+type AMLReportRequest struct {
+	From      string `query:"from" validate:"required,datetime=2006-01-02"`
+	To        string `query:"to" validate:"required,datetime=2006-01-02"`
+	IPAddress string `query:"-"`
+	UserAgent string `query:"-"`
+}
+
+// Response
+type AMLAlertDetailResponse struct {
+	Alert AMLAlertData       `json:"alert"`
+	Notes []AMLAlertNoteData `json:"notes"`
+}
+
+type AMLReportFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Data
+type AMLAlertData struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	UserID     int64           `json:"user_id"`
+	WalletID   int64           `json:"wallet_id"`
+	Amount     int64           `json:"amount"`
+	Details    json.RawMessage `json:"details"`
+	Status     string          `json:"status"`
+	AssignedTo *int64          `json:"assigned_to"`
+	Resolution string          `json:"resolution"`
+	ClosedBy   *int64          `json:"closed_by"`
+	ClosedAt   string          `json:"closed_at"`
+	CreatedAt  string          `json:"created_at"`
+	UpdatedAt  string          `json:"updated_at"`
+}
+
+type AMLAlertNoteData struct {
+	ID        int64  `json:"id"`
+	AuthorID  int64  `json:"author_id"`
+	Note      string `json:"note"`
+	CreatedAt string `json:"created_at"`
+}
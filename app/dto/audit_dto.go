@@ -0,0 +1,49 @@
+package dto
+
+import "encoding/json"
+
+// Request
+// AuditLogListRequest filters the audit log. From and To are dates and the
+// range includes both days.
+type AuditLogListRequest struct {
+	UserID     int64  `query:"user_id" validate:"min=0"`
+	ActorID    int64  `query:"actor_id" validate:"min=0"`
+	Action     string `query:"action" validate:"max=64"`
+	TargetType string `query:"target_type" validate:"max=32"`
+	TargetID   string `query:"target_id" validate:"max=64"`
+	RequestID  string `query:"request_id" validate:"max=64"`
+	From       string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To         string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Page       int    `query:"page" validate:"min=0"`
+	Size       int    `query:"size" validate:"min=0,max=100"`
+	IPAddress  string `query:"-"`
+	UserAgent  string `query:"-"`
+}
+
+// Response
+// AuditVerifyResponse reports whether the hash chain is intact. When it is
+// not, BrokenAt is the first entry that does not match.
+type AuditVerifyResponse struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`
+	BrokenAt *int64 `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Data
+type AuditLogData struct {
+	ID         int64           `json:"id"`
+	UserID     *int64          `json:"user_id"`
+	ActorID    *int64          `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	RequestID  string          `json:"request_id"`
+	Metadata   json.RawMessage `json:"metadata"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IPAddress  string          `json:"ip_address"`
+	UserAgent  string          `json:"user_agent"`
+	Hash       string          `json:"hash"`
+	CreatedAt  string          `json:"created_at"`
+}
@@ -1,31 +1,79 @@
 package dto
 
 // Request
+// LoginRequest identifies the account by email or by a verified phone number.
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email      string `json:"email" validate:"required_without=Phone,omitempty,email"`
+	Phone      string `json:"phone" validate:"required_without=Email,omitempty,max=20"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"device_name" validate:"max=125"`
+	IPAddress  string `json:"-"`
+	UserAgent  string `json:"-"`
 }
 
 type RegisterRequest struct {
 	FullName string `json:"full_name" validate:"required"`
-	Phone    string `json:"phone" validate:"required"`
+	Phone    string `json:"phone" validate:"required,max=20"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Language string `json:"language" validate:"omitempty,oneof=id en"`
 }
 
 type EmailVerificationRequest struct {
 	ReferenceID string `json:"reference_id" validate:"required"`
 	OTP         string `json:"otp" validate:"required"`
+	DeviceName  string `json:"device_name" validate:"max=125"`
+	IPAddress   string `json:"-"`
+	UserAgent   string `json:"-"`
+}
+
+type ResendOTPRequest struct {
+	ReferenceID string `json:"reference_id" validate:"required"`
+}
+
+type PhoneLoginOTPRequest struct {
+	Phone string `json:"phone" validate:"required,max=20"`
+}
+
+type PhoneLoginRequest struct {
+	ReferenceID string `json:"reference_id" validate:"required"`
+	OTP         string `json:"otp" validate:"required"`
+	DeviceName  string `json:"device_name" validate:"max=125"`
+	IPAddress   string `json:"-"`
+	UserAgent   string `json:"-"`
+}
+
+type VerifyPhoneRequest struct {
+	OTP string `json:"otp" validate:"required"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
+	IPAddress    string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest confirms a reset with either the token from the
+// emailed link or the emailed code together with the account's email.
+type ResetPasswordRequest struct {
+	Token                string `json:"token" validate:"required_without=OTP"`
+	Email                string `json:"email" validate:"required_with=OTP,omitempty,email"`
+	OTP                  string `json:"otp" validate:"required_without=Token"`
+	Password             string `json:"password" validate:"required,password"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
 }
 
 // Response
+// LoginResponse carries either the tokens or, for accounts with two-factor
+// authentication, the challenge to complete with the second factor.
 type LoginResponse struct {
-	User  CredentialData `json:"user"`
-	Token TokenData      `json:"token"`
+	User      CredentialData          `json:"user"`
+	Token     *TokenData              `json:"token,omitempty"`
+	TwoFactor *TwoFactorChallengeData `json:"two_factor,omitempty"`
 }
 
 type RegisterResponse struct {
@@ -42,3 +90,13 @@ type TokenData struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+type SessionData struct {
+	ID         int64  `json:"id"`
+	DeviceName string `json:"device_name"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	Current    bool   `json:"current"`
+	LastUsedAt string `json:"last_used_at"`
+	CreatedAt  string `json:"created_at"`
+}
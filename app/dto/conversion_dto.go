@@ -0,0 +1,44 @@
+package dto
+
+// Request
+type ConversionQuoteRequest struct {
+	From string `json:"from" validate:"required,iso4217"`
+	To   string `json:"to" validate:"required,iso4217,nefield=From"`
+	// Amount is in the minor unit of From
+	Amount int64 `json:"amount" validate:"required,min=1"`
+}
+
+type ConversionExecuteRequest struct {
+	QuoteKey  string `json:"quote_key" validate:"required"`
+	PinCode   string `json:"pin_code" validate:"required"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ConversionListRequest struct {
+	Page int `query:"page" validate:"min=0"`
+	Size int `query:"size" validate:"min=0,max=100"`
+}
+
+// Response
+type ConversionQuoteResponse struct {
+	QuoteKey string `json:"quote_key"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Amount   int64  `json:"amount"`
+	// ConvertedAmount is what the user receives, in the minor unit of To
+	ConvertedAmount int64  `json:"converted_amount"`
+	Rate            string `json:"rate"`
+	ExpiresAt       string `json:"expires_at"`
+}
+
+// Data
+type ConversionData struct {
+	ID           int64  `json:"id"`
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	FromAmount   int64  `json:"from_amount"`
+	ToAmount     int64  `json:"to_amount"`
+	Rate         string `json:"rate"`
+	CreatedAt    string `json:"created_at"`
+}
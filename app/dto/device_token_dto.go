@@ -0,0 +1,20 @@
+package dto
+
+// Request
+type RegisterDeviceTokenRequest struct {
+	Token      string `json:"token" validate:"required"`
+	Platform   string `json:"platform" validate:"required,oneof=android ios web"`
+	DeviceName string `json:"device_name" validate:"max=125"`
+}
+
+// Response
+
+// Data
+type DeviceTokenData struct {
+	ID            int64  `json:"id"`
+	Platform      string `json:"platform"`
+	DeviceName    string `json:"device_name"`
+	FailureCount  int    `json:"failure_count"`
+	LastSuccessAt string `json:"last_success_at"`
+	CreatedAt     string `json:"created_at"`
+}
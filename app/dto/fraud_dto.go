@@ -0,0 +1,75 @@
+package dto
+
+// Request
+// UpdateFraudRuleRequest changes how a rule acts; params replace the rule's
+// thresholds when given.
+type UpdateFraudRuleRequest struct {
+	Action    string           `json:"action" validate:"omitempty,oneof=challenge block"`
+	Enabled   *bool            `json:"enabled"`
+	Params    *FraudRuleParams `json:"params"`
+	IPAddress string           `json:"-"`
+	UserAgent string           `json:"-"`
+}
+
+type FraudRuleParams struct {
+	MaxCount       int64 `json:"max_count" validate:"min=0"`
+	WindowMinutes  int   `json:"window_minutes" validate:"min=0,max=10080"`
+	MinAmount      int64 `json:"min_amount" validate:"min=0"`
+	DeviceAgeHours int   `json:"device_age_hours" validate:"min=0,max=8760"`
+	MinPercent     int64 `json:"min_percent" validate:"min=0,max=100"`
+}
+
+type FraudDecisionListRequest struct {
+	UserID    int64  `query:"user_id" validate:"min=0"`
+	Outcome   string `query:"outcome" validate:"omitempty,oneof=challenge block"`
+	Reviewed  string `query:"reviewed" validate:"omitempty,oneof=true false"`
+	Page      int    `query:"page" validate:"min=0"`
+	Size      int    `query:"size" validate:"min=0,max=100"`
+	IPAddress string `query:"-"`
+	UserAgent string `query:"-"`
+}
+
+type ReviewFraudDecisionRequest struct {
+	Note      string `json:"note" validate:"required,max=255"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// Data
+type FraudRuleData struct {
+	ID          int64           `json:"id"`
+	Code        string          `json:"code"`
+	Type        string          `json:"type"`
+	Action      string          `json:"action"`
+	Params      FraudRuleParams `json:"params"`
+	Description string          `json:"description"`
+	Enabled     bool            `json:"enabled"`
+	UpdatedBy   *int64          `json:"updated_by"`
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+type FraudDecisionData struct {
+	ID                int64    `json:"id"`
+	UserID            int64    `json:"user_id"`
+	WalletID          int64    `json:"wallet_id"`
+	SessionID         *int64   `json:"session_id"`
+	Stage             string   `json:"stage"`
+	DofNumber         string   `json:"dof_number"`
+	Amount            int64    `json:"amount"`
+	Currency          string   `json:"currency"`
+	Outcome           string   `json:"outcome"`
+	MatchedRules      []string `json:"matched_rules"`
+	IPAddress         string   `json:"ip_address"`
+	ChallengePassedAt string   `json:"challenge_passed_at"`
+	ReviewedBy        *int64   `json:"reviewed_by"`
+	ReviewedAt        string   `json:"reviewed_at"`
+	ReviewNote        string   `json:"review_note"`
+	CreatedAt         string   `json:"created_at"`
+}
+
+// TransferChallengeData tells the client a code was sent to confirm the
+// transfer, and where.
+type TransferChallengeData struct {
+	Channel   string `json:"channel"`
+	ExpiresIn int    `json:"expires_in"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// Response
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Data
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
@@ -0,0 +1,53 @@
+package dto
+
+// Request
+// SubmitKYCRequest carries the identity data from a multipart form; the
+// images are read from the id_card and selfie file fields.
+type SubmitKYCRequest struct {
+	NIK       string `form:"nik" validate:"required,len=16,numeric"`
+	FullName  string `form:"full_name" validate:"required,max=125"`
+	IDCard    []byte `form:"-" validate:"required"`
+	Selfie    []byte `form:"-" validate:"required"`
+	IPAddress string `form:"-"`
+	UserAgent string `form:"-"`
+}
+
+type KYCListRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending approved rejected"`
+	Page   int    `query:"page" validate:"min=0"`
+	Size   int    `query:"size" validate:"min=0,max=100"`
+}
+
+// ReviewKYCRequest is the reviewer's decision; a reason is required to
+// reject a submission.
+type ReviewKYCRequest struct {
+	Reason    string `json:"reason" validate:"max=255"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// Response
+type KYCStatusResponse struct {
+	Level      int16              `json:"level"`
+	Submission *KYCSubmissionData `json:"submission"`
+}
+
+type KYCDocumentFile struct {
+	ContentType string
+	Content     []byte
+}
+
+// Data
+type KYCSubmissionData struct {
+	ID              int64  `json:"id"`
+	UserID          int64  `json:"user_id"`
+	NIK             string `json:"nik"`
+	FullName        string `json:"full_name"`
+	BirthDate       string `json:"birth_date"`
+	Status          string `json:"status"`
+	RejectionReason string `json:"rejection_reason"`
+	ReviewedBy      *int64 `json:"reviewed_by"`
+	ReviewedAt      string `json:"reviewed_at"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
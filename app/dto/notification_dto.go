@@ -8,6 +8,17 @@ type CreateNotificationRequest struct {
 	Body   string `json:"body"`
 }
 
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences" validate:"required,min=1,dive"`
+}
+
+type NotificationPreferenceRequest struct {
+	EventType string `json:"event_type" validate:"required,oneof=transfer_sent transfer_received topup_success security_alert"`
+	InApp     bool   `json:"in_app"`
+	Email     bool   `json:"email"`
+	Push      bool   `json:"push"`
+}
+
 // Response
 
 // Data
@@ -21,3 +32,11 @@ type NotificationData struct {
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
+
+type NotificationPreferenceData struct {
+	EventType string `json:"event_type"`
+	InApp     bool   `json:"in_app"`
+	Email     bool   `json:"email"`
+	Push      bool   `json:"push"`
+	Mandatory bool   `json:"mandatory"`
+}
@@ -4,6 +4,8 @@ package dto
 type SetupWalletPINRequest struct {
 	PinCode             string `json:"pin_code" validate:"required,numeric"`
 	PinCodeConfirmation string `json:"pin_code_confirmation" validate:"required,numeric"`
+	IPAddress           string `json:"-"`
+	UserAgent           string `json:"-"`
 }
 
 // Response
@@ -0,0 +1,16 @@
+package dto
+
+// Request
+type StatementRequest struct {
+	Period string `json:"period" query:"period" validate:"required,datetime=2006-01"`
+	Format string `json:"format" query:"format" validate:"required,oneof=csv pdf"`
+	// Currency picks the balance the statement covers, rupiah when empty
+	Currency string `json:"currency" query:"currency" validate:"omitempty,iso4217"`
+}
+
+// Response
+type StatementFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
@@ -5,6 +5,12 @@ type TopUpRequest struct {
 	Amount int64 `json:"amount"`
 }
 
+type ReleaseTopUpRequest struct {
+	Reason    string `json:"reason" validate:"required,max=255"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
 // Response
 type TopUpResponse struct {
 	SnapURL string `json:"snap_url"`
@@ -12,12 +18,11 @@ type TopUpResponse struct {
 
 // Data
 type TopUpData struct {
-	ID        int64  `json:"id"`
+	ID        string `json:"id"`
 	UserID    int64  `json:"user_id"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
 	Status    int8   `json:"status"`
-	Title     string `json:"title"`
-	Body      string `json:"body"`
-	IsRead    bool   `json:"is_read"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
@@ -2,18 +2,32 @@ package dto
 
 // Request
 type TransferInquiryRequest struct {
-	AccountNumber string `json:"account_number"`
-	Amount        int64  `json:"amount"`
+	AccountNumber string `json:"account_number" validate:"required"`
+	// Amount is in the minor unit of Currency, rupiah when none is given
+	Amount   int64  `json:"amount" validate:"required,min=1"`
+	Currency string `json:"currency" validate:"omitempty,iso4217"`
+	// PocketID picks the pocket the money leaves from, the main wallet
+	// when zero
+	PocketID  int64  `json:"pocket_id" validate:"min=0"`
+	SessionID int64  `json:"-"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type TransferExecuteRequest struct {
 	InquiryKey string `json:"inquiry_key" validate:"required"`
 	PinCode    string `json:"pin_code" validate:"required"`
+	// OTPCode answers the challenge sent when a transfer looks risky
+	OTPCode   string `json:"otp_code" validate:"omitempty,numeric"`
+	SessionID int64  `json:"-"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 // Response
 type TransferInquiryResponse struct {
-	InquiryKey string `json:"inquiry_key"`
+	InquiryKey string                 `json:"inquiry_key"`
+	Challenge  *TransferChallengeData `json:"challenge,omitempty"`
 }
 
 type TransferExecuteResponse struct {
@@ -28,6 +42,7 @@ type TransactionData struct {
 	SofNumber       string `json:"sof_number"`
 	DofNumber       string `json:"dof_number"`
 	Amount          int64  `json:"amount"`
+	Currency        string `json:"currency"`
 	TransactionType string `json:"transaction_type"`
 	TransactionAt   string `json:"transaction_at"`
 }
@@ -36,6 +51,7 @@ type TransferData struct {
 	SofNumber     string `json:"sof_number"`
 	DofNumber     string `json:"dof_number"`
 	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
 	TransactionAt string `json:"transaction_at"`
 	Status        string `json:"status"`
 }
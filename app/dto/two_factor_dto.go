@@ -0,0 +1,41 @@
+package dto
+
+// Request
+type ConfirmTwoFactorRequest struct {
+	Code      string `json:"code" validate:"required,numeric,len=6"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// DisableTwoFactorRequest turns the second factor off, proven with the
+// password and either an authenticator code or a recovery code.
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code"`
+	IPAddress    string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+// VerifyTwoFactorRequest completes a login challenged for a second factor,
+// with either an authenticator code or a recovery code.
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+	IPAddress      string `json:"-"`
+	UserAgent      string `json:"-"`
+}
+
+// Response
+type EnrollTwoFactorResponse struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Data
+type TwoFactorChallengeData struct {
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int    `json:"expires_in"`
+}
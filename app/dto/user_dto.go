@@ -1,17 +1,59 @@
 package dto
 
 // Request
+type UpdateLanguageRequest struct {
+	Language string `json:"language" validate:"required,oneof=id en"`
+}
+
+// UpdateProfileRequest changes the fields that are given; a new phone number
+// has to be verified again.
+type UpdateProfileRequest struct {
+	FullName  string `json:"full_name" validate:"required_without=Phone,max=125"`
+	Phone     string `json:"phone" validate:"required_without=FullName,max=20"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ChangeEmailRequest struct {
+	Email     string `json:"email" validate:"required,email,max=75"`
+	Password  string `json:"password" validate:"required"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	OTP       string `json:"otp" validate:"required"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword      string `json:"current_password" validate:"required"`
+	Password             string `json:"password" validate:"required,password"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+	IPAddress            string `json:"-"`
+	UserAgent            string `json:"-"`
+}
 
 // Response
+type ProfileResponse struct {
+	User   UserData    `json:"user"`
+	Wallet *WalletData `json:"wallet"`
+}
 
 // Data
 type UserData struct {
-	ID              int64  `json:"id"`
-	FullName        string `json:"full_name"`
-	Phone           string `json:"phone"`
-	Email           string `json:"email"`
-	EmailVerifiedAt string `json:"email_verified_at"`
-	CreatedAt       string `json:"created_at"`
-	UpdatedAt       string `json:"updated_at"`
-	IsActive        bool   `json:"is_active"`
+	ID               int64  `json:"id"`
+	FullName         string `json:"full_name"`
+	Phone            string `json:"phone"`
+	Email            string `json:"email"`
+	EmailVerifiedAt  string `json:"email_verified_at"`
+	PhoneVerifiedAt  string `json:"phone_verified_at"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+	KYCLevel         int16  `json:"kyc_level"`
+	Role             string `json:"role"`
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
+	IsActive         bool   `json:"is_active"`
+	Language         string `json:"language"`
 }
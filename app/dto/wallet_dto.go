@@ -1,12 +1,68 @@
 package dto
 
 // Request
+type OpenBalanceRequest struct {
+	Currency string `json:"currency" validate:"required,iso4217"`
+}
+
+// PocketRequest opens or edits a pocket. Currency is only read when opening,
+// rupiah when empty; a zero TargetAmount means no target.
+type PocketRequest struct {
+	Name         string `json:"name" validate:"required,max=64"`
+	Currency     string `json:"currency" validate:"omitempty,iso4217"`
+	TargetAmount int64  `json:"target_amount" validate:"min=0"`
+	LockedUntil  string `json:"locked_until" validate:"omitempty,datetime=2006-01-02"`
+	IPAddress    string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+type ClosePocketRequest struct {
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// MovePocketMoneyRequest moves money between the user's own wallets. A zero
+// pocket ID stands for the main wallet.
+type MovePocketMoneyRequest struct {
+	FromPocketID int64  `json:"from_pocket_id" validate:"min=0"`
+	ToPocketID   int64  `json:"to_pocket_id" validate:"min=0,nefield=FromPocketID"`
+	Amount       int64  `json:"amount" validate:"required,min=1"`
+	IPAddress    string `json:"-"`
+	UserAgent    string `json:"-"`
+}
 
 // Response
 
 // Data
 type WalletData struct {
-	ID        int64  `json:"id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID           int64               `json:"id"`
+	WalletNumber string              `json:"wallet_number"`
+	Balances     []WalletBalanceData `json:"balances"`
+	Status       string              `json:"status"`
+	PinSet       bool                `json:"pin_set"`
+	CreatedAt    string              `json:"created_at"`
+	UpdatedAt    string              `json:"updated_at"`
+}
+
+// PocketData is a pocket with its balance, in the minor unit of Currency.
+type PocketData struct {
+	ID           int64  `json:"id"`
+	WalletNumber string `json:"wallet_number"`
+	Name         string `json:"name"`
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	Formatted    string `json:"formatted"`
+	TargetAmount *int64 `json:"target_amount"`
+	LockedUntil  string `json:"locked_until"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// WalletBalanceData is a balance in one currency. Balance is in the
+// currency's minor unit, MinorUnits digits below the major one.
+type WalletBalanceData struct {
+	Currency   string `json:"currency"`
+	Balance    int64  `json:"balance"`
+	MinorUnits int    `json:"minor_units"`
+	Formatted  string `json:"formatted"`
 }
@@ -16,18 +16,84 @@ import (
 )
 
 var authSet = wire.NewSet(
+	repository.NewSession,
+	wire.Bind(new(domain.SessionRepository), new(*repository.SessionRepository)),
+	repository.NewRefreshToken,
+	wire.Bind(new(domain.RefreshTokenRepository), new(*repository.RefreshTokenRepository)),
 	usecase.NewAuthUseCase,
 	controller.NewAuthController,
 )
 
+var twoFactorSet = wire.NewSet(
+	repository.NewRecoveryCode,
+	wire.Bind(new(domain.RecoveryCodeRepository), new(*repository.RecoveryCodeRepository)),
+	usecase.NewTwoFactorUseCase,
+	controller.NewTwoFactorController,
+)
+
+var auditSet = wire.NewSet(
+	repository.NewAuditLog,
+	wire.Bind(new(domain.AuditLogRepository), new(*repository.AuditLogRepository)),
+	usecase.NewAuditUseCase,
+	controller.NewAuditController,
+)
+
 var userSet = wire.NewSet(
 	repository.NewUser,
 	wire.Bind(new(domain.UserRepository), new(*repository.UserRepository)),
+	usecase.NewUserUseCase,
+	controller.NewUserController,
+)
+
+var accountSet = wire.NewSet(
+	usecase.NewAccountUseCase,
+	controller.NewAccountController,
+)
+
+var kycSet = wire.NewSet(
+	repository.NewKYCSubmission,
+	wire.Bind(new(domain.KYCSubmissionRepository), new(*repository.KYCSubmissionRepository)),
+	usecase.NewKYCUseCase,
+	controller.NewKYCController,
+)
+
+var adminSet = wire.NewSet(
+	usecase.NewAdminUseCase,
+	controller.NewAdminController,
+)
+
+var fraudSet = wire.NewSet(
+	repository.NewFraudRule,
+	wire.Bind(new(domain.FraudRuleRepository), new(*repository.FraudRuleRepository)),
+	repository.NewFraudDecision,
+	wire.Bind(new(domain.FraudDecisionRepository), new(*repository.FraudDecisionRepository)),
+	usecase.NewFraudUseCase,
+	controller.NewFraudController,
+)
+
+var amlSet = wire.NewSet(
+	repository.NewAMLAlert,
+	wire.Bind(new(domain.AMLAlertRepository), new(*repository.AMLAlertRepository)),
+	repository.NewAMLAlertNote,
+	wire.Bind(new(domain.AMLAlertNoteRepository), new(*repository.AMLAlertNoteRepository)),
+	usecase.NewAMLUseCase,
+	controller.NewAMLController,
 )
 
 var walletSet = wire.NewSet(
 	repository.NewWallet,
 	wire.Bind(new(domain.WalletRepository), new(*repository.WalletRepository)),
+	repository.NewWalletBalance,
+	wire.Bind(new(domain.WalletBalanceRepository), new(*repository.WalletBalanceRepository)),
+	usecase.NewWalletUseCase,
+	controller.NewWalletController,
+)
+
+var conversionSet = wire.NewSet(
+	repository.NewConversion,
+	wire.Bind(new(domain.ConversionRepository), new(*repository.ConversionRepository)),
+	usecase.NewConversionUseCase,
+	controller.NewConversionController,
 )
 
 var pinRecoverySet = wire.NewSet(
@@ -40,10 +106,19 @@ var pinRecoverySet = wire.NewSet(
 var notificationSet = wire.NewSet(
 	repository.NewNotification,
 	wire.Bind(new(domain.NotificationRepository), new(*repository.NotificationRepository)),
+	repository.NewNotificationPreference,
+	wire.Bind(new(domain.NotificationPreferenceRepository), new(*repository.NotificationPreferenceRepository)),
 	usecase.NewNotificationUseCase,
 	controller.NewNotificationController,
 )
 
+var deviceTokenSet = wire.NewSet(
+	repository.NewDeviceToken,
+	wire.Bind(new(domain.DeviceTokenRepository), new(*repository.DeviceTokenRepository)),
+	usecase.NewDeviceTokenUseCase,
+	controller.NewDeviceTokenController,
+)
+
 var transactionSet = wire.NewSet(
 	repository.NewTransaction,
 	wire.Bind(new(domain.TransactionRepository), new(*repository.TransactionRepository)),
@@ -58,12 +133,27 @@ var topUpSet = wire.NewSet(
 	controller.NewTopUpController,
 )
 
+var emailSet = wire.NewSet(
+	util.NewEmailTransport,
+	util.NewEmailUtil,
+	wire.Bind(new(domain.Email), new(*util.EmailUtil)),
+	wire.Bind(new(domain.EmailQueue), new(*util.EmailUtil)),
+)
+
+var statementSet = wire.NewSet(
+	usecase.NewStatementUseCase,
+	controller.NewStatementController,
+)
+
 var mainSet = wire.NewSet(
 	controller.NewMainController,
+	controller.NewJWKSController,
 )
 
 var middlewareSet = wire.NewSet(
 	middleware.NewAuthMiddleware,
+	middleware.NewRateLimitMiddleware,
+	middleware.NewPermissionMiddleware,
 )
 
 func InitializedApp() *config.App {
@@ -76,16 +166,35 @@ func InitializedApp() *config.App {
 		config.NewApp,
 		config.NewRedisClient,
 		delivery.NewRouter,
+		delivery.NewWorker,
 		util.NewJWTUtil,
+		util.NewTokenDenylistUtil,
+		util.NewRateLimiterUtil,
 		util.NewMidtransUtil,
-		util.NewEmailUtil,
+		util.NewPushUtil,
+		util.NewSMSUtil,
+		util.NewTemplateUtil,
+		util.NewTOTPUtil,
+		util.NewObjectStoreUtil,
+		util.NewFXRateUtil,
+		emailSet,
 		authSet,
+		twoFactorSet,
+		auditSet,
 		userSet,
+		accountSet,
+		kycSet,
+		adminSet,
+		fraudSet,
+		amlSet,
 		walletSet,
+		conversionSet,
 		notificationSet,
+		deviceTokenSet,
 		transactionSet,
 		pinRecoverySet,
 		topUpSet,
+		statementSet,
 		mainSet,
 		middlewareSet,
 	)
@@ -23,52 +23,124 @@ import (
 func InitializedApp() *config.App {
 	configConfig := config.Get()
 	app := config.NewFiber(configConfig)
-	jwt := util.NewJWTUtil(configConfig)
-	v := middleware.NewAuthMiddleware(jwt)
 	logger := config.NewLogger(configConfig)
+	jwt := util.NewJWTUtil(configConfig, logger)
+	client := config.NewRedisClient(configConfig)
+	tokenDenylist := util.NewTokenDenylistUtil(configConfig, client)
+	v := middleware.NewAuthMiddleware(jwt, tokenDenylist)
+	rateLimiter := util.NewRateLimiterUtil(configConfig, client)
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(rateLimiter, jwt, logger)
 	db := config.NewDatabase(configConfig, logger)
 	userRepository := repository.NewUser(logger)
+	permissionMiddleware := middleware.NewPermissionMiddleware(db, userRepository, logger)
 	walletRepository := repository.NewWallet(logger)
+	walletBalanceRepository := repository.NewWalletBalance(logger)
+	sessionRepository := repository.NewSession(logger)
+	refreshTokenRepository := repository.NewRefreshToken(logger)
+	recoveryCodeRepository := repository.NewRecoveryCode(logger)
+	notificationRepository := repository.NewNotification(logger)
+	notificationPreferenceRepository := repository.NewNotificationPreference(logger)
+	deviceTokenRepository := repository.NewDeviceToken(logger)
+	push := util.NewPushUtil(configConfig, logger)
+	emailTransport := util.NewEmailTransport(configConfig)
+	emailUtil := util.NewEmailUtil(configConfig, emailTransport, client, logger)
+	template := util.NewTemplateUtil()
 	validate := config.NewValidator(configConfig)
-	client := config.NewRedisClient(configConfig)
-	email := util.NewEmailUtil(configConfig)
-	authUseCase := usecase.NewAuthUseCase(db, logger, userRepository, walletRepository, jwt, validate, client, email)
+	notificationUseCase := usecase.NewNotificationUseCase(db, logger, notificationRepository, notificationPreferenceRepository, deviceTokenRepository, userRepository, push, emailUtil, template, validate)
+	auditLogRepository := repository.NewAuditLog(logger)
+	auditUseCase := usecase.NewAuditUseCase(db, logger, auditLogRepository, validate)
+	totp := util.NewTOTPUtil(configConfig)
+	sms := util.NewSMSUtil(configConfig, logger)
+	authUseCase := usecase.NewAuthUseCase(db, configConfig, logger, userRepository, walletRepository, sessionRepository, refreshTokenRepository, recoveryCodeRepository, notificationUseCase, auditUseCase, jwt, totp, tokenDenylist, validate, client, emailUtil, sms, template)
 	authController := controller.NewAuthController(authUseCase, logger)
+	jwksController := controller.NewJWKSController(jwt, logger)
+	twoFactorUseCase := usecase.NewTwoFactorUseCase(db, logger, configConfig, userRepository, recoveryCodeRepository, notificationUseCase, authUseCase, totp, validate)
+	twoFactorController := controller.NewTwoFactorController(twoFactorUseCase, logger)
 	transactionRepository := repository.NewTransaction(logger)
-	notificationRepository := repository.NewNotification(logger)
-	transactionUseCase := usecase.NewTransactionUseCase(db, logger, walletRepository, transactionRepository, notificationRepository, validate, client)
+	fraudRuleRepository := repository.NewFraudRule(logger)
+	fraudDecisionRepository := repository.NewFraudDecision(logger)
+	fraudUseCase := usecase.NewFraudUseCase(db, logger, fraudRuleRepository, fraudDecisionRepository, transactionRepository, sessionRepository, userRepository, auditUseCase, emailUtil, sms, template, validate, client)
+	transactionUseCase := usecase.NewTransactionUseCase(db, logger, walletRepository, walletBalanceRepository, transactionRepository, notificationUseCase, auditUseCase, fraudUseCase, validate, client)
 	transactionController := controller.NewTransactionController(transactionUseCase, logger)
 	pinRecoveryRepository := repository.NewPinRecovery(logger)
-	pinRecoveryUseCase := usecase.NewPinRecoveryUseCase(db, logger, walletRepository, pinRecoveryRepository, validate)
+	pinRecoveryUseCase := usecase.NewPinRecoveryUseCase(db, logger, walletRepository, pinRecoveryRepository, notificationUseCase, auditUseCase, tokenDenylist, validate)
 	pinRecoveryController := controller.NewPinRecoveryController(pinRecoveryUseCase, logger)
-	notificationUseCase := usecase.NewNotificationUseCase(db, logger, notificationRepository, validate)
 	notificationController := controller.NewNotificationController(notificationUseCase, logger)
+	deviceTokenUseCase := usecase.NewDeviceTokenUseCase(db, logger, deviceTokenRepository, validate)
+	deviceTokenController := controller.NewDeviceTokenController(deviceTokenUseCase, logger)
 	midtrans := util.NewMidtransUtil(configConfig)
 	topUpRepository := repository.NewTopUp(logger)
-	topUpUseCase := usecase.NewTopUpUseCase(db, logger, notificationRepository, midtrans, topUpRepository, walletRepository, transactionRepository, validate)
+	topUpUseCase := usecase.NewTopUpUseCase(db, logger, notificationUseCase, auditUseCase, midtrans, topUpRepository, walletRepository, walletBalanceRepository, transactionRepository, validate)
 	topUpController := controller.NewTopUpController(topUpUseCase, logger, midtrans)
+	userUseCase := usecase.NewUserUseCase(db, logger, configConfig, userRepository, walletRepository, walletBalanceRepository, sessionRepository, auditUseCase, notificationUseCase, tokenDenylist, emailUtil, template, validate, client)
+	userController := controller.NewUserController(userUseCase, logger)
+	accountUseCase := usecase.NewAccountUseCase(db, logger, userRepository, walletRepository, walletBalanceRepository, transactionRepository, topUpRepository, sessionRepository, recoveryCodeRepository, notificationRepository, notificationPreferenceRepository, deviceTokenRepository, auditUseCase, tokenDenylist, emailUtil, template, validate)
+	accountController := controller.NewAccountController(accountUseCase, logger)
+	kycSubmissionRepository := repository.NewKYCSubmission(logger)
+	objectStore := util.NewObjectStoreUtil(configConfig)
+	kycUseCase := usecase.NewKYCUseCase(db, logger, userRepository, kycSubmissionRepository, auditUseCase, notificationUseCase, objectStore, validate)
+	kycController := controller.NewKYCController(kycUseCase, logger)
+	adminUseCase := usecase.NewAdminUseCase(db, logger, userRepository, walletRepository, walletBalanceRepository, transactionRepository, auditUseCase, authUseCase, notificationUseCase, validate)
+	adminController := controller.NewAdminController(adminUseCase, logger)
+	auditController := controller.NewAuditController(auditUseCase, logger)
+	fraudController := controller.NewFraudController(fraudUseCase, logger)
+	amlAlertRepository := repository.NewAMLAlert(logger)
+	amlAlertNoteRepository := repository.NewAMLAlertNote(logger)
+	amlUseCase := usecase.NewAMLUseCase(db, logger, configConfig, amlAlertRepository, amlAlertNoteRepository, transactionRepository, topUpRepository, walletRepository, userRepository, kycSubmissionRepository, auditUseCase, validate, client)
+	amlController := controller.NewAMLController(amlUseCase, logger)
+	statementUseCase := usecase.NewStatementUseCase(db, logger, configConfig, userRepository, walletRepository, walletBalanceRepository, transactionRepository, emailUtil, template, validate, client)
+	statementController := controller.NewStatementController(statementUseCase, logger)
+	walletUseCase := usecase.NewWalletUseCase(db, logger, walletRepository, walletBalanceRepository, transactionRepository, auditUseCase, validate)
+	walletController := controller.NewWalletController(walletUseCase, logger)
+	conversionRepository := repository.NewConversion(logger)
+	fxRates := util.NewFXRateUtil(configConfig, logger)
+	conversionUseCase := usecase.NewConversionUseCase(db, logger, configConfig, walletRepository, walletBalanceRepository, transactionRepository, conversionRepository, auditUseCase, fxRates, validate, client)
+	conversionController := controller.NewConversionController(conversionUseCase, logger)
 	mainController := controller.NewMainController(logger)
-	routerConfig := delivery.NewRouter(app, v, authController, transactionController, pinRecoveryController, notificationController, topUpController, mainController)
-	configApp := config.NewApp(routerConfig, configConfig)
+	routerConfig := delivery.NewRouter(app, v, rateLimitMiddleware, permissionMiddleware, authController, jwksController, twoFactorController, userController, accountController, kycController, adminController, auditController, fraudController, amlController, transactionController, pinRecoveryController, notificationController, deviceTokenController, statementController, walletController, conversionController, topUpController, mainController)
+	workerConfig := delivery.NewWorker(emailUtil, statementUseCase, amlUseCase, auditUseCase, jwt)
+	configApp := config.NewApp(routerConfig, workerConfig, configConfig)
 	return configApp
 }
 
 // app.go:
 
-var authSet = wire.NewSet(usecase.NewAuthUseCase, controller.NewAuthController)
+var authSet = wire.NewSet(repository.NewSession, wire.Bind(new(domain.SessionRepository), new(*repository.SessionRepository)), repository.NewRefreshToken, wire.Bind(new(domain.RefreshTokenRepository), new(*repository.RefreshTokenRepository)), usecase.NewAuthUseCase, controller.NewAuthController)
+
+var twoFactorSet = wire.NewSet(repository.NewRecoveryCode, wire.Bind(new(domain.RecoveryCodeRepository), new(*repository.RecoveryCodeRepository)), usecase.NewTwoFactorUseCase, controller.NewTwoFactorController)
+
+var auditSet = wire.NewSet(repository.NewAuditLog, wire.Bind(new(domain.AuditLogRepository), new(*repository.AuditLogRepository)), usecase.NewAuditUseCase, controller.NewAuditController)
+
+var userSet = wire.NewSet(repository.NewUser, wire.Bind(new(domain.UserRepository), new(*repository.UserRepository)), usecase.NewUserUseCase, controller.NewUserController)
+
+var accountSet = wire.NewSet(usecase.NewAccountUseCase, controller.NewAccountController)
 
-var userSet = wire.NewSet(repository.NewUser, wire.Bind(new(domain.UserRepository), new(*repository.UserRepository)))
+var kycSet = wire.NewSet(repository.NewKYCSubmission, wire.Bind(new(domain.KYCSubmissionRepository), new(*repository.KYCSubmissionRepository)), usecase.NewKYCUseCase, controller.NewKYCController)
 
-var walletSet = wire.NewSet(repository.NewWallet, wire.Bind(new(domain.WalletRepository), new(*repository.WalletRepository)))
+var adminSet = wire.NewSet(usecase.NewAdminUseCase, controller.NewAdminController)
+
+var fraudSet = wire.NewSet(repository.NewFraudRule, wire.Bind(new(domain.FraudRuleRepository), new(*repository.FraudRuleRepository)), repository.NewFraudDecision, wire.Bind(new(domain.FraudDecisionRepository), new(*repository.FraudDecisionRepository)), usecase.NewFraudUseCase, controller.NewFraudController)
+
+var amlSet = wire.NewSet(repository.NewAMLAlert, wire.Bind(new(domain.AMLAlertRepository), new(*repository.AMLAlertRepository)), repository.NewAMLAlertNote, wire.Bind(new(domain.AMLAlertNoteRepository), new(*repository.AMLAlertNoteRepository)), usecase.NewAMLUseCase, controller.NewAMLController)
+
+var walletSet = wire.NewSet(repository.NewWallet, wire.Bind(new(domain.WalletRepository), new(*repository.WalletRepository)), repository.NewWalletBalance, wire.Bind(new(domain.WalletBalanceRepository), new(*repository.WalletBalanceRepository)), usecase.NewWalletUseCase, controller.NewWalletController)
+
+var conversionSet = wire.NewSet(repository.NewConversion, wire.Bind(new(domain.ConversionRepository), new(*repository.ConversionRepository)), usecase.NewConversionUseCase, controller.NewConversionController)
 
 var pinRecoverySet = wire.NewSet(repository.NewPinRecovery, wire.Bind(new(domain.PinRecoveryRepository), new(*repository.PinRecoveryRepository)), usecase.NewPinRecoveryUseCase, controller.NewPinRecoveryController)
 
-var notificationSet = wire.NewSet(repository.NewNotification, wire.Bind(new(domain.NotificationRepository), new(*repository.NotificationRepository)), usecase.NewNotificationUseCase, controller.NewNotificationController)
+var notificationSet = wire.NewSet(repository.NewNotification, wire.Bind(new(domain.NotificationRepository), new(*repository.NotificationRepository)), repository.NewNotificationPreference, wire.Bind(new(domain.NotificationPreferenceRepository), new(*repository.NotificationPreferenceRepository)), usecase.NewNotificationUseCase, controller.NewNotificationController)
+
+var deviceTokenSet = wire.NewSet(repository.NewDeviceToken, wire.Bind(new(domain.DeviceTokenRepository), new(*repository.DeviceTokenRepository)), usecase.NewDeviceTokenUseCase, controller.NewDeviceTokenController)
 
 var transactionSet = wire.NewSet(repository.NewTransaction, wire.Bind(new(domain.TransactionRepository), new(*repository.TransactionRepository)), usecase.NewTransactionUseCase, controller.NewTransactionController)
 
 var topUpSet = wire.NewSet(repository.NewTopUp, wire.Bind(new(domain.TopUpRepository), new(*repository.TopUpRepository)), usecase.NewTopUpUseCase, controller.NewTopUpController)
 
-var mainSet = wire.NewSet(controller.NewMainController)
+var emailSet = wire.NewSet(util.NewEmailTransport, util.NewEmailUtil, wire.Bind(new(domain.Email), new(*util.EmailUtil)), wire.Bind(new(domain.EmailQueue), new(*util.EmailUtil)))
+
+var statementSet = wire.NewSet(usecase.NewStatementUseCase, controller.NewStatementController)
+
+var mainSet = wire.NewSet(controller.NewMainController, controller.NewJWKSController)
 
-var middlewareSet = wire.NewSet(middleware.NewAuthMiddleware)
+var middlewareSet = wire.NewSet(middleware.NewAuthMiddleware, middleware.NewRateLimitMiddleware, middleware.NewPermissionMiddleware)
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type AMLAlertNoteRepository struct {
+	Repository[domain.AMLAlertNoteEntity]
+	Log *logrus.Logger
+}
+
+func NewAMLAlertNote(log *logrus.Logger) *AMLAlertNoteRepository {
+	return &AMLAlertNoteRepository{
+		Log: log,
+	}
+}
+
+func (a *AMLAlertNoteRepository) FindByAlertID(db *gorm.DB, notes *[]domain.AMLAlertNoteEntity, alertID int64) error {
+	return db.Where("alert_id = ?", alertID).Order("created_at, id").Find(notes).Error
+}
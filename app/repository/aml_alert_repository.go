@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"riz.it/domped/app/domain"
+)
+
+type AMLAlertRepository struct {
+	Repository[domain.AMLAlertEntity]
+	Log *logrus.Logger
+}
+
+func NewAMLAlert(log *logrus.Logger) *AMLAlertRepository {
+	return &AMLAlertRepository{
+		Log: log,
+	}
+}
+
+func (a *AMLAlertRepository) CreateIfNew(db *gorm.DB, alert *domain.AMLAlertEntity) (bool, error) {
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "fingerprint"}},
+		DoNothing: true,
+	}).Create(alert)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (a *AMLAlertRepository) FindPage(db *gorm.DB, alerts *[]domain.AMLAlertEntity, filter *domain.AMLAlertFilter, page int, size int) (total int64, err error) {
+	query := db.Model(&domain.AMLAlertEntity{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.AssignedTo != 0 {
+		query = query.Where("assigned_to = ?", filter.AssignedTo)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	err = query.Order("created_at DESC, id DESC").Offset((page - 1) * size).Limit(size).Find(alerts).Error
+	return total, err
+}
+
+func (a *AMLAlertRepository) FindClosedBetween(db *gorm.DB, alerts *[]domain.AMLAlertEntity, resolution string, from, to time.Time) error {
+	return db.Where("status = ? AND resolution = ? AND closed_at >= ? AND closed_at < ?", domain.AMLStatusClosed, resolution, from, to).
+		Order("closed_at, id").
+		Find(alerts).Error
+}
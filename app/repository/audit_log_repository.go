@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type AuditLogRepository struct {
+	Repository[domain.AuditLogEntity]
+	Log *logrus.Logger
+}
+
+func NewAuditLog(log *logrus.Logger) *AuditLogRepository {
+	return &AuditLogRepository{
+		Log: log,
+	}
+}
+
+func (a *AuditLogRepository) FindLatest(db *gorm.DB, log *domain.AuditLogEntity) error {
+	return db.Where("chain_seq IS NOT NULL").Order("chain_seq DESC").First(log).Error
+}
+
+func (a *AuditLogRepository) FindPage(db *gorm.DB, logs *[]domain.AuditLogEntity, filter *domain.AuditLogFilter, page int, size int) (total int64, err error) {
+	query := db.Model(&domain.AuditLogEntity{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	err = query.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(logs).Error
+	return total, err
+}
+
+func (a *AuditLogRepository) FindChainAfter(db *gorm.DB, logs *[]domain.AuditLogEntity, afterSeq int64, limit int) error {
+	return db.Where("chain_seq > ?", afterSeq).Order("chain_seq ASC").Limit(limit).Find(logs).Error
+}
+
+func (a *AuditLogRepository) FindUnchained(db *gorm.DB, logs *[]domain.AuditLogEntity, limit int) error {
+	return db.Where("hash IS NULL").Order("id ASC").Limit(limit).Find(logs).Error
+}
+
+func (a *AuditLogRepository) Link(db *gorm.DB, log *domain.AuditLogEntity) error {
+	return db.Model(log).UpdateColumns(map[string]any{
+		"prev_hash": log.PrevHash,
+		"hash":      log.Hash,
+		"chain_seq": log.ChainSeq,
+	}).Error
+}
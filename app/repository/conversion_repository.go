@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type ConversionRepository struct {
+	Repository[domain.ConversionEntity]
+	Log *logrus.Logger
+}
+
+func NewConversion(log *logrus.Logger) *ConversionRepository {
+	return &ConversionRepository{
+		Log: log,
+	}
+}
+
+func (c *ConversionRepository) FindPageByWalletID(db *gorm.DB, conversions *[]domain.ConversionEntity, walletID int64, page int, size int) (total int64, err error) {
+	find := db.Model(&domain.ConversionEntity{}).Where("wallet_id = ?", walletID)
+	if err := find.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	err = find.Order("created_at DESC, id DESC").Offset((page - 1) * size).Limit(size).Find(conversions).Error
+	return total, err
+}
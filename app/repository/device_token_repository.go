@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type DeviceTokenRepository struct {
+	Repository[domain.DeviceTokenEntity]
+	Log *logrus.Logger
+}
+
+func NewDeviceToken(log *logrus.Logger) *DeviceTokenRepository {
+	return &DeviceTokenRepository{
+		Log: log,
+	}
+}
+
+func (d *DeviceTokenRepository) FindByUserID(db *gorm.DB, tokens *[]domain.DeviceTokenEntity, userID int64) error {
+	return db.Where("user_id = ?", userID).Order("id").Find(tokens).Error
+}
+
+func (d *DeviceTokenRepository) FindByToken(db *gorm.DB, token *domain.DeviceTokenEntity, value string) error {
+	return db.Model(&domain.DeviceTokenEntity{}).Where("token = ?", value).First(&token).Error
+}
+
+func (d *DeviceTokenRepository) DeleteByUserID(db *gorm.DB, userID int64) error {
+	return db.Where("user_id = ?", userID).Delete(&domain.DeviceTokenEntity{}).Error
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type FraudDecisionRepository struct {
+	Repository[domain.FraudDecisionEntity]
+	Log *logrus.Logger
+}
+
+func NewFraudDecision(log *logrus.Logger) *FraudDecisionRepository {
+	return &FraudDecisionRepository{
+		Log: log,
+	}
+}
+
+func (f *FraudDecisionRepository) FindPage(db *gorm.DB, decisions *[]domain.FraudDecisionEntity, filter *domain.FraudDecisionFilter, page int, size int) (total int64, err error) {
+	query := db.Model(&domain.FraudDecisionEntity{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.Reviewed != nil {
+		if *filter.Reviewed {
+			query = query.Where("reviewed_at IS NOT NULL")
+		} else {
+			query = query.Where("reviewed_at IS NULL")
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	err = query.Order("created_at DESC, id DESC").Offset((page - 1) * size).Limit(size).Find(decisions).Error
+	return total, err
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type FraudRuleRepository struct {
+	Repository[domain.FraudRuleEntity]
+	Log *logrus.Logger
+}
+
+func NewFraudRule(log *logrus.Logger) *FraudRuleRepository {
+	return &FraudRuleRepository{
+		Log: log,
+	}
+}
+
+func (f *FraudRuleRepository) FindEnabled(db *gorm.DB, rules *[]domain.FraudRuleEntity) error {
+	return db.Where("enabled = ?", true).Order("id").Find(rules).Error
+}
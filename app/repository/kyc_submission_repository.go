@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"riz.it/domped/app/domain"
+)
+
+type KYCSubmissionRepository struct {
+	Repository[domain.KYCSubmissionEntity]
+	Log *logrus.Logger
+}
+
+func NewKYCSubmission(log *logrus.Logger) *KYCSubmissionRepository {
+	return &KYCSubmissionRepository{
+		Log: log,
+	}
+}
+
+func (k *KYCSubmissionRepository) FindByIDForUpdate(db *gorm.DB, submission *domain.KYCSubmissionEntity, id int64) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).First(submission, id).Error
+}
+
+func (k *KYCSubmissionRepository) FindLatestByUserID(db *gorm.DB, submission *domain.KYCSubmissionEntity, userID int64) error {
+	return db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").First(submission).Error
+}
+
+func (k *KYCSubmissionRepository) CountPendingByUserID(db *gorm.DB, userID int64) (count int64, err error) {
+	err = db.Model(&domain.KYCSubmissionEntity{}).Where("user_id = ? AND status = ?", userID, domain.KYCStatusPending).Count(&count).Error
+	return count, err
+}
+
+func (k *KYCSubmissionRepository) CountApprovedByNIK(db *gorm.DB, nik string) (count int64, err error) {
+	err = db.Model(&domain.KYCSubmissionEntity{}).Where("nik = ? AND status = ?", nik, domain.KYCStatusApproved).Count(&count).Error
+	return count, err
+}
+
+func (k *KYCSubmissionRepository) FindPage(db *gorm.DB, submissions *[]domain.KYCSubmissionEntity, status string, page int, size int) (total int64, err error) {
+	query := db.Model(&domain.KYCSubmissionEntity{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	// Oldest first, so the review queue is worked in submission order
+	err = query.Order("created_at ASC, id ASC").Offset((page - 1) * size).Limit(size).Find(submissions).Error
+	return total, err
+}
+
+func (k *KYCSubmissionRepository) FindApprovedByUserID(db *gorm.DB, submission *domain.KYCSubmissionEntity, userID int64) error {
+	return db.Where("user_id = ? AND status = ?", userID, domain.KYCStatusApproved).Order("reviewed_at DESC, id DESC").First(submission).Error
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type NotificationPreferenceRepository struct {
+	Repository[domain.NotificationPreferenceEntity]
+	Log *logrus.Logger
+}
+
+func NewNotificationPreference(log *logrus.Logger) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{
+		Log: log,
+	}
+}
+
+func (n *NotificationPreferenceRepository) FindByUserID(db *gorm.DB, preferences *[]domain.NotificationPreferenceEntity, userID int64) error {
+	return db.Where("user_id = ?", userID).Find(preferences).Error
+}
+
+func (n *NotificationPreferenceRepository) FindByUserIDAndEventType(db *gorm.DB, preference *domain.NotificationPreferenceEntity, userID int64, eventType string) error {
+	return db.Model(&domain.NotificationPreferenceEntity{}).Where("user_id = ? AND event_type = ?", userID, eventType).First(&preference).Error
+}
+
+func (n *NotificationPreferenceRepository) DeleteByUserID(db *gorm.DB, userID int64) error {
+	return db.Where("user_id = ?", userID).Delete(&domain.NotificationPreferenceEntity{}).Error
+}
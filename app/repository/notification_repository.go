@@ -20,3 +20,7 @@ func NewNotification(log *logrus.Logger) *NotificationRepository {
 func (u *NotificationRepository) FindByUserID(db *gorm.DB, notifications *[]domain.NotificationEntity, userID int64) error {
 	return db.Where("user_id = ?", userID).Find(notifications).Error
 }
+
+func (u *NotificationRepository) DeleteByUserID(db *gorm.DB, userID int64) error {
+	return db.Where("user_id = ?", userID).Delete(&domain.NotificationEntity{}).Error
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type RecoveryCodeRepository struct {
+	Repository[domain.RecoveryCodeEntity]
+	Log *logrus.Logger
+}
+
+func NewRecoveryCode(log *logrus.Logger) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{
+		Log: log,
+	}
+}
+
+func (r *RecoveryCodeRepository) DeleteByUserID(db *gorm.DB, userID int64) error {
+	return db.Where("user_id = ?", userID).Delete(&domain.RecoveryCodeEntity{}).Error
+}
+
+// MarkUsed consumes an unused code of the user, reporting false when there
+// is no such code.
+func (r *RecoveryCodeRepository) MarkUsed(db *gorm.DB, userID int64, codeHash string) (bool, error) {
+	result := db.Model(&domain.RecoveryCodeEntity{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
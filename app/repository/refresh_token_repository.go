@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type RefreshTokenRepository struct {
+	Repository[domain.RefreshTokenEntity]
+	Log *logrus.Logger
+}
+
+func NewRefreshToken(log *logrus.Logger) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		Log: log,
+	}
+}
+
+func (r *RefreshTokenRepository) FindByTokenHash(db *gorm.DB, token *domain.RefreshTokenEntity, hash string) error {
+	return db.Where("token_hash = ?", hash).First(token).Error
+}
+
+// MarkUsed consumes the token, reporting false when it had already been used.
+// The check and the update are a single statement so two concurrent refreshes
+// with the same token cannot both succeed.
+func (r *RefreshTokenRepository) MarkUsed(db *gorm.DB, token *domain.RefreshTokenEntity) (bool, error) {
+	now := time.Now()
+	result := db.Model(&domain.RefreshTokenEntity{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	token.UsedAt = &now
+	return true, nil
+}
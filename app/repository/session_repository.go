@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"riz.it/domped/app/domain"
+)
+
+type SessionRepository struct {
+	Repository[domain.SessionEntity]
+	Log *logrus.Logger
+}
+
+func NewSession(log *logrus.Logger) *SessionRepository {
+	return &SessionRepository{
+		Log: log,
+	}
+}
+
+func (s *SessionRepository) FindActiveByUserID(db *gorm.DB, sessions *[]domain.SessionEntity, userID int64) error {
+	return db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).Order("last_used_at DESC").Find(sessions).Error
+}
+
+func (s *SessionRepository) RevokeByUserID(db *gorm.DB, sessions *[]domain.SessionEntity, userID int64, reason string) error {
+	return db.Model(sessions).
+		Clauses(clause.Returning{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]any{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"riz.it/domped/app/domain"
@@ -20,3 +22,13 @@ func NewTopUp(log *logrus.Logger) *TopUpRepository {
 func (u *TopUpRepository) FindByUUID(db *gorm.DB, topup *domain.TopUpEntity, orderID string) error {
 	return db.Model(&domain.TopUpEntity{}).Where("id = ?", orderID).First(&topup).Error
 }
+
+func (u *TopUpRepository) FindByUserID(db *gorm.DB, topups *[]domain.TopUpEntity, userID int64) error {
+	return db.Where("user_id = ?", userID).Order("created_at").Find(topups).Error
+}
+
+func (u *TopUpRepository) FindPaidByAmountRangeSince(db *gorm.DB, topups *[]domain.TopUpEntity, minAmount, maxAmount int64, since time.Time) error {
+	return db.Where("status IN ? AND amount >= ? AND amount < ? AND updated_at >= ?", []int8{domain.TopUpStatusSuccess, domain.TopUpStatusHeld}, minAmount, maxAmount, since).
+		Order("user_id, updated_at").
+		Find(topups).Error
+}
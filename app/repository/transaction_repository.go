@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"riz.it/domped/app/domain"
 )
 
@@ -15,3 +18,87 @@ func NewTransaction(log *logrus.Logger) *TransactionRepository {
 		Log: log,
 	}
 }
+
+func (t *TransactionRepository) FindByWalletID(db *gorm.DB, transactions *[]domain.TransactionEntity, walletID int64) error {
+	return db.Where("wallet_id = ?", walletID).
+		Order("transaction_at, id").
+		Find(transactions).Error
+}
+
+func (t *TransactionRepository) FindPageByWalletID(db *gorm.DB, transactions *[]domain.TransactionEntity, walletID int64, page int, size int) (total int64, err error) {
+	find := db.Model(&domain.TransactionEntity{}).Where("wallet_id = ?", walletID)
+	if err := find.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	err = find.Order("transaction_at DESC, id DESC").Offset((page - 1) * size).Limit(size).Find(transactions).Error
+	return total, err
+}
+
+func (t *TransactionRepository) FindByWalletIDBetween(db *gorm.DB, transactions *[]domain.TransactionEntity, walletID int64, currency string, from, to time.Time) error {
+	return db.Where("wallet_id = ? AND currency = ? AND transaction_at >= ? AND transaction_at < ?", walletID, currency, from, to).
+		Order("transaction_at, id").
+		Find(transactions).Error
+}
+
+func (t *TransactionRepository) SumNetByWalletIDSince(db *gorm.DB, walletID int64, currency string, since time.Time) (int64, error) {
+	var net int64
+	err := db.Model(&domain.TransactionEntity{}).
+		Select("COALESCE(SUM(CASE WHEN transaction_type = ? THEN amount ELSE -amount END), 0)", domain.TransactionIn).
+		Where("wallet_id = ? AND currency = ? AND transaction_at >= ?", walletID, currency, since).
+		Scan(&net).Error
+	return net, err
+}
+
+func (t *TransactionRepository) CountOutByWalletIDSince(db *gorm.DB, walletID int64, since time.Time) (count int64, err error) {
+	err = db.Model(&domain.TransactionEntity{}).
+		Where("wallet_id IN (?) AND transaction_type = ? AND dof_number NOT IN ? AND transaction_at >= ?", walletAndPockets(db, walletID), domain.TransactionOut, domain.InternalNumbers, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (t *TransactionRepository) CountOutByWalletIDAndDofNumber(db *gorm.DB, walletID int64, dofNumber string) (count int64, err error) {
+	err = db.Model(&domain.TransactionEntity{}).
+		Where("wallet_id IN (?) AND transaction_type = ? AND dof_number = ?", walletAndPockets(db, walletID), domain.TransactionOut, dofNumber).
+		Count(&count).Error
+	return count, err
+}
+
+func (t *TransactionRepository) SumInBySofNumberSince(db *gorm.DB, walletID int64, sofNumber string, since time.Time) (int64, error) {
+	var sum int64
+	err := db.Model(&domain.TransactionEntity{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("wallet_id IN (?) AND transaction_type = ? AND sof_number = ? AND transaction_at >= ?", walletAndPockets(db, walletID), domain.TransactionIn, sofNumber, since).
+		Scan(&sum).Error
+	return sum, err
+}
+
+func (t *TransactionRepository) FindOutByAmountRangeSince(db *gorm.DB, transactions *[]domain.TransactionEntity, currency string, minAmount, maxAmount int64, since time.Time) error {
+	return db.Where("transaction_type = ? AND dof_number NOT IN ? AND currency = ? AND amount >= ? AND amount < ? AND transaction_at >= ?", domain.TransactionOut, domain.InternalNumbers, currency, minAmount, maxAmount, since).
+		Order("wallet_id, transaction_at, id").
+		Find(transactions).Error
+}
+
+func (t *TransactionRepository) FindOutByMinAmountSince(db *gorm.DB, transactions *[]domain.TransactionEntity, currency string, minAmount int64, since time.Time) error {
+	return db.Where("transaction_type = ? AND dof_number NOT IN ? AND currency = ? AND amount >= ? AND transaction_at >= ?", domain.TransactionOut, domain.InternalNumbers, currency, minAmount, since).
+		Order("transaction_at, id").
+		Find(transactions).Error
+}
+
+func (t *TransactionRepository) FindByMinAmountSince(db *gorm.DB, transactions *[]domain.TransactionEntity, currency string, minAmount int64, since time.Time) error {
+	return db.Where("sof_number NOT IN ? AND dof_number NOT IN ? AND currency = ? AND amount >= ? AND transaction_at >= ?", domain.InternalNumbers, domain.InternalNumbers, currency, minAmount, since).
+		Order("transaction_at, id").
+		Find(transactions).Error
+}
+
+// walletAndPockets selects the IDs of a main wallet and its pockets.
+func walletAndPockets(db *gorm.DB, walletID int64) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(&domain.WalletEntity{}).Select("id").Where("id = ? OR parent_id = ?", walletID, walletID)
+}
+
+func (t *TransactionRepository) CountByWalletIDBetween(db *gorm.DB, walletID int64, from, to time.Time) (count int64, err error) {
+	err = db.Model(&domain.TransactionEntity{}).
+		Where("wallet_id = ? AND transaction_at >= ? AND transaction_at < ?", walletID, from, to).
+		Count(&count).Error
+	return count, err
+}
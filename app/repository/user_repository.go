@@ -3,6 +3,7 @@ package repository
 import (
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"riz.it/domped/app/domain"
 )
 
@@ -17,6 +18,10 @@ func NewUser(log *logrus.Logger) *UserRepository {
 	}
 }
 
+func (u *UserRepository) FindByIDForUpdate(db *gorm.DB, user *domain.UserEntity, id int64) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).First(user, id).Error
+}
+
 func (u *UserRepository) FindByEmail(db *gorm.DB, user *domain.UserEntity, email string) error {
 	return db.Model(&domain.UserEntity{}).Where("email = ?", email).First(&user).Error
 }
@@ -25,3 +30,27 @@ func (u *UserRepository) CountByEmail(db *gorm.DB, email string) (count int64, e
 	err = db.Model(&domain.UserEntity{}).Where("email = ?", email).Count(&count).Error
 	return count, err
 }
+
+func (u *UserRepository) FindByVerifiedPhone(db *gorm.DB, user *domain.UserEntity, phone string) error {
+	return db.Model(&domain.UserEntity{}).Where("phone = ? AND phone_verified_at IS NOT NULL", phone).First(&user).Error
+}
+
+func (u *UserRepository) CountByVerifiedPhone(db *gorm.DB, phone string) (count int64, err error) {
+	err = db.Model(&domain.UserEntity{}).Where("phone = ? AND phone_verified_at IS NOT NULL", phone).Count(&count).Error
+	return count, err
+}
+
+func (u *UserRepository) Search(db *gorm.DB, users *[]domain.UserEntity, query string, page int, size int) (total int64, err error) {
+	find := db.Model(&domain.UserEntity{})
+	if query != "" {
+		pattern := "%" + query + "%"
+		find = find.Where("full_name ILIKE ? OR email ILIKE ? OR phone ILIKE ?", pattern, pattern, pattern)
+	}
+
+	if err := find.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	err = find.Order("id").Offset((page - 1) * size).Limit(size).Find(users).Error
+	return total, err
+}
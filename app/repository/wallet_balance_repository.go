@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type WalletBalanceRepository struct {
+	Repository[domain.WalletBalanceEntity]
+	Log *logrus.Logger
+}
+
+func NewWalletBalance(log *logrus.Logger) *WalletBalanceRepository {
+	return &WalletBalanceRepository{
+		Log: log,
+	}
+}
+
+func (w *WalletBalanceRepository) FindByWalletID(db *gorm.DB, balances *[]domain.WalletBalanceEntity, walletID int64) error {
+	return db.Where("wallet_id = ?", walletID).Order("id").Find(balances).Error
+}
+
+func (w *WalletBalanceRepository) FindByWalletIDAndCurrency(db *gorm.DB, balance *domain.WalletBalanceEntity, walletID int64, currency string) error {
+	return db.Where("wallet_id = ? AND currency = ?", walletID, currency).First(balance).Error
+}
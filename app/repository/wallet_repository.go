@@ -18,7 +18,19 @@ func NewWallet(log *logrus.Logger) *WalletRepository {
 }
 
 func (u *WalletRepository) FindByUserID(db *gorm.DB, wallet *domain.WalletEntity, userID int64) error {
-	return db.Model(&domain.WalletEntity{}).Where("user_id = ?", userID).First(&wallet).Error
+	return db.Model(&domain.WalletEntity{}).Where("user_id = ? AND parent_id IS NULL", userID).First(&wallet).Error
+}
+
+func (u *WalletRepository) FindMainAfterID(db *gorm.DB, wallets *[]domain.WalletEntity, afterID int64, limit int) error {
+	return db.Where("id > ? AND parent_id IS NULL", afterID).Order("id").Limit(limit).Find(wallets).Error
+}
+
+func (u *WalletRepository) FindPocketsByParentID(db *gorm.DB, wallets *[]domain.WalletEntity, parentID int64) error {
+	return db.Where("parent_id = ? AND status <> ?", parentID, domain.WalletStatusClosed).Order("id").Find(wallets).Error
+}
+
+func (u *WalletRepository) FindPocketByID(db *gorm.DB, wallet *domain.WalletEntity, parentID int64, id int64) error {
+	return db.Model(&domain.WalletEntity{}).Where("id = ? AND parent_id = ? AND status <> ?", id, parentID, domain.WalletStatusClosed).First(&wallet).Error
 }
 
 func (u *WalletRepository) FindByWalletNumber(db *gorm.DB, wallet *domain.WalletEntity, walletNumber string) error {
@@ -29,3 +41,7 @@ func (u *WalletRepository) CountByWalletNumber(db *gorm.DB, walletNumber string)
 	err = db.Model(&domain.WalletEntity{}).Where("wallet_number = ?", walletNumber).Count(&count).Error
 	return count, err
 }
+
+func (u *WalletRepository) FindByWalletNumbers(db *gorm.DB, wallets *[]domain.WalletEntity, walletNumbers []string) error {
+	return db.Preload("User").Where("wallet_number IN ?", walletNumbers).Find(wallets).Error
+}
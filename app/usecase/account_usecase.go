@@ -0,0 +1,416 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+type AccountUseCase struct {
+	DB                               *gorm.DB
+	Log                              *logrus.Logger
+	UserRepository                   domain.UserRepository
+	WalletRepository                 domain.WalletRepository
+	WalletBalanceRepository          domain.WalletBalanceRepository
+	TransactionRepository            domain.TransactionRepository
+	TopUpRepository                  domain.TopUpRepository
+	SessionRepository                domain.SessionRepository
+	RecoveryCodeRepository           domain.RecoveryCodeRepository
+	NotificationRepository           domain.NotificationRepository
+	NotificationPreferenceRepository domain.NotificationPreferenceRepository
+	DeviceTokenRepository            domain.DeviceTokenRepository
+	AuditUseCase                     domain.AuditUseCase
+	TokenDenylist                    domain.TokenDenylist
+	Email                            domain.Email
+	Template                         domain.Template
+	Validate                         *validator.Validate
+}
+
+func NewAccountUseCase(db *gorm.DB, log *logrus.Logger, userRepository domain.UserRepository, walletRepository domain.WalletRepository, walletBalanceRepository domain.WalletBalanceRepository, transactionRepository domain.TransactionRepository, topUpRepository domain.TopUpRepository, sessionRepository domain.SessionRepository, recoveryCodeRepository domain.RecoveryCodeRepository, notificationRepository domain.NotificationRepository, notificationPreferenceRepository domain.NotificationPreferenceRepository, deviceTokenRepository domain.DeviceTokenRepository, auditUseCase domain.AuditUseCase, tokenDenylist domain.TokenDenylist, email domain.Email, template domain.Template, validate *validator.Validate) domain.AccountUseCase {
+	return &AccountUseCase{
+		DB:                               db,
+		Log:                              log,
+		UserRepository:                   userRepository,
+		WalletRepository:                 walletRepository,
+		WalletBalanceRepository:          walletBalanceRepository,
+		TransactionRepository:            transactionRepository,
+		TopUpRepository:                  topUpRepository,
+		SessionRepository:                sessionRepository,
+		RecoveryCodeRepository:           recoveryCodeRepository,
+		NotificationRepository:           notificationRepository,
+		NotificationPreferenceRepository: notificationPreferenceRepository,
+		DeviceTokenRepository:            deviceTokenRepository,
+		AuditUseCase:                     auditUseCase,
+		TokenDenylist:                    tokenDenylist,
+		Email:                            email,
+		Template:                         template,
+		Validate:                         validate,
+	}
+}
+
+// Close implements domain.AccountUseCase. Personal data is removed from the
+// account, but the user and wallet rows stay so the transactions that
+// reference them are kept for regulatory retention.
+func (a *AccountUseCase) Close(ctx context.Context, req *dto.CloseAccountRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if !util.VerifyPassword(user.Password, req.Password) {
+		return domain.NewError(fiber.StatusUnauthorized, "Invalid password")
+	}
+
+	// Lock the wallet; transfers share-lock both wallet rows, so they
+	// either finish before the balance check or see the wallet closed
+	wallet := new(domain.WalletEntity)
+	err := a.WalletRepository.FindByUserID(tx.Clauses(clause.Locking{Strength: "UPDATE"}), wallet, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		a.Log.WithError(err).Warn("Failed to query wallet")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	hasWallet := err == nil
+
+	// A frozen wallet stays under review; closing would end the freeze
+	if hasWallet && wallet.Status != domain.WalletStatusActive {
+		return walletStatusError(wallet)
+	}
+
+	// There is no payout to an outside account yet, so every balance, the
+	// pockets' included, has to be moved out before closing
+	pockets := new([]domain.WalletEntity)
+	if hasWallet {
+		if err := a.WalletRepository.FindPocketsByParentID(tx, pockets, wallet.ID); err != nil {
+			a.Log.WithError(err).Warn("Failed to query pockets")
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+
+		walletIDs := []int64{wallet.ID}
+		for _, pocket := range *pockets {
+			walletIDs = append(walletIDs, pocket.ID)
+		}
+		for _, walletID := range walletIDs {
+			balances := new([]domain.WalletBalanceEntity)
+			if err := a.WalletBalanceRepository.FindByWalletID(tx, balances, walletID); err != nil {
+				a.Log.WithError(err).Warn("Failed to query wallet balances")
+				return domain.NewError(fiber.StatusInternalServerError)
+			}
+			for _, balance := range *balances {
+				if balance.Balance != 0 {
+					return domain.NewError(fiber.StatusBadRequest, "Please transfer your remaining balance before closing the account")
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	name, email, language := user.FullName, user.Email, user.Language
+
+	if hasWallet {
+		wallet.Status = domain.WalletStatusClosed
+		wallet.StatusReason = "Account closed by the owner"
+		wallet.StatusChangedBy = &userID
+		wallet.StatusChangedAt = &now
+		wallet.ClosedAt = &now
+		wallet.WalletPin = ""
+		if err := a.WalletRepository.Update(tx, wallet); err != nil {
+			a.Log.WithError(err).Warnf("Failed to close wallet: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+
+		for i := range *pockets {
+			pocket := &(*pockets)[i]
+			pocket.Status = domain.WalletStatusClosed
+			pocket.StatusReason = wallet.StatusReason
+			pocket.StatusChangedBy = &userID
+			pocket.StatusChangedAt = &now
+			pocket.ClosedAt = &now
+			if err := a.WalletRepository.Update(tx, pocket); err != nil {
+				a.Log.WithError(err).Warnf("Failed to close pocket: %+v", err)
+				return domain.NewError(fiber.StatusInternalServerError)
+			}
+		}
+	}
+
+	// Anonymise the account; the placeholder email keeps the column unique
+	// and frees the address for a new registration
+	user.FullName = "Closed account"
+	user.Email = "closed-" + strconv.FormatInt(user.ID, 10) + "@closed.invalid"
+	user.Phone = ""
+	user.PhoneVerifiedAt = nil
+	user.Password = ""
+	user.IsActive = false
+	user.TwoFactorSecret = ""
+	user.TwoFactorEnabledAt = nil
+	user.TwoFactorLastCounter = 0
+	user.ClosedAt = &now
+	if err := a.UserRepository.Update(tx, user); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	sessions := new([]domain.SessionEntity)
+	if err := a.SessionRepository.RevokeByUserID(tx, sessions, userID, domain.SessionRevokedClosed); err != nil {
+		a.Log.WithError(err).Warnf("Failed to revoke sessions: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Drop data that only served the user
+	for _, remove := range []func(*gorm.DB, int64) error{
+		a.RecoveryCodeRepository.DeleteByUserID,
+		a.DeviceTokenRepository.DeleteByUserID,
+		a.NotificationRepository.DeleteByUserID,
+		a.NotificationPreferenceRepository.DeleteByUserID,
+	} {
+		if err := remove(tx, userID); err != nil {
+			a.Log.WithError(err).Warnf("Failed to delete account data: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	if err := a.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   userID,
+		Action:    domain.AuditAccountClosed,
+		Metadata:  map[string]any{"reason": req.Reason},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.TokenDenylist.DenyUserTokensBefore(c, userID, now); err != nil {
+		a.Log.WithError(err).Warn("Failed to denylist access tokens")
+	}
+	for _, session := range *sessions {
+		if session.AccessTokenID == "" || session.AccessExpiresAt == nil {
+			continue
+		}
+		if err := a.TokenDenylist.Deny(c, session.AccessTokenID, *session.AccessExpiresAt); err != nil {
+			a.Log.WithError(err).Warn("Failed to denylist access token")
+		}
+	}
+
+	// The address is no longer on the account, so confirm to it directly
+	go a.sendClosedEmail(name, email, language)
+
+	return nil
+}
+
+// Export implements domain.AccountUseCase. It bundles everything stored
+// about the user as JSON files in a zip archive.
+func (a *AccountUseCase) Export(ctx context.Context, req *dto.ExportAccountRequest, userID int64) (*dto.AccountExportFile, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tx := a.DB.WithContext(c)
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	files := map[string]any{
+		"profile.json": toUserData(user),
+	}
+
+	wallet := new(domain.WalletEntity)
+	err := a.WalletRepository.FindByUserID(tx, wallet, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		a.Log.WithError(err).Warn("Failed to query wallet")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	transactions := []dto.TransactionData{}
+	if err == nil {
+		balances := new([]domain.WalletBalanceEntity)
+		if err := a.WalletBalanceRepository.FindByWalletID(tx, balances, wallet.ID); err != nil {
+			a.Log.WithError(err).Warn("Failed to query wallet balances")
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		files["wallet.json"] = toWalletData(wallet, *balances)
+
+		entities := new([]domain.TransactionEntity)
+		if err := a.TransactionRepository.FindByWalletID(tx, entities, wallet.ID); err != nil {
+			a.Log.WithError(err).Warn("Failed to query transactions")
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		for i := range *entities {
+			transactions = append(transactions, toTransactionData(&(*entities)[i]))
+		}
+
+		// Pockets keep their own balances and transactions
+		pockets := new([]domain.WalletEntity)
+		if err := a.WalletRepository.FindPocketsByParentID(tx, pockets, wallet.ID); err != nil {
+			a.Log.WithError(err).Warn("Failed to query pockets")
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		pocketData := make([]dto.PocketData, 0, len(*pockets))
+		for i := range *pockets {
+			pocket := &(*pockets)[i]
+
+			balances := new([]domain.WalletBalanceEntity)
+			if err := a.WalletBalanceRepository.FindByWalletID(tx, balances, pocket.ID); err != nil || len(*balances) == 0 {
+				a.Log.WithError(err).Warn("Failed to query pocket balance")
+				return nil, domain.NewError(fiber.StatusInternalServerError)
+			}
+			pocketData = append(pocketData, toPocketData(pocket, &(*balances)[0]))
+
+			entities := new([]domain.TransactionEntity)
+			if err := a.TransactionRepository.FindByWalletID(tx, entities, pocket.ID); err != nil {
+				a.Log.WithError(err).Warn("Failed to query transactions")
+				return nil, domain.NewError(fiber.StatusInternalServerError)
+			}
+			for i := range *entities {
+				transactions = append(transactions, toTransactionData(&(*entities)[i]))
+			}
+		}
+		files["pockets.json"] = pocketData
+	}
+	files["transactions.json"] = transactions
+
+	topUps := new([]domain.TopUpEntity)
+	if err := a.TopUpRepository.FindByUserID(tx, topUps, userID); err != nil {
+		a.Log.WithError(err).Warn("Failed to query top ups")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	topUpData := make([]dto.TopUpData, 0, len(*topUps))
+	for _, t := range *topUps {
+		topUpData = append(topUpData, dto.TopUpData{
+			ID:        t.ID,
+			UserID:    t.UserID,
+			Amount:    t.Amount,
+			Currency:  t.Currency,
+			Status:    t.Status,
+			CreatedAt: t.CreatedAt.Format(time.RFC3339),
+			UpdatedAt: t.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	files["topups.json"] = topUpData
+
+	notifications := new([]domain.NotificationEntity)
+	if err := a.NotificationRepository.FindByUserID(tx, notifications, userID); err != nil {
+		a.Log.WithError(err).Warn("Failed to query notifications")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	notificationData := make([]dto.NotificationData, 0, len(*notifications))
+	for _, n := range *notifications {
+		notificationData = append(notificationData, dto.NotificationData{
+			ID:        n.ID,
+			UserID:    n.UserID,
+			Status:    n.Status,
+			Title:     n.Title,
+			Body:      n.Body,
+			IsRead:    n.IsRead,
+			CreatedAt: n.CreatedAt.Format(time.RFC3339),
+			UpdatedAt: n.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	files["notifications.json"] = notificationData
+
+	content, err := zipJSON(files)
+	if err != nil {
+		a.Log.WithError(err).Error("Failed to build data export")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   userID,
+		Action:    domain.AuditDataExported,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+	}
+
+	return &dto.AccountExportFile{
+		Filename:    "domped-export-" + time.Now().Format("20060102") + ".zip",
+		ContentType: "application/zip",
+		Content:     content,
+	}, nil
+}
+
+func (a *AccountUseCase) sendClosedEmail(name, address, language string) {
+	c, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	email, err := a.Template.RenderEmail(language, "account_closed", map[string]any{
+		"Name": name,
+	})
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to render account closed email: %+v", err)
+		return
+	}
+
+	if err := a.Email.Send(c, &domain.EmailMessage{
+		To:      []string{address},
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to send account closed email: %+v", err)
+	}
+}
+
+// zipJSON writes each value as an indented JSON file into a zip archive.
+func zipJSON(files map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, name := range []string{"profile.json", "wallet.json", "transactions.json", "topups.json", "notifications.json"} {
+		value, ok := files[name]
+		if !ok {
+			continue
+		}
+
+		w, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,431 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+type AdminUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	UserRepository          domain.UserRepository
+	WalletRepository        domain.WalletRepository
+	WalletBalanceRepository domain.WalletBalanceRepository
+	TransactionRepository   domain.TransactionRepository
+	AuditUseCase            domain.AuditUseCase
+	AuthUseCase             domain.AuthUseCase
+	NotificationUseCase     domain.NotificationUseCase
+	Validate                *validator.Validate
+}
+
+func NewAdminUseCase(db *gorm.DB, log *logrus.Logger, userRepository domain.UserRepository, walletRepository domain.WalletRepository, walletBalanceRepository domain.WalletBalanceRepository, transactionRepository domain.TransactionRepository, auditUseCase domain.AuditUseCase, authUseCase domain.AuthUseCase, notificationUseCase domain.NotificationUseCase, validate *validator.Validate) domain.AdminUseCase {
+	return &AdminUseCase{
+		DB:                      db,
+		Log:                     log,
+		UserRepository:          userRepository,
+		WalletRepository:        walletRepository,
+		WalletBalanceRepository: walletBalanceRepository,
+		TransactionRepository:   transactionRepository,
+		AuditUseCase:            auditUseCase,
+		AuthUseCase:             authUseCase,
+		NotificationUseCase:     notificationUseCase,
+		Validate:                validate,
+	}
+}
+
+// SearchUsers implements domain.AdminUseCase.
+func (a *AdminUseCase) SearchUsers(ctx context.Context, req *dto.AdminUserSearchRequest, actorID int64) ([]dto.UserData, *dto.PageMetadata, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	req.Page, req.Size = util.NormalizePage(req.Page, req.Size)
+	tx := a.DB.WithContext(c)
+
+	if err := a.record(c, tx, &domain.AuditEvent{
+		ActorID:   actorID,
+		Action:    domain.AuditAdminUsersSearched,
+		Metadata:  map[string]any{"query": req.Query, "page": req.Page},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	var users []domain.UserEntity
+	total, err := a.UserRepository.Search(tx, &users, req.Query, req.Page, req.Size)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to search users: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.UserData, 0, len(users))
+	for i := range users {
+		result = append(result, toUserData(&users[i]))
+	}
+
+	return result, util.NewPageMetadata(req.Page, req.Size, total), nil
+}
+
+// GetUser implements domain.AdminUseCase.
+func (a *AdminUseCase) GetUser(ctx context.Context, req *dto.AdminRequest, userID int64, actorID int64) (*dto.ProfileResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := a.DB.WithContext(c)
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if err := a.record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   actorID,
+		Action:    domain.AuditAdminUserViewed,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		return nil, err
+	}
+
+	response := &dto.ProfileResponse{
+		User: toUserData(user),
+	}
+
+	wallet := new(domain.WalletEntity)
+	err := a.WalletRepository.FindByUserID(tx, wallet, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		a.Log.WithError(err).Warn("Failed to query wallet")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if err == nil {
+		wallet, err := a.toWalletData(tx, wallet)
+		if err != nil {
+			return nil, err
+		}
+		response.Wallet = wallet
+	}
+
+	return response, nil
+}
+
+// GetWallet implements domain.AdminUseCase.
+func (a *AdminUseCase) GetWallet(ctx context.Context, req *dto.AdminRequest, userID int64, actorID int64) (*dto.WalletData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := a.DB.WithContext(c)
+
+	wallet, err := a.findWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   actorID,
+		Action:    domain.AuditAdminWalletViewed,
+		Metadata:  map[string]any{"wallet_id": wallet.ID},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		return nil, err
+	}
+
+	return a.toWalletData(tx, wallet)
+}
+
+// toWalletData loads the wallet's balances for the response.
+func (a *AdminUseCase) toWalletData(tx *gorm.DB, wallet *domain.WalletEntity) (*dto.WalletData, error) {
+	balances := new([]domain.WalletBalanceEntity)
+	if err := a.WalletBalanceRepository.FindByWalletID(tx, balances, wallet.ID); err != nil {
+		a.Log.WithError(err).Warn("Failed to query wallet balances")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	return toWalletData(wallet, *balances), nil
+}
+
+// FindTransactions implements domain.AdminUseCase.
+func (a *AdminUseCase) FindTransactions(ctx context.Context, req *dto.AdminTransactionListRequest, userID int64, actorID int64) ([]dto.TransactionData, *dto.PageMetadata, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	req.Page, req.Size = util.NormalizePage(req.Page, req.Size)
+	tx := a.DB.WithContext(c)
+
+	wallet, err := a.findWallet(tx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := a.record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   actorID,
+		Action:    domain.AuditAdminTransactionsViewed,
+		Metadata:  map[string]any{"wallet_id": wallet.ID, "page": req.Page},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	var transactions []domain.TransactionEntity
+	total, err := a.TransactionRepository.FindPageByWalletID(tx, &transactions, wallet.ID, req.Page, req.Size)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to query transactions: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.TransactionData, 0, len(transactions))
+	for i := range transactions {
+		result = append(result, toTransactionData(&transactions[i]))
+	}
+
+	return result, util.NewPageMetadata(req.Page, req.Size, total), nil
+}
+
+// FreezeWallet implements domain.AdminUseCase.
+func (a *AdminUseCase) FreezeWallet(ctx context.Context, req *dto.FreezeWalletRequest, userID int64, actorID int64) error {
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	status := domain.WalletStatusFrozenAll
+	if req.Scope == "debit" {
+		status = domain.WalletStatusFrozenDebit
+	}
+
+	return a.setWalletStatus(ctx, &walletStatusChange{
+		Status:    status,
+		Reason:    req.Reason,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}, userID, actorID)
+}
+
+// UnfreezeWallet implements domain.AdminUseCase.
+func (a *AdminUseCase) UnfreezeWallet(ctx context.Context, req *dto.UnfreezeWalletRequest, userID int64, actorID int64) error {
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	return a.setWalletStatus(ctx, &walletStatusChange{
+		Status:    domain.WalletStatusActive,
+		Reason:    req.Reason,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}, userID, actorID)
+}
+
+type walletStatusChange struct {
+	Status    string
+	Reason    string
+	IPAddress string
+	UserAgent string
+}
+
+// setWalletStatus moves a wallet between active and frozen, recording who
+// did it and why, and tells the owner.
+func (a *AdminUseCase) setWalletStatus(ctx context.Context, change *walletStatusChange, userID int64, actorID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	// Lock the wallet so a transfer in flight finishes before the change
+	wallet, err := a.findWallet(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+	if err != nil {
+		return err
+	}
+
+	// Closing is final and only happens with the account
+	if wallet.Status == domain.WalletStatusClosed {
+		return domain.NewError(fiber.StatusBadRequest, "Wallet is closed")
+	}
+	if wallet.Status == change.Status {
+		return domain.NewError(fiber.StatusConflict, "Wallet is already "+change.Status)
+	}
+
+	now := time.Now()
+	previous := wallet.Status
+	wallet.Status = change.Status
+	wallet.StatusReason = change.Reason
+	wallet.StatusChangedBy = &actorID
+	wallet.StatusChangedAt = &now
+	if err := a.WalletRepository.Update(tx, wallet); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save wallet: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	action := domain.AuditWalletFrozen
+	if change.Status == domain.WalletStatusActive {
+		action = domain.AuditWalletUnfrozen
+	}
+	if err := a.record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: domain.AuditTargetWallet,
+		TargetID:   strconv.FormatInt(wallet.ID, 10),
+		Metadata: map[string]any{
+			"reason": change.Reason,
+		},
+		Before:    map[string]any{"status": previous},
+		After:     map[string]any{"status": change.Status},
+		IPAddress: change.IPAddress,
+		UserAgent: change.UserAgent,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// The reason is for staff; the owner is told what changed
+	if err := a.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityWalletStatus, map[string]any{
+		"Status":       change.Status,
+		"WalletNumber": wallet.WalletNumber,
+	}); err != nil {
+		a.Log.WithError(err).Warn("Failed to create wallet status notification")
+	}
+
+	return nil
+}
+
+// ResendVerification implements domain.AdminUseCase.
+func (a *AdminUseCase) ResendVerification(ctx context.Context, req *dto.AdminRequest, userID int64, actorID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := a.AuthUseCase.SendVerification(c, userID); err != nil {
+		return err
+	}
+
+	// The email has gone out, so a failure here is only logged
+	if err := a.record(c, a.DB.WithContext(c), &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   actorID,
+		Action:    domain.AuditAdminVerificationSent,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		a.Log.Warn("Verification email sent without an audit entry")
+	}
+
+	return nil
+}
+
+// AssignRole implements domain.AdminUseCase.
+func (a *AdminUseCase) AssignRole(ctx context.Context, req *dto.AssignRoleRequest, userID int64, actorID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	// Keep admins from locking themselves out
+	if userID == actorID {
+		return domain.NewError(fiber.StatusForbidden, "You cannot change your own role")
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+	if user.ClosedAt != nil {
+		return domain.NewError(fiber.StatusBadRequest, "Account has been closed")
+	}
+	if user.Role == req.Role {
+		return nil
+	}
+
+	previous := user.Role
+	user.Role = req.Role
+	if err := a.UserRepository.Update(tx, user); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    actorID,
+		Action:     domain.AuditRoleAssigned,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   strconv.FormatInt(userID, 10),
+		Before:     map[string]any{"role": previous},
+		After:      map[string]any{"role": req.Role},
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// findWallet loads the wallet of a user, which only exists once the email
+// has been verified.
+func (a *AdminUseCase) findWallet(db *gorm.DB, userID int64) (*domain.WalletEntity, error) {
+	wallet := new(domain.WalletEntity)
+	if err := a.WalletRepository.FindByUserID(db, wallet, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusNotFound, "Wallet not found")
+		}
+		a.Log.WithError(err).Warn("Failed to query wallet")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	return wallet, nil
+}
+
+// record writes an audit entry. Staff access is refused when it cannot be
+// recorded.
+func (a *AdminUseCase) record(ctx context.Context, db *gorm.DB, event *domain.AuditEvent) error {
+	if err := a.AuditUseCase.Record(ctx, db, event); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	return nil
+}
@@ -0,0 +1,816 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+const (
+	// Amounts from this share of the reporting threshold up to just below
+	// it count towards structuring
+	amlStructuringFloorPercent = 90
+	amlStructuringWindow       = 24 * time.Hour
+
+	// Money has to come back within this window, passing through at most
+	// amlCircularFlowMaxLegs transfers, to count as a circular flow
+	amlCircularFlowWindow  = 72 * time.Hour
+	amlCircularFlowMaxLegs = 4
+
+	amlDormantWindow = 24 * time.Hour
+
+	amlReportDateFormat = "2006-01-02"
+)
+
+type AMLUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	Config                  *config.Config
+	AMLAlertRepository      domain.AMLAlertRepository
+	AMLAlertNoteRepository  domain.AMLAlertNoteRepository
+	TransactionRepository   domain.TransactionRepository
+	TopUpRepository         domain.TopUpRepository
+	WalletRepository        domain.WalletRepository
+	UserRepository          domain.UserRepository
+	KYCSubmissionRepository domain.KYCSubmissionRepository
+	AuditUseCase            domain.AuditUseCase
+	Validate                *validator.Validate
+	Redis                   *redis.Client
+}
+
+func NewAMLUseCase(db *gorm.DB, log *logrus.Logger, config *config.Config, amlAlertRepository domain.AMLAlertRepository, amlAlertNoteRepository domain.AMLAlertNoteRepository, transactionRepository domain.TransactionRepository, topUpRepository domain.TopUpRepository, walletRepository domain.WalletRepository, userRepository domain.UserRepository, kycSubmissionRepository domain.KYCSubmissionRepository, auditUseCase domain.AuditUseCase, validate *validator.Validate, redis *redis.Client) domain.AMLUseCase {
+	return &AMLUseCase{
+		DB:                      db,
+		Log:                     log,
+		Config:                  config,
+		AMLAlertRepository:      amlAlertRepository,
+		AMLAlertNoteRepository:  amlAlertNoteRepository,
+		TransactionRepository:   transactionRepository,
+		TopUpRepository:         topUpRepository,
+		WalletRepository:        walletRepository,
+		UserRepository:          userRepository,
+		KYCSubmissionRepository: kycSubmissionRepository,
+		AuditUseCase:            auditUseCase,
+		Validate:                validate,
+		Redis:                   redis,
+	}
+}
+
+// Scan implements domain.AMLUseCase. Alerts are keyed by a fingerprint of
+// the activity they describe, so overlapping scan windows never raise the
+// same alert twice. The thresholds are amounts of the default currency, so
+// only movements in it are scanned.
+func (a *AMLUseCase) Scan(ctx context.Context) {
+	if !a.Config.AML.ScanEnabled {
+		return
+	}
+
+	now := time.Now()
+	hour := now.Format("2006010215")
+
+	// Claim the hour so the scan runs once
+	claimed, err := a.Redis.SetNX(ctx, "aml:scan:"+hour, now.Unix(), 2*time.Hour).Result()
+	if err != nil {
+		a.Log.WithError(err).Warn("Failed to claim AML scan")
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	db := a.DB.WithContext(ctx)
+
+	scans := []struct {
+		name string
+		find func(db *gorm.DB, now time.Time) ([]domain.AMLAlertEntity, error)
+	}{
+		{domain.AMLStructuring, a.findStructuring},
+		{domain.AMLCircularFlow, a.findCircularFlows},
+		{domain.AMLDormantReactivation, a.findDormantReactivations},
+	}
+
+	raised := 0
+	for _, scan := range scans {
+		if ctx.Err() != nil {
+			return
+		}
+
+		alerts, err := scan.find(db, now)
+		if err != nil {
+			a.Log.WithError(err).WithField("scan", scan.name).Error("Failed to run AML scan")
+			continue
+		}
+
+		for i := range alerts {
+			created, err := a.AMLAlertRepository.CreateIfNew(db, &alerts[i])
+			if err != nil {
+				a.Log.WithError(err).WithField("fingerprint", alerts[i].Fingerprint).Warn("Failed to create AML alert")
+				continue
+			}
+			if created {
+				raised++
+			}
+		}
+	}
+
+	a.Log.WithFields(logrus.Fields{
+		"hour":   hour,
+		"raised": raised,
+	}).Info("AML scan finished")
+}
+
+// findStructuring looks for wallets that moved several amounts just below
+// the reporting threshold within a day, by transfer or by top-up.
+func (a *AMLUseCase) findStructuring(db *gorm.DB, now time.Time) ([]domain.AMLAlertEntity, error) {
+	threshold := int64(util.ParseIntOrDefault(a.Config.AML.ReportThreshold, 100_000_000))
+	minCount := util.ParseIntOrDefault(a.Config.AML.StructuringMinCount, 3)
+	floor := threshold * amlStructuringFloorPercent / 100
+	since := now.Add(-amlStructuringWindow)
+
+	type activity struct {
+		transactions []int64
+		topUps       []string
+		total        int64
+	}
+	byWallet := make(map[int64]*activity)
+	get := func(walletID int64) *activity {
+		if byWallet[walletID] == nil {
+			byWallet[walletID] = new(activity)
+		}
+		return byWallet[walletID]
+	}
+
+	var transactions []domain.TransactionEntity
+	if err := a.TransactionRepository.FindOutByAmountRangeSince(db, &transactions, domain.DefaultCurrency, floor, threshold, since); err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		act := get(transaction.WalletID)
+		act.transactions = append(act.transactions, transaction.ID)
+		act.total += transaction.Amount
+	}
+
+	var topUps []domain.TopUpEntity
+	if err := a.TopUpRepository.FindPaidByAmountRangeSince(db, &topUps, floor, threshold, since); err != nil {
+		return nil, err
+	}
+	wallets := make(map[int64]*domain.WalletEntity)
+	for _, topUp := range topUps {
+		wallet, ok := wallets[topUp.UserID]
+		if !ok {
+			wallet = new(domain.WalletEntity)
+			if err := a.WalletRepository.FindByUserID(db, wallet, topUp.UserID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			wallets[topUp.UserID] = wallet
+		}
+		act := get(wallet.ID)
+		act.topUps = append(act.topUps, topUp.ID)
+		act.total += topUp.Amount
+	}
+
+	var alerts []domain.AMLAlertEntity
+	for walletID, act := range byWallet {
+		count := len(act.transactions) + len(act.topUps)
+		if count < minCount {
+			continue
+		}
+
+		wallet := new(domain.WalletEntity)
+		if err := a.WalletRepository.FindByID(db, wallet, walletID); err != nil {
+			return nil, err
+		}
+
+		alert, err := newAMLAlert(domain.AMLStructuring, fmt.Sprintf("structuring:%d:%s", walletID, now.Format(amlReportDateFormat)), wallet, act.total, map[string]any{
+			"count":        count,
+			"threshold":    threshold,
+			"window_hours": int(amlStructuringWindow.Hours()),
+			"transactions": act.transactions,
+			"topups":       act.topUps,
+		})
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	return alerts, nil
+}
+
+// findCircularFlows looks for money that left a wallet and came back to it
+// through other wallets, each transfer following the previous one.
+func (a *AMLUseCase) findCircularFlows(db *gorm.DB, now time.Time) ([]domain.AMLAlertEntity, error) {
+	minAmount := int64(util.ParseIntOrDefault(a.Config.AML.CircularFlowMinAmount, 1_000_000))
+
+	// Outgoing rows are recorded on the sender, from its number to the
+	// receiver's, in time order
+	var transfers []domain.TransactionEntity
+	if err := a.TransactionRepository.FindOutByMinAmountSince(db, &transfers, domain.DefaultCurrency, minAmount, now.Add(-amlCircularFlowWindow)); err != nil {
+		return nil, err
+	}
+
+	bySender := make(map[string][]*domain.TransactionEntity)
+	for i := range transfers {
+		transfer := &transfers[i]
+		bySender[transfer.SofNumber] = append(bySender[transfer.SofNumber], transfer)
+	}
+
+	// Each cycle is followed from its earliest transfer only, since legs
+	// must happen in order
+	var cycles [][]*domain.TransactionEntity
+	var walk func(path []*domain.TransactionEntity)
+	walk = func(path []*domain.TransactionEntity) {
+		last := path[len(path)-1]
+		if len(path) > 1 && last.DofNumber == path[0].SofNumber {
+			cycles = append(cycles, append([]*domain.TransactionEntity(nil), path...))
+			return
+		}
+		if len(path) == amlCircularFlowMaxLegs {
+			return
+		}
+
+		for _, next := range bySender[last.DofNumber] {
+			if !next.TransactionAt.After(last.TransactionAt) || visits(path, next.DofNumber) {
+				continue
+			}
+			walk(append(path, next))
+		}
+	}
+	for i := range transfers {
+		walk([]*domain.TransactionEntity{&transfers[i]})
+	}
+
+	var alerts []domain.AMLAlertEntity
+	for _, cycle := range cycles {
+		first := cycle[0]
+
+		wallet := new(domain.WalletEntity)
+		if err := a.WalletRepository.FindByID(db, wallet, first.WalletID); err != nil {
+			return nil, err
+		}
+
+		ids := make([]string, 0, len(cycle))
+		transactions := make([]int64, 0, len(cycle))
+		walletNumbers := make([]string, 0, len(cycle))
+		for _, leg := range cycle {
+			ids = append(ids, strconv.FormatInt(leg.ID, 10))
+			transactions = append(transactions, leg.ID)
+			walletNumbers = append(walletNumbers, leg.SofNumber)
+		}
+
+		// Money going round a user's own pockets never left them
+		legWallets := new([]domain.WalletEntity)
+		if err := a.WalletRepository.FindByWalletNumbers(db, legWallets, walletNumbers); err != nil {
+			return nil, err
+		}
+		ownOnly := true
+		for _, w := range *legWallets {
+			if w.UserID != wallet.UserID {
+				ownOnly = false
+			}
+		}
+		if ownOnly {
+			continue
+		}
+
+		alert, err := newAMLAlert(domain.AMLCircularFlow, "circular_flow:"+strings.Join(ids, "-"), wallet, first.Amount, map[string]any{
+			"wallets":      walletNumbers,
+			"transactions": transactions,
+			"window_hours": int(amlCircularFlowWindow.Hours()),
+		})
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	return alerts, nil
+}
+
+// findDormantReactivations looks for wallets that had no movement for the
+// dormant period and then moved a large amount within the last day.
+func (a *AMLUseCase) findDormantReactivations(db *gorm.DB, now time.Time) ([]domain.AMLAlertEntity, error) {
+	dormantDays := util.ParseIntOrDefault(a.Config.AML.DormantDays, 180)
+	minAmount := int64(util.ParseIntOrDefault(a.Config.AML.DormantMinAmount, 10_000_000))
+	since := now.Add(-amlDormantWindow)
+	dormantSince := since.AddDate(0, 0, -dormantDays)
+
+	var transactions []domain.TransactionEntity
+	if err := a.TransactionRepository.FindByMinAmountSince(db, &transactions, domain.DefaultCurrency, minAmount, since); err != nil {
+		return nil, err
+	}
+
+	checked := make(map[int64]bool)
+	var alerts []domain.AMLAlertEntity
+	for _, transaction := range transactions {
+		// Only the first large movement of a wallet is looked at
+		if checked[transaction.WalletID] {
+			continue
+		}
+		checked[transaction.WalletID] = true
+
+		wallet := new(domain.WalletEntity)
+		if err := a.WalletRepository.FindByID(db, wallet, transaction.WalletID); err != nil {
+			return nil, err
+		}
+		// A wallet younger than the dormant period was never dormant
+		if !wallet.CreatedAt.Before(dormantSince) {
+			continue
+		}
+
+		count, err := a.TransactionRepository.CountByWalletIDBetween(db, wallet.ID, dormantSince, since)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			continue
+		}
+
+		alert, err := newAMLAlert(domain.AMLDormantReactivation, fmt.Sprintf("dormant_reactivation:%d:%d", wallet.ID, transaction.ID), wallet, transaction.Amount, map[string]any{
+			"transaction_id":   transaction.ID,
+			"transaction_type": transaction.TransactionType,
+			"counterparty":     counterpartyNumber(&transaction),
+			"dormant_days":     dormantDays,
+		})
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	return alerts, nil
+}
+
+// FindAlerts implements domain.AMLUseCase.
+func (a *AMLUseCase) FindAlerts(ctx context.Context, req *dto.AMLAlertListRequest, actorID int64) ([]dto.AMLAlertData, *dto.PageMetadata, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	req.Page, req.Size = util.NormalizePage(req.Page, req.Size)
+
+	tx := a.DB.WithContext(c)
+
+	if err := a.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:  req.UserID,
+		ActorID: actorID,
+		Action:  domain.AuditAdminAMLSearched,
+		Metadata: map[string]any{
+			"status":      req.Status,
+			"type":        req.Type,
+			"assigned_to": req.AssignedTo,
+			"page":        req.Page,
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	var alerts []domain.AMLAlertEntity
+	total, err := a.AMLAlertRepository.FindPage(tx, &alerts, &domain.AMLAlertFilter{
+		Status:     req.Status,
+		Type:       req.Type,
+		AssignedTo: req.AssignedTo,
+		UserID:     req.UserID,
+	}, req.Page, req.Size)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to query AML alerts: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.AMLAlertData, 0, len(alerts))
+	for i := range alerts {
+		result = append(result, *toAMLAlertData(&alerts[i]))
+	}
+
+	return result, util.NewPageMetadata(req.Page, req.Size, total), nil
+}
+
+// GetAlert implements domain.AMLUseCase.
+func (a *AMLUseCase) GetAlert(ctx context.Context, req *dto.AdminRequest, alertID int64, actorID int64) (*dto.AMLAlertDetailResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := a.DB.WithContext(c)
+
+	alert := new(domain.AMLAlertEntity)
+	if err := a.AMLAlertRepository.FindByID(tx, alert, alertID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "Alert not found")
+	}
+
+	var notes []domain.AMLAlertNoteEntity
+	if err := a.AMLAlertNoteRepository.FindByAlertID(tx, &notes, alert.ID); err != nil {
+		a.Log.WithError(err).Warnf("Failed to query AML alert notes: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     alert.UserID,
+		ActorID:    actorID,
+		Action:     domain.AuditAdminAMLViewed,
+		TargetType: domain.AuditTargetAMLAlert,
+		TargetID:   strconv.FormatInt(alert.ID, 10),
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := &dto.AMLAlertDetailResponse{
+		Alert: *toAMLAlertData(alert),
+		Notes: make([]dto.AMLAlertNoteData, 0, len(notes)),
+	}
+	for _, note := range notes {
+		result.Notes = append(result.Notes, dto.AMLAlertNoteData{
+			ID:        note.ID,
+			AuthorID:  note.AuthorID,
+			Note:      note.Note,
+			CreatedAt: note.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return result, nil
+}
+
+// Assign implements domain.AMLUseCase.
+func (a *AMLUseCase) Assign(ctx context.Context, req *dto.AssignAMLAlertRequest, alertID int64, actorID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	alert, err := a.findOpenAlert(tx, alertID)
+	if err != nil {
+		return err
+	}
+
+	// Alerts only go to staff who can work on them
+	assignee := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, assignee, req.AssigneeID); err != nil {
+		return domain.NewError(fiber.StatusBadRequest, "Assignee not found")
+	}
+	if !domain.HasPermission(assignee.Role, domain.PermissionAMLReview) {
+		return domain.NewError(fiber.StatusBadRequest, "Assignee cannot review AML alerts")
+	}
+
+	before := map[string]any{"status": alert.Status, "assigned_to": alert.AssignedTo}
+	alert.AssignedTo = &assignee.ID
+	alert.Status = domain.AMLStatusInvestigating
+	if err := a.AMLAlertRepository.Update(tx, alert); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save AML alert: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     alert.UserID,
+		ActorID:    actorID,
+		Action:     domain.AuditAMLAlertAssigned,
+		TargetType: domain.AuditTargetAMLAlert,
+		TargetID:   strconv.FormatInt(alert.ID, 10),
+		Before:     before,
+		After:      map[string]any{"status": alert.Status, "assigned_to": alert.AssignedTo},
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// AddNote implements domain.AMLUseCase.
+func (a *AMLUseCase) AddNote(ctx context.Context, req *dto.AMLAlertNoteRequest, alertID int64, actorID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	alert, err := a.findOpenAlert(tx, alertID)
+	if err != nil {
+		return err
+	}
+
+	note := &domain.AMLAlertNoteEntity{
+		AlertID:  alert.ID,
+		AuthorID: actorID,
+		Note:     req.Note,
+	}
+	if err := a.AMLAlertNoteRepository.Create(tx, note); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save AML alert note: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     alert.UserID,
+		ActorID:    actorID,
+		Action:     domain.AuditAMLNoteAdded,
+		TargetType: domain.AuditTargetAMLAlert,
+		TargetID:   strconv.FormatInt(alert.ID, 10),
+		Metadata:   map[string]any{"note_id": note.ID},
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// Close implements domain.AMLUseCase.
+func (a *AMLUseCase) Close(ctx context.Context, req *dto.CloseAMLAlertRequest, alertID int64, actorID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	alert, err := a.findOpenAlert(tx, alertID)
+	if err != nil {
+		return err
+	}
+	// Staff do not close alerts about their own wallets
+	if alert.UserID == actorID {
+		return domain.NewError(fiber.StatusForbidden, "You cannot close alerts about your own account")
+	}
+
+	// The resolution is explained by a note kept with the alert
+	note := &domain.AMLAlertNoteEntity{
+		AlertID:  alert.ID,
+		AuthorID: actorID,
+		Note:     req.Note,
+	}
+	if err := a.AMLAlertNoteRepository.Create(tx, note); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save AML alert note: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	before := map[string]any{"status": alert.Status}
+	now := time.Now()
+	alert.Status = domain.AMLStatusClosed
+	alert.Resolution = &req.Resolution
+	alert.ClosedBy = &actorID
+	alert.ClosedAt = &now
+	if err := a.AMLAlertRepository.Update(tx, alert); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save AML alert: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     alert.UserID,
+		ActorID:    actorID,
+		Action:     domain.AuditAMLAlertClosed,
+		TargetType: domain.AuditTargetAMLAlert,
+		TargetID:   strconv.FormatInt(alert.ID, 10),
+		Metadata:   map[string]any{"note_id": note.ID},
+		Before:     before,
+		After:      map[string]any{"status": alert.Status, "resolution": req.Resolution},
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// ExportReports implements domain.AMLUseCase.
+func (a *AMLUseCase) ExportReports(ctx context.Context, req *dto.AMLReportRequest, actorID int64) (*dto.AMLReportFile, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	from, err := time.ParseInLocation(amlReportDateFormat, req.From, time.Local)
+	if err != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid from date")
+	}
+	to, err := time.ParseInLocation(amlReportDateFormat, req.To, time.Local)
+	if err != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid to date")
+	}
+	if to.Before(from) {
+		return nil, domain.NewError(fiber.StatusBadRequest, "The to date must not be before the from date")
+	}
+
+	tx := a.DB.WithContext(c)
+
+	var alerts []domain.AMLAlertEntity
+	if err := a.AMLAlertRepository.FindClosedBetween(tx, &alerts, domain.AMLResolutionReported, from, to.AddDate(0, 0, 1)); err != nil {
+		a.Log.WithError(err).Warnf("Failed to query AML alerts: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	reports := make([]domain.SuspiciousActivityReport, 0, len(alerts))
+	for i := range alerts {
+		report, err := a.buildReport(tx, &alerts[i])
+		if err != nil {
+			a.Log.WithError(err).Warnf("Failed to build suspicious activity report: %+v", err)
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		reports = append(reports, *report)
+	}
+
+	content, err := util.RenderSuspiciousActivityCSV(reports)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to render suspicious activity report: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		ActorID: actorID,
+		Action:  domain.AuditAdminAMLReportExported,
+		Metadata: map[string]any{
+			"from":   req.From,
+			"to":     req.To,
+			"alerts": len(reports),
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return &dto.AMLReportFile{
+		Filename:    fmt.Sprintf("suspicious-activity-%s-%s.csv", req.From, req.To),
+		ContentType: "text/csv",
+		Content:     content,
+	}, nil
+}
+
+// findOpenAlert loads an alert that is still being worked on, locked for
+// the rest of the transaction.
+func (a *AMLUseCase) findOpenAlert(tx *gorm.DB, alertID int64) (*domain.AMLAlertEntity, error) {
+	alert := new(domain.AMLAlertEntity)
+	if err := a.AMLAlertRepository.FindByID(tx.Clauses(clause.Locking{Strength: "UPDATE"}), alert, alertID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "Alert not found")
+	}
+	if alert.Status == domain.AMLStatusClosed {
+		return nil, domain.NewError(fiber.StatusConflict, "Alert has already been closed")
+	}
+	return alert, nil
+}
+
+// buildReport gathers who a reported alert is about. The NIK comes from the
+// approved identity verification, when there is one.
+func (a *AMLUseCase) buildReport(tx *gorm.DB, alert *domain.AMLAlertEntity) (*domain.SuspiciousActivityReport, error) {
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, alert.UserID); err != nil {
+		return nil, err
+	}
+
+	wallet := new(domain.WalletEntity)
+	if err := a.WalletRepository.FindByID(tx, wallet, alert.WalletID); err != nil {
+		return nil, err
+	}
+
+	submission := new(domain.KYCSubmissionEntity)
+	if err := a.KYCSubmissionRepository.FindApprovedByUserID(tx, submission, user.ID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var notes []domain.AMLAlertNoteEntity
+	if err := a.AMLAlertNoteRepository.FindByAlertID(tx, &notes, alert.ID); err != nil {
+		return nil, err
+	}
+
+	report := &domain.SuspiciousActivityReport{
+		Alert:        *alert,
+		FullName:     user.FullName,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		NIK:          submission.NIK,
+		WalletNumber: wallet.WalletNumber,
+	}
+	for _, note := range notes {
+		report.Notes = append(report.Notes, note.Note)
+	}
+
+	return report, nil
+}
+
+func newAMLAlert(alertType string, fingerprint string, wallet *domain.WalletEntity, amount int64, details map[string]any) (*domain.AMLAlertEntity, error) {
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AMLAlertEntity{
+		Type:        alertType,
+		Fingerprint: fingerprint,
+		UserID:      wallet.UserID,
+		WalletID:    wallet.ID,
+		Amount:      amount,
+		Details:     string(encoded),
+		Status:      domain.AMLStatusOpen,
+	}, nil
+}
+
+// visits reports whether the path already passed through the wallet, other
+// than the one it started from.
+func visits(path []*domain.TransactionEntity, walletNumber string) bool {
+	for _, leg := range path[1:] {
+		if leg.SofNumber == walletNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// counterpartyNumber returns the other wallet of a transaction.
+func counterpartyNumber(transaction *domain.TransactionEntity) string {
+	if transaction.TransactionType == domain.TransactionOut {
+		return transaction.DofNumber
+	}
+	return transaction.SofNumber
+}
+
+func toAMLAlertData(alert *domain.AMLAlertEntity) *dto.AMLAlertData {
+	data := &dto.AMLAlertData{
+		ID:         alert.ID,
+		Type:       alert.Type,
+		UserID:     alert.UserID,
+		WalletID:   alert.WalletID,
+		Amount:     alert.Amount,
+		Details:    json.RawMessage(alert.Details),
+		Status:     alert.Status,
+		AssignedTo: alert.AssignedTo,
+		Resolution: valueOrEmpty(alert.Resolution),
+		ClosedBy:   alert.ClosedBy,
+		CreatedAt:  alert.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  alert.UpdatedAt.Format(time.RFC3339),
+	}
+	if alert.ClosedAt != nil {
+		data.ClosedAt = alert.ClosedAt.Format(time.RFC3339)
+	}
+	return data
+}
@@ -0,0 +1,369 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+const (
+	// auditChainLockKey is the advisory lock serialising the chain writer,
+	// so every entry links to the one linked before it
+	auditChainLockKey = 7_305_001
+
+	auditChainBatchSize  = 500
+	auditChainTimeout    = 30 * time.Second
+	auditVerifyBatchSize = 1000
+	auditVerifyTimeout   = 2 * time.Minute
+	auditUserAgentLength = 255
+)
+
+type AuditUseCase struct {
+	DB                 *gorm.DB
+	Log                *logrus.Logger
+	AuditLogRepository domain.AuditLogRepository
+	Validate           *validator.Validate
+}
+
+func NewAuditUseCase(db *gorm.DB, log *logrus.Logger, auditLogRepository domain.AuditLogRepository, validate *validator.Validate) domain.AuditUseCase {
+	return &AuditUseCase{
+		DB:                 db,
+		Log:                log,
+		AuditLogRepository: auditLogRepository,
+		Validate:           validate,
+	}
+}
+
+// Record implements domain.AuditUseCase.
+func (a *AuditUseCase) Record(ctx context.Context, db *gorm.DB, event *domain.AuditEvent) error {
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	entry := &domain.AuditLogEntity{
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		RequestID:  domain.RequestIDFromContext(ctx),
+		Metadata:   string(encoded),
+		IPAddress:  event.IPAddress,
+		UserAgent:  truncateRunes(event.UserAgent, auditUserAgentLength),
+		// Stored without a time zone and at microsecond precision, so the
+		// hash still matches once the entry is read back
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if event.UserID != 0 {
+		entry.UserID = &event.UserID
+	}
+	if event.ActorID != 0 {
+		entry.ActorID = &event.ActorID
+	}
+	if entry.Before, err = auditSnapshot(event.Before); err != nil {
+		return err
+	}
+	if entry.After, err = auditSnapshot(event.After); err != nil {
+		return err
+	}
+
+	// The entry is linked into the chain once committed, so recording takes
+	// no lock that would serialise the transactions being audited
+	return a.AuditLogRepository.Create(db.WithContext(ctx), entry)
+}
+
+// Chain implements domain.AuditUseCase.
+func (a *AuditUseCase) Chain(ctx context.Context) {
+	for {
+		linked, err := a.chainBatch(ctx)
+		if err != nil {
+			a.Log.WithError(err).Warnf("Failed to chain audit logs: %+v", err)
+			return
+		}
+		if linked < auditChainBatchSize {
+			return
+		}
+	}
+}
+
+// chainBatch links the oldest unlinked entries after the last linked one,
+// returning how many it linked.
+func (a *AuditUseCase) chainBatch(ctx context.Context) (int, error) {
+	c, cancel := context.WithTimeout(ctx, auditChainTimeout)
+	defer cancel()
+
+	var logs []domain.AuditLogEntity
+	err := a.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// Only the chain writer takes the lock, so a second instance waits
+		// rather than linking entries to the same predecessor
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
+
+		latest := new(domain.AuditLogEntity)
+		if err := a.AuditLogRepository.FindLatest(tx, latest); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		prevHash := valueOrEmpty(latest.Hash)
+		seq := int64(0)
+		if latest.ChainSeq != nil {
+			seq = *latest.ChainSeq
+		}
+
+		if err := a.AuditLogRepository.FindUnchained(tx, &logs, auditChainBatchSize); err != nil {
+			return err
+		}
+
+		for i := range logs {
+			entry := &logs[i]
+
+			hash, err := entry.ComputeHash(prevHash)
+			if err != nil {
+				return fmt.Errorf("audit log %d: %w", entry.ID, err)
+			}
+
+			if prevHash != "" {
+				prev := prevHash
+				entry.PrevHash = &prev
+			}
+			seq++
+			position := seq
+			entry.Hash = &hash
+			entry.ChainSeq = &position
+			if err := a.AuditLogRepository.Link(tx, entry); err != nil {
+				return err
+			}
+
+			prevHash = hash
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(logs), nil
+}
+
+// FindAll implements domain.AuditUseCase.
+func (a *AuditUseCase) FindAll(ctx context.Context, req *dto.AuditLogListRequest, actorID int64) ([]dto.AuditLogData, *dto.PageMetadata, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	req.Page, req.Size = util.NormalizePage(req.Page, req.Size)
+
+	filter := &domain.AuditLogFilter{
+		UserID:     req.UserID,
+		ActorID:    req.ActorID,
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		RequestID:  req.RequestID,
+	}
+	// Dates are in UTC, the zone entries are stored in; To includes the
+	// whole day
+	if req.From != "" {
+		from, _ := time.Parse(time.DateOnly, req.From)
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, _ := time.Parse(time.DateOnly, req.To)
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	tx := a.DB.WithContext(c)
+
+	if err := a.Record(c, tx, &domain.AuditEvent{
+		ActorID: actorID,
+		Action:  domain.AuditAdminAuditSearched,
+		Metadata: map[string]any{
+			"user_id":     req.UserID,
+			"actor_id":    req.ActorID,
+			"action":      req.Action,
+			"target_type": req.TargetType,
+			"target_id":   req.TargetID,
+			"request_id":  req.RequestID,
+			"from":        req.From,
+			"to":          req.To,
+			"page":        req.Page,
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	var logs []domain.AuditLogEntity
+	total, err := a.AuditLogRepository.FindPage(tx, &logs, filter, req.Page, req.Size)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to query audit logs: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.AuditLogData, 0, len(logs))
+	for i := range logs {
+		result = append(result, *toAuditLogData(&logs[i]))
+	}
+
+	return result, util.NewPageMetadata(req.Page, req.Size, total), nil
+}
+
+// Verify implements domain.AuditUseCase.
+func (a *AuditUseCase) Verify(ctx context.Context, req *dto.AdminRequest, actorID int64) (*dto.AuditVerifyResponse, error) {
+	// Walking the whole chain takes longer than a regular request
+	c, cancel := context.WithTimeout(ctx, auditVerifyTimeout)
+	defer cancel()
+
+	result, err := a.verifyChain(c)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to read audit log chain: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.Record(c, a.DB.WithContext(c), &domain.AuditEvent{
+		ActorID: actorID,
+		Action:  domain.AuditAdminAuditVerified,
+		Metadata: map[string]any{
+			"valid":     result.Valid,
+			"checked":   result.Checked,
+			"broken_at": result.BrokenAt,
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return result, nil
+}
+
+// verifyChain walks the audit log in order, recomputing each hash and
+// checking it links to the entry before.
+func (a *AuditUseCase) verifyChain(ctx context.Context) (*dto.AuditVerifyResponse, error) {
+	result := &dto.AuditVerifyResponse{Valid: true}
+	broken := func(id int64, reason string) (*dto.AuditVerifyResponse, error) {
+		result.Valid = false
+		result.BrokenAt = &id
+		result.Reason = reason
+		return result, nil
+	}
+
+	// Entries not linked yet are left out; they are checked once the chain
+	// writer has caught up
+	var lastSeq int64
+	prevHash := ""
+	for {
+		var logs []domain.AuditLogEntity
+		if err := a.AuditLogRepository.FindChainAfter(a.DB.WithContext(ctx), &logs, lastSeq, auditVerifyBatchSize); err != nil {
+			return nil, err
+		}
+
+		for i := range logs {
+			entry := &logs[i]
+			lastSeq = *entry.ChainSeq
+			result.Checked++
+
+			if entry.Hash == nil {
+				return broken(entry.ID, "missing hash")
+			}
+
+			if valueOrEmpty(entry.PrevHash) != prevHash {
+				return broken(entry.ID, "previous hash does not match")
+			}
+
+			hash, err := entry.ComputeHash(prevHash)
+			if err != nil {
+				return broken(entry.ID, fmt.Sprintf("unreadable entry: %s", err))
+			}
+			if hash != *entry.Hash {
+				return broken(entry.ID, "hash does not match contents")
+			}
+			prevHash = hash
+		}
+
+		if len(logs) < auditVerifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// auditSnapshot encodes a before or after snapshot, leaving it out when
+// there is none.
+func auditSnapshot(snapshot any) (*string, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	value := string(encoded)
+	return &value, nil
+}
+
+func toAuditLogData(log *domain.AuditLogEntity) *dto.AuditLogData {
+	data := &dto.AuditLogData{
+		ID:         log.ID,
+		UserID:     log.UserID,
+		ActorID:    log.ActorID,
+		Action:     log.Action,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		RequestID:  log.RequestID,
+		Metadata:   json.RawMessage(log.Metadata),
+		IPAddress:  log.IPAddress,
+		UserAgent:  log.UserAgent,
+		Hash:       valueOrEmpty(log.Hash),
+		CreatedAt:  log.CreatedAt.Format(time.RFC3339),
+	}
+	if log.Before != nil {
+		data.Before = json.RawMessage(*log.Before)
+	}
+	if log.After != nil {
+		data.After = json.RawMessage(*log.After)
+	}
+	return data
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// truncateRunes shortens value to at most length characters, the way the
+// database counts them.
+func truncateRunes(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length])
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+	"riz.it/domped/app/domain"
+)
+
+// auditChain serves linked entries in chain order, a page at a time.
+type auditChain struct {
+	domain.AuditLogRepository
+	entries []domain.AuditLogEntity
+}
+
+func (r *auditChain) FindChainAfter(db *gorm.DB, logs *[]domain.AuditLogEntity, afterSeq int64, limit int) error {
+	for _, entry := range r.entries {
+		if *entry.ChainSeq > afterSeq && len(*logs) < limit {
+			*logs = append(*logs, entry)
+		}
+	}
+	return nil
+}
+
+// newAuditChain links n transfer entries the way the chain writer does.
+func newAuditChain(t *testing.T, n int) *auditChain {
+	t.Helper()
+
+	chain := &auditChain{}
+	createdAt := time.Date(2025, time.February, 1, 8, 0, 0, 0, time.UTC)
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		seq := int64(i)
+		entry := domain.AuditLogEntity{
+			ID:        seq,
+			Action:    domain.AuditTransfer,
+			Metadata:  `{"amount": 50}`,
+			IPAddress: "127.0.0.1",
+			ChainSeq:  &seq,
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Second),
+		}
+		hash, err := entry.ComputeHash(prevHash)
+		if err != nil {
+			t.Fatalf("ComputeHash() error = %v", err)
+		}
+		if prevHash != "" {
+			link := prevHash
+			entry.PrevHash = &link
+		}
+		entry.Hash = &hash
+		chain.entries = append(chain.entries, entry)
+		prevHash = hash
+	}
+	return chain
+}
+
+func verifyAuditChain(t *testing.T, chain *auditChain) (valid bool, checked int64, brokenAt int64, reason string) {
+	t.Helper()
+
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	a := &AuditUseCase{DB: db, AuditLogRepository: chain}
+
+	result, err := a.verifyChain(context.Background())
+	if err != nil {
+		t.Fatalf("verifyChain() error = %v", err)
+	}
+	if result.BrokenAt != nil {
+		brokenAt = *result.BrokenAt
+	}
+	return result.Valid, result.Checked, brokenAt, result.Reason
+}
+
+func TestAuditVerifyChain(t *testing.T) {
+	t.Run("intact chain across pages", func(t *testing.T) {
+		chain := newAuditChain(t, auditVerifyBatchSize+5)
+
+		valid, checked, _, reason := verifyAuditChain(t, chain)
+		if !valid || checked != int64(auditVerifyBatchSize+5) {
+			t.Errorf("verifyChain() = valid %v after %d entries (%s), want valid after %d", valid, checked, reason, auditVerifyBatchSize+5)
+		}
+	})
+
+	t.Run("edited contents", func(t *testing.T) {
+		chain := newAuditChain(t, 5)
+		chain.entries[2].Metadata = `{"amount": 5000}`
+
+		valid, checked, brokenAt, reason := verifyAuditChain(t, chain)
+		if valid || brokenAt != 3 || checked != 3 || reason != "hash does not match contents" {
+			t.Errorf("verifyChain() = valid %v at %d after %d (%s)", valid, brokenAt, checked, reason)
+		}
+	})
+
+	t.Run("removed entry", func(t *testing.T) {
+		chain := newAuditChain(t, 5)
+		chain.entries = append(chain.entries[:1], chain.entries[2:]...)
+
+		valid, _, brokenAt, reason := verifyAuditChain(t, chain)
+		if valid || brokenAt != 3 || reason != "previous hash does not match" {
+			t.Errorf("verifyChain() = valid %v at %d (%s)", valid, brokenAt, reason)
+		}
+	})
+
+	t.Run("rehashed entry", func(t *testing.T) {
+		// Fixing up the edited entry's own hash still breaks the link to
+		// the entry after it
+		chain := newAuditChain(t, 5)
+		edited := &chain.entries[1]
+		edited.Metadata = `{"amount": 5000}`
+		hash, err := edited.ComputeHash(*edited.PrevHash)
+		if err != nil {
+			t.Fatalf("ComputeHash() error = %v", err)
+		}
+		edited.Hash = &hash
+
+		valid, _, brokenAt, reason := verifyAuditChain(t, chain)
+		if valid || brokenAt != 3 || reason != "previous hash does not match" {
+			t.Errorf("verifyChain() = valid %v at %d (%s)", valid, brokenAt, reason)
+		}
+	})
+
+	t.Run("missing hash", func(t *testing.T) {
+		chain := newAuditChain(t, 3)
+		chain.entries[0].Hash = nil
+
+		valid, _, brokenAt, reason := verifyAuditChain(t, chain)
+		if valid || brokenAt != 1 || reason != "missing hash" {
+			t.Errorf("verifyChain() = valid %v at %d (%s)", valid, brokenAt, reason)
+		}
+	})
+
+	t.Run("unreadable entry", func(t *testing.T) {
+		chain := newAuditChain(t, 3)
+		chain.entries[1].Metadata = `{"amount":`
+
+		valid, _, brokenAt, reason := verifyAuditChain(t, chain)
+		if valid || brokenAt != 2 || !strings.HasPrefix(reason, "unreadable entry") {
+			t.Errorf("verifyChain() = valid %v at %d (%s)", valid, brokenAt, reason)
+		}
+	})
+}
@@ -2,7 +2,13 @@ package usecase
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -10,32 +16,66 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"riz.it/domped/app/config"
 	"riz.it/domped/app/domain"
 	"riz.it/domped/app/dto"
 	"riz.it/domped/app/util"
 )
 
+const (
+	passwordResetTTL         = 30 * time.Minute
+	passwordResetMaxAttempts = 5
+
+	twoFactorChallengeTTL         = 5 * time.Minute
+	twoFactorChallengeMaxAttempts = 5
+
+	// Failed logins for one account start to be slowed down after this many
+	// attempts, doubling the wait each time up to loginMaxDelay
+	loginDelayAfter = 3
+	loginMaxDelay   = 30 * time.Second
+)
+
 type AuthUseCase struct {
-	DB               *gorm.DB
-	Log              *logrus.Logger
-	UserRepository   domain.UserRepository
-	JWT              domain.JWT
-	Validate         *validator.Validate
-	Redis            *redis.Client
-	Email            domain.Email
-	WalletRepository domain.WalletRepository
+	DB                     *gorm.DB
+	Config                 *config.Config
+	Log                    *logrus.Logger
+	UserRepository         domain.UserRepository
+	SessionRepository      domain.SessionRepository
+	RefreshTokenRepository domain.RefreshTokenRepository
+	RecoveryCodeRepository domain.RecoveryCodeRepository
+	NotificationUseCase    domain.NotificationUseCase
+	AuditUseCase           domain.AuditUseCase
+	JWT                    domain.JWT
+	TOTP                   domain.TOTP
+	TokenDenylist          domain.TokenDenylist
+	Validate               *validator.Validate
+	Redis                  *redis.Client
+	Email                  domain.Email
+	SMS                    domain.SMS
+	Template               domain.Template
+	WalletRepository       domain.WalletRepository
 }
 
-func NewAuthUseCase(db *gorm.DB, log *logrus.Logger, userRepository domain.UserRepository, walletRepository domain.WalletRepository, jwt domain.JWT, validate *validator.Validate, redis *redis.Client, email domain.Email) domain.AuthUseCase {
+func NewAuthUseCase(db *gorm.DB, config *config.Config, log *logrus.Logger, userRepository domain.UserRepository, walletRepository domain.WalletRepository, sessionRepository domain.SessionRepository, refreshTokenRepository domain.RefreshTokenRepository, recoveryCodeRepository domain.RecoveryCodeRepository, notificationUseCase domain.NotificationUseCase, auditUseCase domain.AuditUseCase, jwt domain.JWT, totp domain.TOTP, tokenDenylist domain.TokenDenylist, validate *validator.Validate, redis *redis.Client, email domain.Email, sms domain.SMS, template domain.Template) domain.AuthUseCase {
 	return &AuthUseCase{
-		DB:               db,
-		Log:              log,
-		UserRepository:   userRepository,
-		WalletRepository: walletRepository,
-		JWT:              jwt,
-		Validate:         validate,
-		Redis:            redis,
-		Email:            email,
+		DB:                     db,
+		Config:                 config,
+		Log:                    log,
+		UserRepository:         userRepository,
+		WalletRepository:       walletRepository,
+		SessionRepository:      sessionRepository,
+		RefreshTokenRepository: refreshTokenRepository,
+		RecoveryCodeRepository: recoveryCodeRepository,
+		NotificationUseCase:    notificationUseCase,
+		AuditUseCase:           auditUseCase,
+		JWT:                    jwt,
+		TOTP:                   totp,
+		TokenDenylist:          tokenDenylist,
+		Validate:               validate,
+		Redis:                  redis,
+		Email:                  email,
+		SMS:                    sms,
+		Template:               template,
 	}
 }
 
@@ -51,21 +91,48 @@ func (a *AuthUseCase) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Lo
 		return nil, domain.NewError(fiber.StatusBadRequest, "The provided data is invalid", validationErrors)
 	}
 
+	// The account is identified by email, or by its verified phone number
+	identifier := req.Email
+	if req.Phone != "" {
+		phone, err := util.NormalizePhone(req.Phone)
+		if err != nil {
+			return nil, domain.NewError(fiber.StatusBadRequest, "Invalid phone number")
+		}
+		identifier = phone
+	}
+	invalid := domain.NewError(fiber.StatusUnauthorized, "Invalid credentials")
+
+	// Refuse attempts while the account or address is locked out
+	account := loginAccount(identifier)
+	if err := a.checkLoginThrottle(c, account, req.IPAddress); err != nil {
+		return nil, err
+	}
+
 	// Start a new database transaction
 	tx := a.DB.WithContext(c).Begin()
 	defer tx.Rollback() // Ensure rollback if an error occurs
 
-	// Find the user by email
+	// Find the user by email or phone
 	user := new(domain.UserEntity)
-	if err := a.UserRepository.FindByEmail(tx, user, req.Email); err != nil {
-		// Return an error if the user is not found
-		return nil, domain.NewError(fiber.StatusUnauthorized, "Invalid email or password")
+	var err error
+	if req.Phone != "" {
+		err = a.UserRepository.FindByVerifiedPhone(tx, user, identifier)
+	} else {
+		err = a.UserRepository.FindByEmail(tx, user, identifier)
+	}
+	if err != nil {
+		// Spend the same bcrypt time as a real check so unknown accounts
+		// cannot be told apart by response time
+		util.VerifyDummyPassword(req.Password)
+		a.recordLoginFailure(c, nil, account, req.IPAddress, req.UserAgent)
+		return nil, invalid
 	}
 
 	// Verify the password is correct
 	if !util.VerifyPassword(user.Password, req.Password) {
 		// Return an error if the password is invalid
-		return nil, domain.NewError(fiber.StatusUnauthorized, "Invalid email or password")
+		a.recordLoginFailure(c, user, account, req.IPAddress, req.UserAgent)
+		return nil, invalid
 	}
 
 	// Ensure the user's account is active
@@ -74,17 +141,32 @@ func (a *AuthUseCase) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Lo
 		return nil, domain.NewError(fiber.StatusUnauthorized, "Account has not been verified yet")
 	}
 
-	// Generate the access and refresh tokens
-	accessToken, refreshToken, err := a.JWT.GenerateToken(user.ID)
-	if err != nil {
-		a.Log.WithError(err).Error("Failed to generate tokens")
-		return nil, domain.NewError(fiber.StatusInternalServerError)
+	// With two-factor authentication on, the password only earns a
+	// challenge that has to be completed with the second factor. The
+	// failure streak carries over until that factor is passed too.
+	if user.TwoFactorEnabledAt != nil {
+		challenge, err := a.createTwoFactorChallenge(c, user.ID, account, req)
+		if err != nil {
+			a.Log.WithError(err).Warnf("Failed to create two-factor challenge: %+v", err)
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+
+		return &dto.LoginResponse{
+			User: dto.CredentialData{
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			TwoFactor: challenge,
+		}, nil
 	}
 
-	// Store the refresh token in the user's record
-	user.HashedRt = refreshToken
-	if err := a.UserRepository.Update(tx, user); err != nil {
-		a.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+	// The password is right, so the account's failure streak ends here
+	a.clearLoginFailures(c, account)
+
+	// Open a new session for this device alongside any existing ones
+	tokens, err := a.createSession(c, tx, user.ID, req.DeviceName, req.IPAddress, req.UserAgent)
+	if err != nil {
+		a.Log.WithError(err).Error("Failed to create session")
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
@@ -100,9 +182,125 @@ func (a *AuthUseCase) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Lo
 			FullName: user.FullName,
 			Email:    user.Email,
 		},
-		Token: dto.TokenData{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
+		Token: &dto.TokenData{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		},
+	}, nil
+}
+
+// VerifyTwoFactor implements domain.AuthUseCase.
+func (a *AuthUseCase) VerifyTwoFactor(ctx context.Context, req *dto.VerifyTwoFactorRequest) (*dto.LoginResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "The provided data is invalid", validationErrors)
+	}
+
+	invalid := domain.NewError(fiber.StatusUnauthorized, "Invalid or expired challenge")
+
+	// Retrieve the challenge created by the password step
+	key := twoFactorChallengeKey(util.HashToken(req.ChallengeToken))
+	challenge, err := a.Redis.HGetAll(c, key).Result()
+	if err != nil || len(challenge) == 0 {
+		return nil, invalid
+	}
+
+	// A lockout reached while the challenge was open applies here too
+	if err := a.checkLoginThrottle(c, challenge["account"], req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	// Count the attempt before checking the code so guesses are limited
+	attempts, err := a.Redis.HIncrBy(c, key, "attempts", 1).Result()
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to count two-factor attempts: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	userID, err := strconv.ParseInt(challenge["user_id"], 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil || user.TwoFactorEnabledAt == nil {
+		return nil, invalid
+	}
+
+	// Accept an authenticator code from a time step after the last one
+	// used, or an unused recovery code
+	verified := false
+	if req.Code != "" {
+		secret, err := a.TOTP.Open(user.TwoFactorSecret)
+		if err != nil {
+			a.Log.WithError(err).Error("Failed to open two-factor secret")
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		counter, ok := a.TOTP.Validate(secret, req.Code)
+		if ok && counter > user.TwoFactorLastCounter {
+			user.TwoFactorLastCounter = counter
+			if err := a.UserRepository.Update(tx, user); err != nil {
+				a.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+				return nil, domain.NewError(fiber.StatusInternalServerError)
+			}
+			verified = true
+		}
+	} else {
+		verified, err = a.RecoveryCodeRepository.MarkUsed(tx, user.ID, util.HashToken(util.NormalizeRecoveryCode(req.RecoveryCode)))
+		if err != nil {
+			a.Log.WithError(err).Warnf("Failed to consume recovery code: %+v", err)
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	if !verified {
+		// Wrong codes count toward the account lockout like wrong
+		// passwords, so fresh challenges do not buy more guesses. Too
+		// many on one challenge discard it; the user has to start over
+		// with the password.
+		a.recordLoginFailure(c, user, challenge["account"], req.IPAddress, req.UserAgent)
+		if attempts >= twoFactorChallengeMaxAttempts {
+			a.Redis.Del(c, key)
+		}
+		return nil, domain.NewError(fiber.StatusUnauthorized, "Invalid authentication code")
+	}
+
+	// Consume the challenge; only one request can delete it
+	deleted, err := a.Redis.Del(c, key).Result()
+	if err != nil || deleted == 0 {
+		return nil, invalid
+	}
+
+	// Both factors passed, so the account's failure streak ends here
+	a.clearLoginFailures(c, challenge["account"])
+
+	// Open a new session for the device that passed the password step
+	tokens, err := a.createSession(c, tx, user.ID, challenge["device_name"], req.IPAddress, req.UserAgent)
+	if err != nil {
+		a.Log.WithError(err).Error("Failed to create session")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return &dto.LoginResponse{
+		User: dto.CredentialData{
+			FullName: user.FullName,
+			Email:    user.Email,
+		},
+		Token: &dto.TokenData{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
 		},
 	}, nil
 }
@@ -122,14 +320,31 @@ func (a *AuthUseCase) Register(ctx context.Context, req *dto.RegisterRequest) (*
 	tx := a.DB.WithContext(c).Begin()
 	defer tx.Rollback()
 
-	// Check if the email is already in use
-	count, err := a.UserRepository.CountByEmail(tx, req.Email)
+	// Check if the email is already in use by a verified account
+	user := new(domain.UserEntity)
+	err := a.UserRepository.FindByEmail(tx, user, req.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		a.Log.WithError(err).Warnf("Failed to find user: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if err == nil && user.IsActive {
+		return nil, domain.NewError(fiber.StatusConflict, "The email address is already in use")
+	}
+
+	// Store the phone number in E.164 so it can be matched on login
+	phone, err := util.NormalizePhone(req.Phone)
+	if err != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid phone number")
+	}
+
+	// Check if the phone number is already verified by another account
+	count, err := a.UserRepository.CountByVerifiedPhone(tx, phone)
 	if err != nil {
-		a.Log.WithError(err).Warnf("Failed to count email: %+v", err)
+		a.Log.WithError(err).Warnf("Failed to count phone: %+v", err)
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 	if count > 0 {
-		return nil, domain.NewError(fiber.StatusConflict, "The email address is already in use")
+		return nil, domain.NewError(fiber.StatusConflict, "The phone number is already in use")
 	}
 
 	// Hash the password for storage
@@ -139,35 +354,38 @@ func (a *AuthUseCase) Register(ctx context.Context, req *dto.RegisterRequest) (*
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
-	// Create a new user entity
-	user := &domain.UserEntity{
-		Password: hashedPassword,
-		FullName: req.FullName,
-		Email:    req.Email,
-	}
-
-	// Save the new user to the database
-	if err := a.UserRepository.Create(tx, user); err != nil {
-		a.Log.WithError(err).Warnf("Failed to create user: %+v", err)
-		return nil, domain.NewError(fiber.StatusInternalServerError)
+	// Fall back to the default language when none is requested
+	language := req.Language
+	if language == "" {
+		language = domain.DefaultLocale
 	}
 
-	// Generate a unique OTP reference ID and code
-	otpReferenceId := util.GenerateUUID() + "-" + strconv.FormatInt(user.ID, 10)
-	otpCode := util.GenerateRandomCode(4)
-	ttl := time.Minute * 5
+	// An unverified account is taken over by the new registration. It only
+	// becomes usable with the code sent to the email, which requires both
+	// the returned reference ID and access to the mailbox.
+	user.Password = hashedPassword
+	user.FullName = req.FullName
+	user.Email = req.Email
+	user.Phone = phone
+	user.PhoneVerifiedAt = nil
+	user.Language = language
 
-	// Store the OTP code in Redis with a TTL (Time to Live)
-	insertOtp := a.Redis.Set(ctx, otpReferenceId, otpCode, ttl)
-	if err := insertOtp.Err(); err != nil {
-		a.Log.WithError(err).Warnf("Failed to store OTP in Redis: %+v", err)
+	// Save the user to the database
+	if user.ID == 0 {
+		user.Role = domain.RoleUser
+		err = a.UserRepository.Create(tx, user)
+	} else {
+		err = a.UserRepository.Update(tx, user)
+	}
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to save user: %+v", err)
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
-	// Send the OTP code to the user's email
-	if err := a.Email.Send(user.Email, "Your OTP Code", "Your OTP code is: <b>"+otpCode+"</b>"); err != nil {
-		a.Log.WithError(err).Warnf("Failed to send OTP email: %+v", err)
-		return nil, domain.NewError(fiber.StatusInternalServerError)
+	// Generate a unique OTP reference ID and code
+	otpReferenceId, otpCode, err := a.issueOTP(c, user.ID, false)
+	if err != nil {
+		return nil, err
 	}
 
 	// Commit the transaction
@@ -176,6 +394,12 @@ func (a *AuthUseCase) Register(ctx context.Context, req *dto.RegisterRequest) (*
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
+	// Send the OTP code to the user's email once the account is stored
+	if err := a.sendOTP(c, user, otpReferenceId, otpCode); err != nil {
+		a.Log.WithError(err).Warnf("Failed to send OTP email: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
 	// Return the OTP reference ID as part of the registration response
 	return &dto.RegisterResponse{
 		ReferenceID: otpReferenceId,
@@ -183,7 +407,7 @@ func (a *AuthUseCase) Register(ctx context.Context, req *dto.RegisterRequest) (*
 }
 
 // Logout implements domain.AuthUseCase.
-func (a *AuthUseCase) Logout(ctx context.Context, userID int64) error {
+func (a *AuthUseCase) Logout(ctx context.Context, userID int64, sessionID int64) error {
 	// Set a timeout for the logout process
 	c, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -192,25 +416,22 @@ func (a *AuthUseCase) Logout(ctx context.Context, userID int64) error {
 	tx := a.DB.WithContext(c).Begin()
 	defer tx.Rollback()
 
-	// Retrieve the user based on userID
-	user := new(domain.UserEntity)
-	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
-		// If user is not found, return a 'Not Found' error
-		return domain.NewError(fiber.StatusNotFound, "User not found")
+	// Retrieve the session the access token was issued for
+	session := new(domain.SessionEntity)
+	if err := a.SessionRepository.FindByID(tx, session, sessionID); err != nil || session.UserID != userID {
+		// If the session is unknown, return an 'Unauthorized' error
+		return domain.NewError(fiber.StatusUnauthorized, "User is not authorized")
 	}
 
-	// Check if the user has a valid refresh token
-	if user.HashedRt == "" {
-		// If no refresh token exists, return an 'Unauthorized' error
+	// Check if the session has already been closed
+	if session.RevokedAt != nil {
+		// If the session is revoked, return an 'Unauthorized' error
 		return domain.NewError(fiber.StatusUnauthorized, "User is not authorized")
 	}
 
-	// Remove the refresh token (effectively logging the user out)
-	user.HashedRt = ""
-
-	// Update the user record in the database to remove the refresh token
-	if err := a.UserRepository.Update(tx, user); err != nil {
-		a.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+	// Revoke the session so its refresh token can no longer be used
+	if err := a.revokeSession(c, tx, session, domain.SessionRevokedLogout); err != nil {
+		a.Log.WithError(err).Warnf("Failed to revoke session: %+v", err)
 		return domain.NewError(fiber.StatusInternalServerError)
 	}
 
@@ -237,7 +458,7 @@ func (a *AuthUseCase) Refresh(ctx context.Context, req *dto.RefreshTokenRequest)
 	}
 
 	// Validate the provided refresh token
-	userID, err := a.JWT.ValidateRefreshToken(req.RefreshToken)
+	claims, err := a.JWT.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		// Return an error if the refresh token is invalid
 		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid refresh token")
@@ -247,30 +468,77 @@ func (a *AuthUseCase) Refresh(ctx context.Context, req *dto.RefreshTokenRequest)
 	tx := a.DB.WithContext(c).Begin()
 	defer tx.Rollback()
 
-	// Retrieve the user based on userID
-	user := new(domain.UserEntity)
-	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
-		// If the user is not found, return a 'Not Found' error
-		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
+	// Look the token up by its digest; only issued tokens are on record
+	token := new(domain.RefreshTokenEntity)
+	if err := a.RefreshTokenRepository.FindByTokenHash(tx, token, util.HashToken(req.RefreshToken)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusUnauthorized, "Invalid refresh token")
+		}
+		a.Log.WithError(err).Warnf("Failed to query refresh token: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Retrieve the session the token belongs to
+	session := new(domain.SessionEntity)
+	if err := a.SessionRepository.FindByID(tx, session, token.SessionID); err != nil {
+		return nil, domain.NewError(fiber.StatusUnauthorized, "Invalid refresh token")
 	}
 
-	// Ensure that the stored refresh token matches the provided one
-	if user.HashedRt != req.RefreshToken {
-		// If refresh token does not match, return an 'Unauthorized' error
+	// Ensure the token claims match the session on record
+	if session.ID != claims.SessionID || session.UserID != claims.UserID {
 		return nil, domain.NewError(fiber.StatusUnauthorized, "Invalid refresh token")
 	}
 
-	// Generate new access and refresh tokens for the user
-	accessToken, refreshToken, err := a.JWT.GenerateToken(user.ID)
+	// Reject tokens of sessions that were logged out, revoked or expired
+	if !session.IsActive(time.Now()) {
+		return nil, domain.NewError(fiber.StatusUnauthorized, "Session has expired or been revoked")
+	}
+
+	// Consume the token; a token that was already used has been replayed,
+	// so the whole session is revoked and the user warned
+	fresh, err := a.RefreshTokenRepository.MarkUsed(tx, token)
 	if err != nil {
-		a.Log.WithError(err).Error("Failed to generate new tokens")
+		a.Log.WithError(err).Warnf("Failed to consume refresh token: %+v", err)
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
+	if !fresh {
+		if err := a.revokeSession(c, tx, session, domain.SessionRevokedReuse); err != nil {
+			a.Log.WithError(err).Warnf("Failed to revoke session: %+v", err)
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		if err := tx.Commit().Error; err != nil {
+			a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
 
-	// Update the user record with the new refresh token
-	user.HashedRt = refreshToken
-	if err := a.UserRepository.Update(tx, user); err != nil {
-		a.Log.WithError(err).Warnf("Failed to update user refresh token: %+v", err)
+		a.Log.WithFields(logrus.Fields{
+			"user_id":    session.UserID,
+			"session_id": session.ID,
+			"ip_address": req.IPAddress,
+		}).Warn("Refresh token reuse detected, session revoked")
+
+		if err := a.NotificationUseCase.Notify(c, session.UserID, domain.NotificationSecurityTokenReuse, map[string]any{
+			"DeviceName": session.DeviceName,
+		}); err != nil {
+			a.Log.WithError(err).Warn("Failed to create token reuse notification")
+		}
+
+		return nil, domain.NewError(fiber.StatusUnauthorized, "Invalid refresh token")
+	}
+
+	// Retrieve the user based on the session
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, session.UserID); err != nil {
+		// If the user is not found, return a 'Not Found' error
+		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	// Rotate: issue the next token pair for the same session
+	session.IPAddress = req.IPAddress
+	session.UserAgent = req.UserAgent
+	tokens, err := a.issueTokens(c, tx, session)
+	if err != nil {
+		a.Log.WithError(err).Error("Failed to generate new tokens")
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
@@ -286,9 +554,9 @@ func (a *AuthUseCase) Refresh(ctx context.Context, req *dto.RefreshTokenRequest)
 			FullName: user.FullName,
 			Email:    user.Email,
 		},
-		Token: dto.TokenData{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
+		Token: &dto.TokenData{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
 		},
 	}, nil
 }
@@ -305,21 +573,28 @@ func (a *AuthUseCase) EmailVerification(ctx context.Context, req *dto.EmailVerif
 		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid request data", validationErrors)
 	}
 
-	// Retrieve OTP from Redis using the provided ReferenceID
-	getOtp := a.Redis.Get(c, req.ReferenceID)
-	if err := getOtp.Err(); err != nil {
-		// Return an error if the ReferenceID is invalid or the OTP retrieval fails
-		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid reference ID")
+	// Retrieve the OTP digest from Redis using the provided ReferenceID
+	key := otpKey(req.ReferenceID)
+	otpHash, err := a.Redis.HGet(c, key, "code_hash").Result()
+	if err != nil {
+		// Return an error if the ReferenceID is invalid or has expired
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid or expired reference ID")
 	}
 
-	otp, err := getOtp.Result()
+	// Count the attempt before comparing so guesses are always limited
+	attempts, err := a.Redis.HIncrBy(c, key, "attempts", 1).Result()
 	if err != nil {
-		a.Log.WithError(err).Error("Failed to get OTP response")
+		a.Log.WithError(err).Warnf("Failed to count OTP attempts: %+v", err)
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
 	// Validate the provided OTP
-	if otp != req.OTP {
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(req.OTP)), []byte(otpHash)) != 1 {
+		// Too many wrong codes invalidate the reference ID altogether
+		if attempts >= int64(a.otpMaxAttempts()) {
+			a.Redis.Del(c, key)
+			return nil, domain.NewError(fiber.StatusBadRequest, "Too many invalid attempts, please request a new code")
+		}
 		// Return an error if the OTP does not match
 		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid OTP code")
 	}
@@ -342,18 +617,10 @@ func (a *AuthUseCase) EmailVerification(ctx context.Context, req *dto.EmailVerif
 		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
 	}
 
-	// Generate new access and refresh tokens for the user
-	accessToken, refreshToken, err := a.JWT.GenerateToken(user.ID)
-	if err != nil {
-		a.Log.WithError(err).Error("Failed to generate tokens")
-		return nil, domain.NewError(fiber.StatusInternalServerError)
-	}
-
 	// Update user data: mark the user as active and set the email verification timestamp
 	user.IsActive = true
 	now := time.Now()
 	user.EmailVerifiedAt = &now
-	user.HashedRt = refreshToken
 
 	// Generate wallet number
 	walletNumber, err := util.GenerateWalletNumber(8)
@@ -373,8 +640,9 @@ func (a *AuthUseCase) EmailVerification(ctx context.Context, req *dto.EmailVerif
 	}
 
 	user.Wallet = domain.WalletEntity{
-		Balance:      0,
 		WalletNumber: walletNumber,
+		Status:       domain.WalletStatusActive,
+		Balances:     []domain.WalletBalanceEntity{{Currency: domain.DefaultCurrency}},
 	}
 
 	// Update the user record in the repository
@@ -383,8 +651,15 @@ func (a *AuthUseCase) EmailVerification(ctx context.Context, req *dto.EmailVerif
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
+	// Open the first session for the verifying device
+	tokens, err := a.createSession(c, tx, user.ID, req.DeviceName, req.IPAddress, req.UserAgent)
+	if err != nil {
+		a.Log.WithError(err).Error("Failed to create session")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
 	// Delete the OTP from Redis as it is no longer needed
-	delOtp := a.Redis.Del(c, req.ReferenceID)
+	delOtp := a.Redis.Del(c, key, otpUserKey(userID))
 	if err := delOtp.Err(); err != nil {
 		a.Log.WithError(err).Warnf("Failed to delete OTP: %+v", err)
 		return nil, domain.NewError(fiber.StatusInternalServerError)
@@ -402,9 +677,1109 @@ func (a *AuthUseCase) EmailVerification(ctx context.Context, req *dto.EmailVerif
 			FullName: user.FullName,
 			Email:    user.Email,
 		},
-		Token: dto.TokenData{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
+		Token: &dto.TokenData{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
 		},
 	}, nil
 }
+
+// ResendOTP implements domain.AuthUseCase. Only the latest reference ID of
+// an unverified account can request a new code, once per cooldown.
+func (a *AuthUseCase) ResendOTP(ctx context.Context, req *dto.ResendOTPRequest) (*dto.RegisterResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid request data", validationErrors)
+	}
+
+	invalid := domain.NewError(fiber.StatusBadRequest, "Invalid reference ID")
+
+	// The reference stays resendable after its code expires
+	userID, err := util.ExtractIDFromReference(req.ReferenceID)
+	if err != nil {
+		return nil, invalid
+	}
+
+	latest, err := a.Redis.Get(c, otpUserKey(userID)).Result()
+	if err != nil || subtle.ConstantTimeCompare([]byte(latest), []byte(req.ReferenceID)) != 1 {
+		return nil, invalid
+	}
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(a.DB.WithContext(c), user, userID); err != nil {
+		return nil, invalid
+	}
+	if user.IsActive {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Account has already been verified")
+	}
+
+	// Issue a new code, invalidating the previous reference ID
+	referenceID, otpCode, err := a.issueOTP(c, user.ID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.sendOTP(c, user, referenceID, otpCode); err != nil {
+		a.Log.WithError(err).Warnf("Failed to send OTP email: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return &dto.RegisterResponse{
+		ReferenceID: referenceID,
+	}, nil
+}
+
+// SendVerification implements domain.AuthUseCase.
+func (a *AuthUseCase) SendVerification(ctx context.Context, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(a.DB.WithContext(c), user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+	if user.IsActive {
+		return domain.NewError(fiber.StatusBadRequest, "Account has already been verified")
+	}
+	if user.ClosedAt != nil {
+		return domain.NewError(fiber.StatusBadRequest, "Account has been closed")
+	}
+
+	// The new code goes under the reference ID the user already holds, so
+	// it keeps working in the app; only the email carries it otherwise
+	referenceID, otpCode, err := a.issueOTP(c, user.ID, true)
+	if err != nil {
+		return err
+	}
+
+	if err := a.sendOTP(c, user, referenceID, otpCode); err != nil {
+		a.Log.WithError(err).Warnf("Failed to send OTP email: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// FindSessions implements domain.AuthUseCase.
+func (a *AuthUseCase) FindSessions(ctx context.Context, userID int64, currentSessionID int64) (*[]dto.SessionData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sessions := new([]domain.SessionEntity)
+	if err := a.SessionRepository.FindActiveByUserID(a.DB.WithContext(c), sessions, userID); err != nil {
+		a.Log.WithError(err).Warn("Failed to query sessions")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.SessionData, 0, len(*sessions))
+	for _, session := range *sessions {
+		result = append(result, dto.SessionData{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			Current:    session.ID == currentSessionID,
+			LastUsedAt: session.LastUsedAt.Format(time.RFC3339),
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return &result, nil
+}
+
+// RevokeSession implements domain.AuthUseCase.
+func (a *AuthUseCase) RevokeSession(ctx context.Context, userID int64, sessionID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	// Only the owner can revoke a session
+	session := new(domain.SessionEntity)
+	if err := a.SessionRepository.FindByID(tx, session, sessionID); err != nil || session.UserID != userID {
+		return domain.NewError(fiber.StatusNotFound, "Session not found")
+	}
+
+	if session.RevokedAt == nil {
+		if err := a.revokeSession(c, tx, session, domain.SessionRevokedByUser); err != nil {
+			a.Log.WithError(err).Warnf("Failed to revoke session: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions implements domain.AuthUseCase.
+func (a *AuthUseCase) RevokeAllSessions(ctx context.Context, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sessions := new([]domain.SessionEntity)
+	if err := a.SessionRepository.RevokeByUserID(a.DB.WithContext(c), sessions, userID, domain.SessionRevokedByUser); err != nil {
+		a.Log.WithError(err).Warnf("Failed to revoke sessions: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Reject every access token issued so far, on any device
+	if err := a.TokenDenylist.DenyUserTokensBefore(c, userID, time.Now()); err != nil {
+		a.Log.WithError(err).Warnf("Failed to denylist access tokens: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	for _, session := range *sessions {
+		if err := a.denyAccessToken(c, &session); err != nil {
+			a.Log.WithError(err).Warnf("Failed to denylist access token: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// ForgotPassword implements domain.AuthUseCase. The response is the same
+// whether or not the email belongs to an account.
+func (a *AuthUseCase) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	// Find the user by email; unknown and unverified accounts are silently skipped
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByEmail(a.DB.WithContext(c), user, req.Email); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		a.Log.WithError(err).Warnf("Failed to find user: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	// The link token and the code are only stored as digests
+	token := util.GenerateRandomHex(64)
+	otp := util.GenerateRandomCode(6)
+	tokenHash := util.HashToken(token)
+	key := passwordResetKey(user.ID)
+
+	// Replace any pending reset so only the latest link and code work
+	previous, err := a.Redis.HGet(c, key, "token_hash").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		a.Log.WithError(err).Warnf("Failed to query password reset: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	pipe := a.Redis.TxPipeline()
+	if previous != "" {
+		pipe.Del(c, passwordResetTokenKey(previous))
+	}
+	pipe.Del(c, key)
+	pipe.HSet(c, key, "token_hash", tokenHash, "otp_hash", util.HashToken(otp))
+	pipe.Expire(c, key, passwordResetTTL)
+	pipe.Set(c, passwordResetTokenKey(tokenHash), user.ID, passwordResetTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		a.Log.WithError(err).Warnf("Failed to store password reset: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Send in the background so the response time does not reveal whether
+	// the account exists
+	go a.sendPasswordReset(user, token, otp)
+
+	return nil
+}
+
+// ResetPassword implements domain.AuthUseCase.
+func (a *AuthUseCase) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	invalid := domain.NewError(fiber.StatusBadRequest, "Invalid or expired reset token")
+
+	// Resolve the user from the link token, or from the email and code
+	var userID int64
+	if req.Token != "" {
+		id, err := a.Redis.Get(c, passwordResetTokenKey(util.HashToken(req.Token))).Int64()
+		if err != nil {
+			return invalid
+		}
+		userID = id
+	} else {
+		user := new(domain.UserEntity)
+		if err := a.UserRepository.FindByEmail(a.DB.WithContext(c), user, req.Email); err != nil {
+			return invalid
+		}
+		userID = user.ID
+
+		key := passwordResetKey(userID)
+		otpHash, err := a.Redis.HGet(c, key, "otp_hash").Result()
+		if err != nil {
+			return invalid
+		}
+
+		// Codes are short, so a pending reset is dropped after too many
+		// wrong guesses
+		attempts, err := a.Redis.HIncrBy(c, key, "attempts", 1).Result()
+		if err != nil {
+			a.Log.WithError(err).Warnf("Failed to count reset attempts: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+		if attempts > passwordResetMaxAttempts || subtle.ConstantTimeCompare([]byte(util.HashToken(req.OTP)), []byte(otpHash)) != 1 {
+			if attempts >= passwordResetMaxAttempts {
+				a.clearPasswordReset(c, userID)
+			}
+			return invalid
+		}
+	}
+
+	// Consume the reset; only one request can delete it
+	if !a.clearPasswordReset(c, userID) {
+		return invalid
+	}
+
+	// Hash the new password for storage
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to hash password: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
+		return invalid
+	}
+
+	user.Password = hashedPassword
+	if err := a.UserRepository.Update(tx, user); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Sign every device out, including whoever knew the old password
+	sessions := new([]domain.SessionEntity)
+	if err := a.SessionRepository.RevokeByUserID(tx, sessions, userID, domain.SessionRevokedReset); err != nil {
+		a.Log.WithError(err).Warnf("Failed to revoke sessions: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.TokenDenylist.DenyUserTokensBefore(c, userID, time.Now()); err != nil {
+		a.Log.WithError(err).Warn("Failed to denylist access tokens")
+	}
+	for _, session := range *sessions {
+		if err := a.denyAccessToken(c, &session); err != nil {
+			a.Log.WithError(err).Warn("Failed to denylist access token")
+		}
+	}
+
+	// A new password also lifts any lockout on the account
+	a.clearLoginFailures(c, loginAccount(user.Email))
+	if user.PhoneVerifiedAt != nil {
+		a.clearLoginFailures(c, loginAccount(user.Phone))
+	}
+
+	if err := a.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityPassword, map[string]any{
+		"Reset": true,
+	}); err != nil {
+		a.Log.WithError(err).Warn("Failed to create password change notification")
+	}
+
+	return nil
+}
+
+// RequestLoginOTP implements domain.AuthUseCase. The response is the same
+// whether or not the number belongs to an account, so it cannot be used to
+// find out which numbers are registered.
+func (a *AuthUseCase) RequestLoginOTP(ctx context.Context, req *dto.PhoneLoginOTPRequest) (*dto.RegisterResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid request data", validationErrors)
+	}
+
+	phone, err := util.NormalizePhone(req.Phone)
+	if err != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid phone number")
+	}
+
+	// Limit how often codes can be sent to one number
+	claimed, err := a.Redis.SetNX(c, loginOTPCooldownKey(phone), 1, a.otpCooldown()).Result()
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to check OTP cooldown: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if !claimed {
+		return nil, domain.NewError(fiber.StatusTooManyRequests, "Please wait before requesting a new code")
+	}
+
+	referenceID := util.GenerateUUID()
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByVerifiedPhone(a.DB.WithContext(c), user, phone); err != nil || !user.IsActive {
+		return &dto.RegisterResponse{
+			ReferenceID: referenceID,
+		}, nil
+	}
+
+	otpCode := util.GenerateRandomCode(int64(a.otpLength()))
+
+	pipe := a.Redis.TxPipeline()
+	pipe.HSet(c, loginOTPKey(referenceID), "user_id", user.ID, "code_hash", util.HashToken(otpCode))
+	pipe.Expire(c, loginOTPKey(referenceID), a.otpTTL())
+	if _, err := pipe.Exec(c); err != nil {
+		a.Log.WithError(err).Warnf("Failed to store OTP in Redis: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Send in the background so the response time does not reveal
+	// whether the number is registered
+	go a.sendSMS(user, "login_otp", otpCode)
+
+	return &dto.RegisterResponse{
+		ReferenceID: referenceID,
+	}, nil
+}
+
+// LoginWithOTP implements domain.AuthUseCase.
+func (a *AuthUseCase) LoginWithOTP(ctx context.Context, req *dto.PhoneLoginRequest) (*dto.LoginResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid request data", validationErrors)
+	}
+
+	invalid := domain.NewError(fiber.StatusBadRequest, "Invalid or expired code")
+
+	key := loginOTPKey(req.ReferenceID)
+	otp, err := a.Redis.HGetAll(c, key).Result()
+	if err != nil || otp["code_hash"] == "" {
+		return nil, invalid
+	}
+
+	// Count the attempt before comparing so guesses are always limited
+	attempts, err := a.Redis.HIncrBy(c, key, "attempts", 1).Result()
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to count OTP attempts: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(req.OTP)), []byte(otp["code_hash"])) != 1 {
+		if attempts >= int64(a.otpMaxAttempts()) {
+			a.Redis.Del(c, key)
+			return nil, domain.NewError(fiber.StatusBadRequest, "Too many invalid attempts, please request a new code")
+		}
+		return nil, invalid
+	}
+
+	// Consume the code; only one request can delete it
+	deleted, err := a.Redis.Del(c, key).Result()
+	if err != nil || deleted == 0 {
+		return nil, invalid
+	}
+
+	userID, err := strconv.ParseInt(otp["user_id"], 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil || !user.IsActive || user.PhoneVerifiedAt == nil {
+		return nil, invalid
+	}
+
+	// The code stands in for the password only; the second factor is
+	// still required when it is enabled
+	if user.TwoFactorEnabledAt != nil {
+		challenge, err := a.createTwoFactorChallenge(c, user.ID, loginAccount(user.Phone), &dto.LoginRequest{DeviceName: req.DeviceName})
+		if err != nil {
+			a.Log.WithError(err).Warnf("Failed to create two-factor challenge: %+v", err)
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+
+		return &dto.LoginResponse{
+			User: dto.CredentialData{
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			TwoFactor: challenge,
+		}, nil
+	}
+
+	tokens, err := a.createSession(c, tx, user.ID, req.DeviceName, req.IPAddress, req.UserAgent)
+	if err != nil {
+		a.Log.WithError(err).Error("Failed to create session")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return &dto.LoginResponse{
+		User: dto.CredentialData{
+			FullName: user.FullName,
+			Email:    user.Email,
+		},
+		Token: &dto.TokenData{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		},
+	}, nil
+}
+
+// SendPhoneVerification implements domain.AuthUseCase.
+func (a *AuthUseCase) SendPhoneVerification(ctx context.Context, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := a.DB.WithContext(c)
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if user.Phone == "" {
+		return domain.NewError(fiber.StatusBadRequest, "No phone number on the account")
+	}
+	if user.PhoneVerifiedAt != nil {
+		return domain.NewError(fiber.StatusBadRequest, "Phone number has already been verified")
+	}
+
+	count, err := a.UserRepository.CountByVerifiedPhone(tx, user.Phone)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to count phone: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	if count > 0 {
+		return domain.NewError(fiber.StatusConflict, "The phone number is already in use")
+	}
+
+	claimed, err := a.Redis.SetNX(c, phoneOTPCooldownKey(userID), 1, a.otpCooldown()).Result()
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to check OTP cooldown: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	if !claimed {
+		return domain.NewError(fiber.StatusTooManyRequests, "Please wait before requesting a new code")
+	}
+
+	otpCode := util.GenerateRandomCode(int64(a.otpLength()))
+
+	// The code is bound to the number it was sent to, so changing the
+	// number in between invalidates it
+	key := phoneOTPKey(userID)
+	pipe := a.Redis.TxPipeline()
+	pipe.Del(c, key)
+	pipe.HSet(c, key, "phone", user.Phone, "code_hash", util.HashToken(otpCode))
+	pipe.Expire(c, key, a.otpTTL())
+	if _, err := pipe.Exec(c); err != nil {
+		a.Log.WithError(err).Warnf("Failed to store OTP in Redis: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := a.sendSMSWithContext(c, user, "phone_verification", otpCode); err != nil {
+		a.Log.WithError(err).Warnf("Failed to send verification SMS: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// VerifyPhone implements domain.AuthUseCase.
+func (a *AuthUseCase) VerifyPhone(ctx context.Context, req *dto.VerifyPhoneRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(a.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid request data", validationErrors)
+	}
+
+	invalid := domain.NewError(fiber.StatusBadRequest, "Invalid or expired code")
+
+	key := phoneOTPKey(userID)
+	otp, err := a.Redis.HGetAll(c, key).Result()
+	if err != nil || otp["code_hash"] == "" {
+		return invalid
+	}
+
+	attempts, err := a.Redis.HIncrBy(c, key, "attempts", 1).Result()
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to count OTP attempts: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(req.OTP)), []byte(otp["code_hash"])) != 1 {
+		if attempts >= int64(a.otpMaxAttempts()) {
+			a.Redis.Del(c, key)
+			return domain.NewError(fiber.StatusBadRequest, "Too many invalid attempts, please request a new code")
+		}
+		return invalid
+	}
+
+	tx := a.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := a.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if user.Phone != otp["phone"] {
+		a.Redis.Del(c, key)
+		return invalid
+	}
+
+	count, err := a.UserRepository.CountByVerifiedPhone(tx, user.Phone)
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to count phone: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	if count > 0 && user.PhoneVerifiedAt == nil {
+		return domain.NewError(fiber.StatusConflict, "The phone number is already in use")
+	}
+
+	now := time.Now()
+	user.PhoneVerifiedAt = &now
+	if err := a.UserRepository.Update(tx, user); err != nil {
+		a.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		a.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	a.Redis.Del(c, key)
+
+	return nil
+}
+
+// issueOTP stores a new verification code for the user under a new
+// reference ID, replacing any previous one, or under the user's current
+// reference ID when keepReference is set. It fails while the resend
+// cooldown of the previous code is running.
+func (a *AuthUseCase) issueOTP(ctx context.Context, userID int64, keepReference bool) (string, string, error) {
+	claimed, err := a.Redis.SetNX(ctx, otpCooldownKey(userID), 1, a.otpCooldown()).Result()
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to check OTP cooldown: %+v", err)
+		return "", "", domain.NewError(fiber.StatusInternalServerError)
+	}
+	if !claimed {
+		return "", "", domain.NewError(fiber.StatusTooManyRequests, "Please wait before requesting a new code")
+	}
+
+	referenceID := util.GenerateUUID() + "-" + strconv.FormatInt(userID, 10)
+	otpCode := util.GenerateRandomCode(int64(a.otpLength()))
+
+	previous, err := a.Redis.Get(ctx, otpUserKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		a.Log.WithError(err).Warnf("Failed to query OTP: %+v", err)
+		return "", "", domain.NewError(fiber.StatusInternalServerError)
+	}
+	if keepReference && previous != "" {
+		referenceID = previous
+	}
+
+	// Store the OTP digest in Redis with a TTL (Time to Live); the latest
+	// reference ID is kept for a day so it can be resent after expiring
+	pipe := a.Redis.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, otpKey(previous))
+	}
+	pipe.HSet(ctx, otpKey(referenceID), "code_hash", util.HashToken(otpCode))
+	pipe.Expire(ctx, otpKey(referenceID), a.otpTTL())
+	pipe.Set(ctx, otpUserKey(userID), referenceID, 24*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		a.Log.WithError(err).Warnf("Failed to store OTP in Redis: %+v", err)
+		return "", "", domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return referenceID, otpCode, nil
+}
+
+// sendOTP emails the verification code in the user's language, along with
+// a link carrying its reference ID when one is configured.
+func (a *AuthUseCase) sendOTP(ctx context.Context, user *domain.UserEntity, referenceID string, otpCode string) error {
+	link := ""
+	if a.Config.Auth.VerificationURL != "" {
+		link = a.Config.Auth.VerificationURL + "?reference_id=" + url.QueryEscape(referenceID)
+	}
+
+	email, err := a.Template.RenderEmail(user.Language, "email_verification", map[string]any{
+		"Name":             user.FullName,
+		"Link":             link,
+		"OTP":              otpCode,
+		"ExpiresInMinutes": int(a.otpTTL().Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.Email.Send(ctx, &domain.EmailMessage{
+		To:      []string{user.Email},
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	})
+}
+
+// createTwoFactorChallenge stores a short-lived challenge for a user who
+// passed the password step. Only the digest of the token is stored, along
+// with the hashed account that wrong codes are counted against.
+func (a *AuthUseCase) createTwoFactorChallenge(ctx context.Context, userID int64, account string, req *dto.LoginRequest) (*dto.TwoFactorChallengeData, error) {
+	token := util.GenerateRandomHex(64)
+	key := twoFactorChallengeKey(util.HashToken(token))
+
+	pipe := a.Redis.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", userID, "account", account, "device_name", req.DeviceName)
+	pipe.Expire(ctx, key, twoFactorChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorChallengeData{
+		ChallengeToken: token,
+		ExpiresIn:      int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// createSession opens a new session for the user and issues its first
+// token pair.
+func (a *AuthUseCase) createSession(ctx context.Context, tx *gorm.DB, userID int64, deviceName, ipAddress, userAgent string) (*domain.TokenPair, error) {
+	now := time.Now()
+	session := &domain.SessionEntity{
+		UserID:     userID,
+		DeviceName: deviceName,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		LastUsedAt: now,
+		ExpiresAt:  now,
+	}
+	if err := a.SessionRepository.Create(tx, session); err != nil {
+		return nil, err
+	}
+
+	if err := a.AuditUseCase.Record(ctx, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    userID,
+		Action:     domain.AuditLogin,
+		TargetType: domain.AuditTargetSession,
+		TargetID:   strconv.FormatInt(session.ID, 10),
+		Metadata: map[string]any{
+			"device_name": deviceName,
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}); err != nil {
+		return nil, err
+	}
+
+	return a.issueTokens(ctx, tx, session)
+}
+
+// issueTokens signs a token pair for the session, records the digest of the
+// refresh token and extends the session to the refresh token's expiry. The
+// access token it replaces is denylisted so each session has at most one
+// usable access token.
+func (a *AuthUseCase) issueTokens(ctx context.Context, tx *gorm.DB, session *domain.SessionEntity) (*domain.TokenPair, error) {
+	tokens, err := a.JWT.GenerateToken(session.UserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.denyAccessToken(ctx, session); err != nil {
+		return nil, err
+	}
+
+	refreshToken := &domain.RefreshTokenEntity{
+		SessionID: session.ID,
+		TokenHash: util.HashToken(tokens.RefreshToken),
+		ExpiresAt: tokens.RefreshClaims.ExpiresAt,
+	}
+	if err := a.RefreshTokenRepository.Create(tx, refreshToken); err != nil {
+		return nil, err
+	}
+
+	session.LastUsedAt = time.Now()
+	session.ExpiresAt = tokens.RefreshClaims.ExpiresAt
+	session.AccessTokenID = tokens.AccessClaims.ID
+	session.AccessExpiresAt = &tokens.AccessClaims.ExpiresAt
+	if err := a.SessionRepository.Update(tx, session); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// revokeSession closes the session and denylists its outstanding access token.
+func (a *AuthUseCase) revokeSession(ctx context.Context, tx *gorm.DB, session *domain.SessionEntity, reason string) error {
+	if err := a.denyAccessToken(ctx, session); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	session.RevokedReason = reason
+	return a.SessionRepository.Update(tx, session)
+}
+
+func (a *AuthUseCase) denyAccessToken(ctx context.Context, session *domain.SessionEntity) error {
+	if session.AccessTokenID == "" || session.AccessExpiresAt == nil {
+		return nil
+	}
+	return a.TokenDenylist.Deny(ctx, session.AccessTokenID, *session.AccessExpiresAt)
+}
+
+// sendSMS sends an OTP text message in the background.
+func (a *AuthUseCase) sendSMS(user *domain.UserEntity, name string, otpCode string) {
+	c, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := a.sendSMSWithContext(c, user, name, otpCode); err != nil {
+		a.Log.WithError(err).Warnf("Failed to send SMS: %+v", err)
+	}
+}
+
+func (a *AuthUseCase) sendSMSWithContext(ctx context.Context, user *domain.UserEntity, name string, otpCode string) error {
+	body, err := a.Template.RenderSMS(user.Language, name, map[string]any{
+		"OTP":              otpCode,
+		"ExpiresInMinutes": int(a.otpTTL().Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.SMS.Send(ctx, &domain.SMSMessage{
+		To:   user.Phone,
+		Body: body,
+	})
+}
+
+func (a *AuthUseCase) sendPasswordReset(user *domain.UserEntity, token string, otp string) {
+	c, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	link := ""
+	if a.Config.Auth.PasswordResetURL != "" {
+		link = a.Config.Auth.PasswordResetURL + "?token=" + url.QueryEscape(token)
+	}
+
+	email, err := a.Template.RenderEmail(user.Language, "password_reset", map[string]any{
+		"Name":             user.FullName,
+		"Link":             link,
+		"OTP":              otp,
+		"ExpiresInMinutes": int(passwordResetTTL.Minutes()),
+	})
+	if err != nil {
+		a.Log.WithError(err).Warnf("Failed to render password reset email: %+v", err)
+		return
+	}
+
+	if err := a.Email.Send(c, &domain.EmailMessage{
+		To:      []string{user.Email},
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	}); err != nil {
+		a.Log.WithError(err).Warnf("Failed to send password reset email: %+v", err)
+	}
+}
+
+// clearPasswordReset removes the user's pending reset, reporting whether
+// there was one.
+func (a *AuthUseCase) clearPasswordReset(ctx context.Context, userID int64) bool {
+	key := passwordResetKey(userID)
+
+	tokenHash, _ := a.Redis.HGet(ctx, key, "token_hash").Result()
+	deleted, err := a.Redis.Del(ctx, key).Result()
+	if err != nil || deleted == 0 {
+		return false
+	}
+	if tokenHash != "" {
+		a.Redis.Del(ctx, passwordResetTokenKey(tokenHash))
+	}
+
+	return true
+}
+
+// CheckLockout implements domain.AuthUseCase.
+func (a *AuthUseCase) CheckLockout(ctx context.Context, user *domain.UserEntity, ipAddress string) error {
+	return a.checkLoginThrottle(ctx, loginAccount(user.Email), ipAddress)
+}
+
+// RecordFailure implements domain.AuthUseCase.
+func (a *AuthUseCase) RecordFailure(ctx context.Context, user *domain.UserEntity, ipAddress string, userAgent string) {
+	a.recordLoginFailure(ctx, user, loginAccount(user.Email), ipAddress, userAgent)
+}
+
+// ClearFailures implements domain.AuthUseCase.
+func (a *AuthUseCase) ClearFailures(ctx context.Context, user *domain.UserEntity) {
+	a.clearLoginFailures(ctx, loginAccount(user.Email))
+}
+
+// checkLoginThrottle rejects a login while the account or the client address
+// is locked out, or while the account's progressive delay is running. Redis
+// errors let the attempt through so an outage does not lock everyone out.
+func (a *AuthUseCase) checkLoginThrottle(ctx context.Context, account string, ipAddress string) error {
+	keys := []string{loginLockKey("account", account), loginDelayKey(account)}
+	if ipAddress != "" {
+		keys = append(keys, loginLockKey("ip", ipAddress))
+	}
+
+	var wait time.Duration
+	for _, key := range keys {
+		ttl, err := a.Redis.PTTL(ctx, key).Result()
+		if err != nil {
+			a.Log.WithError(err).Warn("Failed to check login throttle")
+			return nil
+		}
+		if ttl > wait {
+			wait = ttl
+		}
+	}
+
+	if wait <= 0 {
+		return nil
+	}
+
+	return domain.NewError(fiber.StatusTooManyRequests, fmt.Sprintf("Too many failed login attempts, try again in %s", formatWait(wait)))
+}
+
+// recordLoginFailure counts a failed login against the account and the
+// client address. Accounts are keyed by email or phone number whether or not
+// they exist, so lockouts do not reveal which accounts are registered. The owner of a real
+// account is notified when it gets locked.
+func (a *AuthUseCase) recordLoginFailure(ctx context.Context, user *domain.UserEntity, account string, ipAddress string, userAgent string) {
+	window := a.loginWindow()
+	lockout := a.loginLockout()
+
+	// The attempt is logged under the hashed account, so the audit log
+	// holds no identifier for accounts that do not exist
+	event := &domain.AuditEvent{
+		Action: domain.AuditLoginFailed,
+		Metadata: map[string]any{
+			"account": account,
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+	if user != nil {
+		event.UserID = user.ID
+		event.TargetType = domain.AuditTargetUser
+		event.TargetID = strconv.FormatInt(user.ID, 10)
+	}
+	if err := a.AuditUseCase.Record(ctx, a.DB, event); err != nil {
+		a.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+	}
+
+	failures, err := a.countLoginFailure(ctx, loginFailKey("account", account), window)
+	if err != nil {
+		a.Log.WithError(err).Warn("Failed to count login failure")
+		return
+	}
+
+	if failures >= int64(a.loginMaxAttempts()) {
+		// Lock the account and start the next streak from zero
+		a.Redis.Set(ctx, loginLockKey("account", account), 1, lockout)
+		a.Redis.Del(ctx, loginFailKey("account", account), loginDelayKey(account))
+
+		a.Log.WithField("account", account).Warn("Account locked after failed logins")
+
+		if user != nil {
+			if err := a.NotificationUseCase.Notify(ctx, user.ID, domain.NotificationSecurityLockout, map[string]any{
+				"Minutes":   int(lockout.Minutes()),
+				"IPAddress": ipAddress,
+			}); err != nil {
+				a.Log.WithError(err).Warn("Failed to create lockout notification")
+			}
+		}
+	} else if failures >= loginDelayAfter {
+		delay := time.Second * time.Duration(math.Pow(2, float64(failures-loginDelayAfter)))
+		a.Redis.Set(ctx, loginDelayKey(account), 1, min(delay, loginMaxDelay))
+	}
+
+	if ipAddress == "" {
+		return
+	}
+
+	failures, err = a.countLoginFailure(ctx, loginFailKey("ip", ipAddress), window)
+	if err != nil {
+		a.Log.WithError(err).Warn("Failed to count login failure")
+		return
+	}
+
+	if failures >= int64(a.loginIPMaxAttempts()) {
+		a.Redis.Set(ctx, loginLockKey("ip", ipAddress), 1, lockout)
+		a.Redis.Del(ctx, loginFailKey("ip", ipAddress))
+
+		a.Log.WithField("ip_address", ipAddress).Warn("Address locked after failed logins")
+	}
+}
+
+// countLoginFailure increments a failure counter whose window starts at the
+// first failure.
+func (a *AuthUseCase) countLoginFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	if _, err := a.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, window)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// clearLoginFailures resets the account's failure streak and lifts its
+// lockout. Address counters are left to expire on their own.
+func (a *AuthUseCase) clearLoginFailures(ctx context.Context, account string) {
+	if err := a.Redis.Del(ctx, loginFailKey("account", account), loginDelayKey(account), loginLockKey("account", account)).Err(); err != nil {
+		a.Log.WithError(err).Warn("Failed to clear login failures")
+	}
+}
+
+func (a *AuthUseCase) loginMaxAttempts() int {
+	return util.ParseIntOrDefault(a.Config.Auth.LoginMaxAttempts, 5)
+}
+
+func (a *AuthUseCase) loginIPMaxAttempts() int {
+	return util.ParseIntOrDefault(a.Config.Auth.LoginIPMaxAttempts, 20)
+}
+
+func (a *AuthUseCase) loginWindow() time.Duration {
+	return time.Minute * time.Duration(util.ParseIntOrDefault(a.Config.Auth.LoginWindow, 15))
+}
+
+func (a *AuthUseCase) loginLockout() time.Duration {
+	return time.Minute * time.Duration(util.ParseIntOrDefault(a.Config.Auth.LoginLockout, 15))
+}
+
+// loginAccount identifies an account by the digest of its normalized email
+// or phone number, keeping them out of Redis keys.
+func loginAccount(identifier string) string {
+	return util.HashToken(strings.ToLower(strings.TrimSpace(identifier)))
+}
+
+func loginFailKey(scope string, id string) string {
+	return "login:fail:" + scope + ":" + id
+}
+
+func loginLockKey(scope string, id string) string {
+	return "login:lock:" + scope + ":" + id
+}
+
+func loginDelayKey(account string) string {
+	return "login:delay:account:" + account
+}
+
+// formatWait rounds a remaining wait up to whole seconds or minutes.
+func formatWait(wait time.Duration) string {
+	if wait <= time.Minute {
+		return fmt.Sprintf("%d seconds", int(math.Ceil(wait.Seconds())))
+	}
+	return fmt.Sprintf("%d minutes", int(math.Ceil(wait.Minutes())))
+}
+
+func passwordResetKey(userID int64) string {
+	return "password_reset:" + strconv.FormatInt(userID, 10)
+}
+
+func passwordResetTokenKey(tokenHash string) string {
+	return "password_reset:token:" + tokenHash
+}
+
+func (a *AuthUseCase) otpLength() int {
+	return util.ParseIntOrDefault(a.Config.Auth.OTPLength, 6)
+}
+
+func (a *AuthUseCase) otpTTL() time.Duration {
+	return time.Minute * time.Duration(util.ParseIntOrDefault(a.Config.Auth.OTPTTL, 10))
+}
+
+func (a *AuthUseCase) otpMaxAttempts() int {
+	return util.ParseIntOrDefault(a.Config.Auth.OTPMaxAttempts, 5)
+}
+
+func (a *AuthUseCase) otpCooldown() time.Duration {
+	return time.Second * time.Duration(util.ParseIntOrDefault(a.Config.Auth.OTPResendCooldown, 60))
+}
+
+func otpKey(referenceID string) string {
+	return "otp:" + referenceID
+}
+
+func otpUserKey(userID int64) string {
+	return "otp:user:" + strconv.FormatInt(userID, 10)
+}
+
+func otpCooldownKey(userID int64) string {
+	return "otp:cooldown:" + strconv.FormatInt(userID, 10)
+}
+
+func loginOTPKey(referenceID string) string {
+	return "login_otp:" + referenceID
+}
+
+func loginOTPCooldownKey(phone string) string {
+	return "login_otp:cooldown:" + util.HashToken(phone)
+}
+
+func phoneOTPKey(userID int64) string {
+	return "phone_otp:" + strconv.FormatInt(userID, 10)
+}
+
+func phoneOTPCooldownKey(userID int64) string {
+	return "phone_otp:cooldown:" + strconv.FormatInt(userID, 10)
+}
+
+func twoFactorChallengeKey(tokenHash string) string {
+	return "2fa:challenge:" + tokenHash
+}
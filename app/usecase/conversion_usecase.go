@@ -0,0 +1,515 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+// conversionRateScale is the number of decimals a conversion rate is kept
+// and shown with.
+const conversionRateScale = 12
+
+type ConversionUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	Config                  *config.Config
+	WalletRepository        domain.WalletRepository
+	WalletBalanceRepository domain.WalletBalanceRepository
+	TransactionRepository   domain.TransactionRepository
+	ConversionRepository    domain.ConversionRepository
+	AuditUseCase            domain.AuditUseCase
+	FXRates                 domain.FXRates
+	Validate                *validator.Validate
+	Redis                   *redis.Client
+}
+
+// conversionQuote is what a quote stores for the conversion to be executed.
+// The amounts are fixed when quoting, so a rate change before execution
+// does not alter what the user agreed to.
+type conversionQuote struct {
+	UserID       int64  `json:"user_id"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Amount       int64  `json:"amount"`
+	ToAmount     int64  `json:"to_amount"`
+	SpreadAmount int64  `json:"spread_amount"`
+	Rate         string `json:"rate"`
+}
+
+func NewConversionUseCase(db *gorm.DB, log *logrus.Logger, config *config.Config, walletRepository domain.WalletRepository, walletBalanceRepository domain.WalletBalanceRepository, transactionRepository domain.TransactionRepository, conversionRepository domain.ConversionRepository, auditUseCase domain.AuditUseCase, fxRates domain.FXRates, validate *validator.Validate, redis *redis.Client) domain.ConversionUseCase {
+	return &ConversionUseCase{
+		DB:                      db,
+		Log:                     log,
+		Config:                  config,
+		WalletRepository:        walletRepository,
+		WalletBalanceRepository: walletBalanceRepository,
+		TransactionRepository:   transactionRepository,
+		ConversionRepository:    conversionRepository,
+		AuditUseCase:            auditUseCase,
+		FXRates:                 fxRates,
+		Validate:                validate,
+		Redis:                   redis,
+	}
+}
+
+// Quote implements domain.ConversionUseCase. The quote holds the converted
+// amount for FX_QUOTE_TTL seconds.
+func (cu *ConversionUseCase) Quote(ctx context.Context, req *dto.ConversionQuoteRequest, userID int64) (*dto.ConversionQuoteResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(cu.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	from, fromOK := domain.FindCurrency(req.From)
+	to, toOK := domain.FindCurrency(req.To)
+	if !fromOK || !toOK {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Currency is not supported")
+	}
+
+	// The spread has nowhere to go without a house wallet
+	if cu.Config.FX.HouseWalletNumber == "" {
+		return nil, domain.NewError(fiber.StatusServiceUnavailable, "Currency conversion is not available")
+	}
+
+	tx := cu.DB.WithContext(c)
+
+	wallet, err := cu.findWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !wallet.CanDebit() {
+		return nil, walletStatusError(wallet)
+	}
+
+	fromBalance, _, err := cu.findBalances(tx, wallet, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	if fromBalance.Balance < req.Amount {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Balance is insufficient")
+	}
+
+	mid, err := cu.FXRates.Rate(c, req.From, req.To)
+	if err != nil {
+		cu.Log.WithError(err).Warnf("Failed to get fx rate: %+v", err)
+		return nil, domain.NewError(fiber.StatusServiceUnavailable, "Exchange rates are unavailable, please try again later")
+	}
+
+	spreadBps := util.ParseIntOrDefault(cu.Config.FX.SpreadBps, 50)
+	toAmount, spreadAmount, rate, err := priceConversion(req.Amount, from, to, mid, spreadBps)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteKey := util.GenerateRandomString(32)
+	ttl := time.Duration(util.ParseIntOrDefault(cu.Config.FX.QuoteTTL, 60)) * time.Second
+
+	quoteData, err := json.Marshal(&conversionQuote{
+		UserID:       userID,
+		From:         req.From,
+		To:           req.To,
+		Amount:       req.Amount,
+		ToAmount:     toAmount,
+		SpreadAmount: spreadAmount,
+		Rate:         rate,
+	})
+	if err != nil {
+		cu.Log.WithError(err).Warn("Failed to serialize quote data")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Store the quote in Redis
+	if err := cu.Redis.Set(c, conversionQuoteKey(quoteKey), quoteData, ttl).Err(); err != nil {
+		cu.Log.WithError(err).Warn("Failed to store quote in Redis")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return &dto.ConversionQuoteResponse{
+		QuoteKey:        quoteKey,
+		From:            req.From,
+		To:              req.To,
+		Amount:          req.Amount,
+		ConvertedAmount: toAmount,
+		Rate:            rate,
+		ExpiresAt:       time.Now().Add(ttl).Format(time.RFC3339),
+	}, nil
+}
+
+// Execute implements domain.ConversionUseCase. Both legs and the spread are
+// booked in one database transaction.
+func (cu *ConversionUseCase) Execute(ctx context.Context, req *dto.ConversionExecuteRequest, userID int64) (*dto.ConversionData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(cu.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := cu.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	// Both wallets are share-locked, so neither can be frozen or closed
+	// until the conversion commits
+	shared := tx.Clauses(clause.Locking{Strength: "SHARE"}).Session(&gorm.Session{})
+
+	wallet, err := cu.findWallet(shared, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !wallet.CanDebit() {
+		return nil, walletStatusError(wallet)
+	}
+
+	// Check if pin code is valid
+	if !util.VerifyPassword(wallet.WalletPin, req.PinCode) {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid pin code")
+	}
+
+	// The house wallet takes the spread, so it is held to the same status
+	// rules as any wallet; checked before the quote is used up
+	house := new(domain.WalletEntity)
+	if err := cu.WalletRepository.FindByWalletNumber(shared, house, cu.Config.FX.HouseWalletNumber); err != nil {
+		cu.Log.WithError(err).Error("Failed to query house wallet")
+		return nil, domain.NewError(fiber.StatusServiceUnavailable, "Currency conversion is not available")
+	}
+	if house.ID == wallet.ID {
+		return nil, domain.NewError(fiber.StatusBadRequest, "The house wallet cannot convert currencies")
+	}
+	if !house.CanCredit() || !house.CanDebit() {
+		cu.Log.WithField("wallet_status", house.Status).Error("House wallet cannot take conversions")
+		return nil, domain.NewError(fiber.StatusServiceUnavailable, "Currency conversion is not available")
+	}
+
+	// Take the quote out of Redis, so it can only be executed once
+	data, err := cu.Redis.GetDel(c, conversionQuoteKey(req.QuoteKey)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, domain.NewError(fiber.StatusBadRequest, "Quote has expired, please request a new one")
+		}
+		cu.Log.WithError(err).Warn("Failed to get quote from Redis")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	quote := new(conversionQuote)
+	if err := json.Unmarshal([]byte(data), quote); err != nil {
+		cu.Log.WithError(err).Error("Failed to deserialize quote data")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if quote.UserID != userID {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Quote has expired, please request a new one")
+	}
+
+	// Lock every balance the conversion touches
+	fromBalance, toBalance, houseBalance, err := cu.lockBalances(tx, wallet, house, quote.From, quote.To)
+	if err != nil {
+		return nil, err
+	}
+	if fromBalance.Balance < quote.Amount {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Balance is insufficient")
+	}
+
+	before := map[string]any{
+		"from_balance":  fromBalance.Balance,
+		"to_balance":    toBalance.Balance,
+		"house_balance": houseBalance.Balance,
+	}
+
+	fromBalance.Balance -= quote.Amount
+	toBalance.Balance += quote.ToAmount
+	houseBalance.Balance += quote.SpreadAmount
+	for _, balance := range []*domain.WalletBalanceEntity{fromBalance, toBalance, houseBalance} {
+		if err := cu.WalletBalanceRepository.Update(tx, balance); err != nil {
+			cu.Log.WithError(err).Error("Failed to update wallet balance")
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	now := time.Now()
+
+	transactions := []domain.TransactionEntity{
+		{
+			WalletID:        wallet.ID,
+			SofNumber:       wallet.WalletNumber,
+			DofNumber:       domain.ConversionNumber,
+			TransactionType: domain.TransactionOut,
+			Amount:          quote.Amount,
+			Currency:        quote.From,
+			TransactionAt:   now,
+		},
+		{
+			WalletID:        wallet.ID,
+			SofNumber:       domain.ConversionNumber,
+			DofNumber:       wallet.WalletNumber,
+			TransactionType: domain.TransactionIn,
+			Amount:          quote.ToAmount,
+			Currency:        quote.To,
+			TransactionAt:   now,
+		},
+	}
+	if quote.SpreadAmount > 0 {
+		transactions = append(transactions, domain.TransactionEntity{
+			WalletID:        house.ID,
+			SofNumber:       domain.ConversionNumber,
+			DofNumber:       house.WalletNumber,
+			TransactionType: domain.TransactionIn,
+			Amount:          quote.SpreadAmount,
+			Currency:        quote.To,
+			TransactionAt:   now,
+		})
+	}
+	for i := range transactions {
+		if err := cu.TransactionRepository.Create(tx, &transactions[i]); err != nil {
+			cu.Log.WithError(err).Error("Failed to create conversion transaction")
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	conversion := &domain.ConversionEntity{
+		UserID:       userID,
+		WalletID:     wallet.ID,
+		FromCurrency: quote.From,
+		ToCurrency:   quote.To,
+		FromAmount:   quote.Amount,
+		ToAmount:     quote.ToAmount,
+		Rate:         quote.Rate,
+		SpreadAmount: quote.SpreadAmount,
+		CreatedAt:    now,
+	}
+	if err := cu.ConversionRepository.Create(tx, conversion); err != nil {
+		cu.Log.WithError(err).Error("Failed to create conversion")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := cu.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    userID,
+		Action:     domain.AuditConversion,
+		TargetType: domain.AuditTargetConversion,
+		TargetID:   strconv.FormatInt(conversion.ID, 10),
+		Metadata: map[string]any{
+			"wallet_id":     wallet.ID,
+			"from":          quote.From,
+			"to":            quote.To,
+			"from_amount":   quote.Amount,
+			"to_amount":     quote.ToAmount,
+			"rate":          quote.Rate,
+			"spread_amount": quote.SpreadAmount,
+		},
+		Before: before,
+		After: map[string]any{
+			"from_balance":  fromBalance.Balance,
+			"to_balance":    toBalance.Balance,
+			"house_balance": houseBalance.Balance,
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		cu.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Commit the transaction to persist changes
+	if err := tx.Commit().Error; err != nil {
+		cu.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := toConversionData(conversion)
+	return &result, nil
+}
+
+// FindHistory implements domain.ConversionUseCase.
+func (cu *ConversionUseCase) FindHistory(ctx context.Context, req *dto.ConversionListRequest, userID int64) ([]dto.ConversionData, *dto.PageMetadata, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(cu.Validate, req); len(validationErrors) > 0 {
+		return nil, nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+	req.Page, req.Size = util.NormalizePage(req.Page, req.Size)
+
+	tx := cu.DB.WithContext(c)
+
+	wallet, err := cu.findWallet(tx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conversions := []domain.ConversionEntity{}
+	total, err := cu.ConversionRepository.FindPageByWalletID(tx, &conversions, wallet.ID, req.Page, req.Size)
+	if err != nil {
+		cu.Log.WithError(err).Warnf("Failed to query conversions: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.ConversionData, 0, len(conversions))
+	for i := range conversions {
+		result = append(result, toConversionData(&conversions[i]))
+	}
+
+	return result, util.NewPageMetadata(req.Page, req.Size, total), nil
+}
+
+func (cu *ConversionUseCase) findWallet(tx *gorm.DB, userID int64) (*domain.WalletEntity, error) {
+	wallet := new(domain.WalletEntity)
+	if err := cu.WalletRepository.FindByUserID(tx, wallet, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusNotFound, "Wallet not found")
+		}
+		cu.Log.WithError(err).Warn("Failed to query wallet")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	return wallet, nil
+}
+
+// findBalances loads the user's balances in both currencies. The target
+// balance has to be opened before converting into it.
+func (cu *ConversionUseCase) findBalances(tx *gorm.DB, wallet *domain.WalletEntity, from, to string) (*domain.WalletBalanceEntity, *domain.WalletBalanceEntity, error) {
+	fromBalance, toBalance := new(domain.WalletBalanceEntity), new(domain.WalletBalanceEntity)
+
+	lookups := []struct {
+		balance  *domain.WalletBalanceEntity
+		currency string
+		missing  string
+	}{
+		{fromBalance, from, fmt.Sprintf("You do not hold a %s balance", from)},
+		{toBalance, to, fmt.Sprintf("Open a %s balance before converting to it", to)},
+	}
+	for _, lookup := range lookups {
+		if err := cu.WalletBalanceRepository.FindByWalletIDAndCurrency(tx, lookup.balance, wallet.ID, lookup.currency); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, domain.NewError(fiber.StatusBadRequest, lookup.missing)
+			}
+			cu.Log.WithError(err).Warn("Failed to query wallet balance")
+			return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	return fromBalance, toBalance, nil
+}
+
+// lockBalances locks the user's two balances and the house balance the
+// spread goes to. Rows are locked in wallet and currency order, the same
+// for every conversion, so concurrent ones cannot deadlock. The house
+// wallet gets a balance in the target currency the first time it needs one.
+func (cu *ConversionUseCase) lockBalances(tx *gorm.DB, wallet *domain.WalletEntity, house *domain.WalletEntity, from, to string) (*domain.WalletBalanceEntity, *domain.WalletBalanceEntity, *domain.WalletBalanceEntity, error) {
+	fromBalance, toBalance, houseBalance := new(domain.WalletBalanceEntity), new(domain.WalletBalanceEntity), new(domain.WalletBalanceEntity)
+
+	type lookup struct {
+		walletID int64
+		currency string
+		balance  *domain.WalletBalanceEntity
+	}
+	lookups := []lookup{
+		{wallet.ID, from, fromBalance},
+		{wallet.ID, to, toBalance},
+		{house.ID, to, houseBalance},
+	}
+	sort.Slice(lookups, func(i, j int) bool {
+		if lookups[i].walletID != lookups[j].walletID {
+			return lookups[i].walletID < lookups[j].walletID
+		}
+		return lookups[i].currency < lookups[j].currency
+	})
+
+	// The session keeps one lookup's conditions out of the next
+	locked := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Session(&gorm.Session{})
+	for _, l := range lookups {
+		err := cu.WalletBalanceRepository.FindByWalletIDAndCurrency(locked, l.balance, l.walletID, l.currency)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			cu.Log.WithError(err).Warn("Failed to query wallet balance")
+			return nil, nil, nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+
+		switch l.balance {
+		case fromBalance:
+			return nil, nil, nil, domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("You do not hold a %s balance", from))
+		case toBalance:
+			return nil, nil, nil, domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("Open a %s balance before converting to it", to))
+		}
+
+		*l.balance = domain.WalletBalanceEntity{WalletID: l.walletID, Currency: l.currency}
+		if err := cu.WalletBalanceRepository.Create(tx, l.balance); err != nil {
+			cu.Log.WithError(err).Warnf("Failed to create house wallet balance: %+v", err)
+			return nil, nil, nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	return fromBalance, toBalance, houseBalance, nil
+}
+
+// priceConversion works out what amount, in the minor unit of from, converts
+// to at the mid-market rate less spreadBps basis points. The user's amount
+// is rounded down; the spread and the rounding go to the house.
+func priceConversion(amount int64, from, to domain.Currency, mid *big.Rat, spreadBps int) (toAmount int64, spreadAmount int64, rate string, err error) {
+	// Bring the amount to the minor unit of to before applying the rate
+	scale := new(big.Rat).SetFrac(pow10(to.MinorUnits), pow10(from.MinorUnits))
+	gross := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), mid)
+	gross.Mul(gross, scale)
+
+	keep := big.NewRat(int64(10_000-spreadBps), 10_000)
+	net := new(big.Rat).Mul(gross, keep)
+
+	grossAmount := new(big.Int).Quo(gross.Num(), gross.Denom())
+	netAmount := new(big.Int).Quo(net.Num(), net.Denom())
+	if !grossAmount.IsInt64() {
+		return 0, 0, "", domain.NewError(fiber.StatusBadRequest, "Amount is too large to convert")
+	}
+	if netAmount.Sign() <= 0 {
+		return 0, 0, "", domain.NewError(fiber.StatusBadRequest, "Amount is too small to convert")
+	}
+
+	toAmount = netAmount.Int64()
+	spreadAmount = grossAmount.Int64() - toAmount
+	rate = new(big.Rat).Mul(mid, keep).FloatString(conversionRateScale)
+
+	return toAmount, spreadAmount, rate, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func conversionQuoteKey(key string) string {
+	return "conversion:quote:" + key
+}
+
+func toConversionData(conversion *domain.ConversionEntity) dto.ConversionData {
+	return dto.ConversionData{
+		ID:           conversion.ID,
+		FromCurrency: conversion.FromCurrency,
+		ToCurrency:   conversion.ToCurrency,
+		FromAmount:   conversion.FromAmount,
+		ToAmount:     conversion.ToAmount,
+		Rate:         conversion.Rate,
+		CreatedAt:    conversion.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"riz.it/domped/app/domain"
+)
+
+func TestPriceConversion(t *testing.T) {
+	idr, _ := domain.FindCurrency("IDR")
+	usd, _ := domain.FindCurrency("USD")
+	jpy, _ := domain.FindCurrency("JPY")
+
+	tests := []struct {
+		name       string
+		amount     int64
+		from, to   domain.Currency
+		mid        *big.Rat
+		spreadBps  int
+		wantAmount int64
+		wantSpread int64
+		wantRate   string
+		wantErr    string
+	}{
+		{
+			name:   "minor units scaled up",
+			amount: 1_000_000, from: idr, to: usd,
+			mid: big.NewRat(1, 16_000), spreadBps: 50,
+			wantAmount: 6_218, wantSpread: 32, wantRate: "0.000062187500",
+		},
+		{
+			name:   "minor units scaled down without spread",
+			amount: 1_050, from: usd, to: idr,
+			mid: big.NewRat(16_000, 1), spreadBps: 0,
+			wantAmount: 168_000, wantSpread: 0, wantRate: "16000.000000000000",
+		},
+		{
+			// Gross 499.5 and net 498.0015 are both rounded down, so the
+			// house keeps the fractions on top of the spread
+			name:   "fractions go to the house",
+			amount: 333, from: usd, to: jpy,
+			mid: big.NewRat(150, 1), spreadBps: 30,
+			wantAmount: 498, wantSpread: 1, wantRate: "149.550000000000",
+		},
+		{
+			name:   "rounds down to nothing",
+			amount: 1, from: idr, to: usd,
+			mid: big.NewRat(1, 16_000), spreadBps: 50,
+			wantErr: "Amount is too small to convert",
+		},
+		{
+			name:   "overflows the target amount",
+			amount: math.MaxInt64, from: usd, to: idr,
+			mid: big.NewRat(16_000, 1), spreadBps: 50,
+			wantErr: "Amount is too large to convert",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, spread, rate, err := priceConversion(tt.amount, tt.from, tt.to, tt.mid, tt.spreadBps)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("priceConversion() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("priceConversion() error = %v", err)
+			}
+			if amount != tt.wantAmount || spread != tt.wantSpread {
+				t.Errorf("priceConversion() = %d with spread %d, want %d with spread %d", amount, spread, tt.wantAmount, tt.wantSpread)
+			}
+			if rate != tt.wantRate {
+				t.Errorf("priceConversion() rate = %s, want %s", rate, tt.wantRate)
+			}
+		})
+	}
+}
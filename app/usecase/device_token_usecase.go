@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+type DeviceTokenUseCase struct {
+	DB                    *gorm.DB
+	Log                   *logrus.Logger
+	DeviceTokenRepository domain.DeviceTokenRepository
+	Validate              *validator.Validate
+}
+
+func NewDeviceTokenUseCase(db *gorm.DB, log *logrus.Logger, deviceTokenRepository domain.DeviceTokenRepository, validate *validator.Validate) domain.DeviceTokenUseCase {
+	return &DeviceTokenUseCase{
+		DB:                    db,
+		Log:                   log,
+		DeviceTokenRepository: deviceTokenRepository,
+		Validate:              validate,
+	}
+}
+
+// Register implements domain.DeviceTokenUseCase.
+func (d *DeviceTokenUseCase) Register(ctx context.Context, req *dto.RegisterDeviceTokenRequest, userID int64) (*dto.DeviceTokenData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(d.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := d.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	// A token identifies a device, so re-registering it moves it to the
+	// current user and clears any failure history
+	token := new(domain.DeviceTokenEntity)
+	err := d.DeviceTokenRepository.FindByToken(tx, token, req.Token)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		d.Log.WithError(err).Warn("Failed to query device token")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	token.UserID = userID
+	token.Token = req.Token
+	token.Platform = req.Platform
+	token.DeviceName = req.DeviceName
+	token.FailureCount = 0
+	token.LastError = ""
+	token.LastFailureAt = nil
+
+	if token.ID == 0 {
+		err = d.DeviceTokenRepository.Create(tx, token)
+	} else {
+		err = d.DeviceTokenRepository.Update(tx, token)
+	}
+	if err != nil {
+		d.Log.WithError(err).Warn("Failed to save device token")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		d.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := toDeviceTokenData(token)
+	return &result, nil
+}
+
+// FindByUserID implements domain.DeviceTokenUseCase.
+func (d *DeviceTokenUseCase) FindByUserID(ctx context.Context, userID int64) (*[]dto.DeviceTokenData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tokens := new([]domain.DeviceTokenEntity)
+	if err := d.DeviceTokenRepository.FindByUserID(d.DB.WithContext(c), tokens, userID); err != nil {
+		d.Log.WithError(err).Warn("Failed to query device tokens")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.DeviceTokenData, 0, len(*tokens))
+	for _, v := range *tokens {
+		result = append(result, toDeviceTokenData(&v))
+	}
+
+	return &result, nil
+}
+
+// Unregister implements domain.DeviceTokenUseCase.
+func (d *DeviceTokenUseCase) Unregister(ctx context.Context, id int64, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := d.DB.WithContext(c)
+
+	token := new(domain.DeviceTokenEntity)
+	if err := d.DeviceTokenRepository.FindByID(tx, token, id); err != nil || token.UserID != userID {
+		return domain.NewError(fiber.StatusNotFound, "Device not found")
+	}
+
+	if err := d.DeviceTokenRepository.Delete(tx, token); err != nil {
+		d.Log.WithError(err).Warn("Failed to delete device token")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func toDeviceTokenData(token *domain.DeviceTokenEntity) dto.DeviceTokenData {
+	data := dto.DeviceTokenData{
+		ID:           token.ID,
+		Platform:     token.Platform,
+		DeviceName:   token.DeviceName,
+		FailureCount: token.FailureCount,
+		CreatedAt:    token.CreatedAt.String(),
+	}
+	if token.LastSuccessAt != nil {
+		data.LastSuccessAt = token.LastSuccessAt.String()
+	}
+	return data
+}
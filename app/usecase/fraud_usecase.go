@@ -0,0 +1,602 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+const (
+	fraudChallengeTTL         = 5 * time.Minute
+	fraudChallengeMaxAttempts = 5
+	fraudChallengeCodeLength  = 6
+	// A transfer gets this many codes over the life of its inquiry
+	fraudChallengeMaxIssues   = 3
+	fraudChallengeIssueWindow = 24 * time.Hour
+
+	fraudChannelSMS   = "sms"
+	fraudChannelEmail = "email"
+)
+
+// fraudOutcomes orders the outcomes by severity, so the strictest matched
+// rule decides.
+var fraudOutcomes = []string{domain.FraudAllow, domain.FraudChallenge, domain.FraudBlock}
+
+type FraudUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	FraudRuleRepository     domain.FraudRuleRepository
+	FraudDecisionRepository domain.FraudDecisionRepository
+	TransactionRepository   domain.TransactionRepository
+	SessionRepository       domain.SessionRepository
+	UserRepository          domain.UserRepository
+	AuditUseCase            domain.AuditUseCase
+	Email                   domain.Email
+	SMS                     domain.SMS
+	Template                domain.Template
+	Validate                *validator.Validate
+	Redis                   *redis.Client
+}
+
+func NewFraudUseCase(db *gorm.DB, log *logrus.Logger, fraudRuleRepository domain.FraudRuleRepository, fraudDecisionRepository domain.FraudDecisionRepository, transactionRepository domain.TransactionRepository, sessionRepository domain.SessionRepository, userRepository domain.UserRepository, auditUseCase domain.AuditUseCase, email domain.Email, sms domain.SMS, template domain.Template, validate *validator.Validate, redis *redis.Client) domain.FraudUseCase {
+	return &FraudUseCase{
+		DB:                      db,
+		Log:                     log,
+		FraudRuleRepository:     fraudRuleRepository,
+		FraudDecisionRepository: fraudDecisionRepository,
+		TransactionRepository:   transactionRepository,
+		SessionRepository:       sessionRepository,
+		UserRepository:          userRepository,
+		AuditUseCase:            auditUseCase,
+		Email:                   email,
+		SMS:                     sms,
+		Template:                template,
+		Validate:                validate,
+		Redis:                   redis,
+	}
+}
+
+// Evaluate implements domain.FraudUseCase.
+func (f *FraudUseCase) Evaluate(ctx context.Context, check *domain.FraudCheck) (*domain.FraudResult, error) {
+	db := f.DB.WithContext(ctx)
+
+	var rules []domain.FraudRuleEntity
+	if err := f.FraudRuleRepository.FindEnabled(db, &rules); err != nil {
+		return nil, err
+	}
+
+	result := &domain.FraudResult{Outcome: domain.FraudAllow}
+	for i := range rules {
+		rule := &rules[i]
+
+		matched, err := f.matches(db, rule, check)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		result.MatchedRules = append(result.MatchedRules, rule.Code)
+		if slices.Index(fraudOutcomes, rule.Action) > slices.Index(fraudOutcomes, result.Outcome) {
+			result.Outcome = rule.Action
+		}
+	}
+
+	if len(result.MatchedRules) == 0 {
+		return result, nil
+	}
+
+	// Written on its own, so a blocked transfer still leaves its decision
+	// behind for review
+	matched, err := json.Marshal(result.MatchedRules)
+	if err != nil {
+		return nil, err
+	}
+	decision := &domain.FraudDecisionEntity{
+		UserID:       check.UserID,
+		WalletID:     check.WalletID,
+		Stage:        check.Stage,
+		DofNumber:    check.DofNumber,
+		Amount:       check.Amount,
+		Currency:     check.Currency,
+		Outcome:      result.Outcome,
+		MatchedRules: string(matched),
+		IPAddress:    check.IPAddress,
+		UserAgent:    truncateRunes(check.UserAgent, auditUserAgentLength),
+	}
+	if check.SessionID != 0 {
+		decision.SessionID = &check.SessionID
+	}
+	if err := f.FraudDecisionRepository.Create(db, decision); err != nil {
+		return nil, err
+	}
+	result.DecisionID = decision.ID
+
+	f.Log.WithFields(logrus.Fields{
+		"user_id":  check.UserID,
+		"stage":    check.Stage,
+		"outcome":  result.Outcome,
+		"rules":    result.MatchedRules,
+		"decision": decision.ID,
+	}).Warn("Transfer matched fraud rules")
+
+	return result, nil
+}
+
+// matches reports whether the transfer trips the rule. A rule whose
+// parameters cannot be read never matches, so a bad edit cannot stop all
+// transfers. Amount thresholds are in the default currency, so rules with
+// one only look at transfers in it.
+func (f *FraudUseCase) matches(db *gorm.DB, rule *domain.FraudRuleEntity, check *domain.FraudCheck) (bool, error) {
+	params := new(domain.FraudRuleParams)
+	if err := json.Unmarshal([]byte(rule.Params), params); err != nil {
+		f.Log.WithError(err).WithField("rule", rule.Code).Warn("Skipping fraud rule with unreadable params")
+		return false, nil
+	}
+
+	if rule.Type != domain.FraudRuleVelocity && check.Currency != domain.DefaultCurrency {
+		return false, nil
+	}
+
+	switch rule.Type {
+	case domain.FraudRuleVelocity:
+		if params.MaxCount <= 0 || params.WindowMinutes <= 0 {
+			return false, nil
+		}
+		since := time.Now().Add(-time.Duration(params.WindowMinutes) * time.Minute)
+		count, err := f.TransactionRepository.CountOutByWalletIDSince(db, check.WalletID, since)
+		if err != nil {
+			return false, err
+		}
+		// The transfer being made counts towards the limit
+		return count+1 > params.MaxCount, nil
+
+	case domain.FraudRuleNewDeviceAmount:
+		if check.Amount < params.MinAmount {
+			return false, nil
+		}
+		// A session that cannot be found is treated as a new device
+		session := new(domain.SessionEntity)
+		if err := f.SessionRepository.FindByID(db, session, check.SessionID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return true, nil
+			}
+			return false, err
+		}
+		return time.Since(session.CreatedAt) < time.Duration(params.DeviceAgeHours)*time.Hour, nil
+
+	case domain.FraudRuleNewBeneficiary:
+		if check.Amount < params.MinAmount {
+			return false, nil
+		}
+		count, err := f.TransactionRepository.CountOutByWalletIDAndDofNumber(db, check.WalletID, check.DofNumber)
+		if err != nil {
+			return false, err
+		}
+		return count == 0, nil
+
+	case domain.FraudRuleTopUpCashOut:
+		if params.WindowMinutes <= 0 || params.MinPercent <= 0 {
+			return false, nil
+		}
+		since := time.Now().Add(-time.Duration(params.WindowMinutes) * time.Minute)
+		toppedUp, err := f.TransactionRepository.SumInBySofNumberSince(db, check.WalletID, domain.TopUpSofNumber, since)
+		if err != nil {
+			return false, err
+		}
+		return toppedUp > 0 && check.Amount*100 >= toppedUp*params.MinPercent, nil
+	}
+
+	f.Log.WithField("rule", rule.Code).Warnf("Skipping fraud rule of unknown type %q", rule.Type)
+	return false, nil
+}
+
+// IssueChallenge implements domain.FraudUseCase.
+func (f *FraudUseCase) IssueChallenge(ctx context.Context, key string, userID int64) (*dto.TransferChallengeData, error) {
+	challengeKey := fraudChallengeKey(key)
+
+	// A code already on its way is not sent again
+	pending, err := f.Redis.HGet(ctx, challengeKey, "channel").Result()
+	if err == nil {
+		ttl, err := f.Redis.TTL(ctx, challengeKey).Result()
+		if err == nil && ttl > 0 {
+			return &dto.TransferChallengeData{
+				Channel:   pending,
+				ExpiresIn: int(ttl.Seconds()),
+			}, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		f.Log.WithError(err).Warnf("Failed to query transfer challenge: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Asking for another code does not reset the guesses; once the codes
+	// run out the transfer has to start over
+	issuedKey := fraudChallengeIssuedKey(key)
+	var issued *redis.IntCmd
+	if _, err := f.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		issued = pipe.Incr(ctx, issuedKey)
+		pipe.ExpireNX(ctx, issuedKey, fraudChallengeIssueWindow)
+		return nil
+	}); err != nil {
+		f.Log.WithError(err).Warnf("Failed to count transfer challenges: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if issued.Val() > fraudChallengeMaxIssues {
+		f.Redis.Del(ctx, key)
+		return nil, domain.NewError(fiber.StatusTooManyRequests, "Too many verification codes requested, please start the transfer again")
+	}
+
+	user := new(domain.UserEntity)
+	if err := f.UserRepository.FindByID(f.DB.WithContext(ctx), user, userID); err != nil {
+		f.Log.WithError(err).Warnf("Failed to find user: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Codes go to the verified phone number when there is one
+	channel := fraudChannelEmail
+	if user.PhoneVerifiedAt != nil {
+		channel = fraudChannelSMS
+	}
+	code := util.GenerateRandomCode(fraudChallengeCodeLength)
+
+	pipe := f.Redis.TxPipeline()
+	pipe.HSet(ctx, challengeKey, "code_hash", util.HashToken(code), "channel", channel)
+	pipe.Expire(ctx, challengeKey, fraudChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		f.Log.WithError(err).Warnf("Failed to store transfer challenge: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := f.sendChallenge(ctx, user, channel, code); err != nil {
+		f.Log.WithError(err).Warnf("Failed to send transfer challenge: %+v", err)
+		f.Redis.Del(ctx, challengeKey)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return &dto.TransferChallengeData{
+		Channel:   channel,
+		ExpiresIn: int(fraudChallengeTTL.Seconds()),
+	}, nil
+}
+
+// VerifyChallenge implements domain.FraudUseCase.
+func (f *FraudUseCase) VerifyChallenge(ctx context.Context, key string, code string, decisionID int64) error {
+	challengeKey := fraudChallengeKey(key)
+
+	codeHash, err := f.Redis.HGet(ctx, challengeKey, "code_hash").Result()
+	if err != nil {
+		return domain.NewError(fiber.StatusBadRequest, "Verification code has expired, submit the transfer without a code to get a new one")
+	}
+
+	// Count the attempt before comparing so guesses are always limited
+	attempts, err := f.Redis.HIncrBy(ctx, challengeKey, "attempts", 1).Result()
+	if err != nil {
+		f.Log.WithError(err).Warnf("Failed to count challenge attempts: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(code)), []byte(codeHash)) != 1 {
+		// Running out of guesses drops the transfer itself, so a new
+		// code cannot be asked for to keep guessing
+		if attempts >= fraudChallengeMaxAttempts {
+			f.Redis.Del(ctx, challengeKey, key)
+			return domain.NewError(fiber.StatusBadRequest, "Too many invalid attempts, please start the transfer again")
+		}
+		return domain.NewError(fiber.StatusBadRequest, "Invalid verification code")
+	}
+
+	// Each code confirms a single transfer
+	f.Redis.Del(ctx, challengeKey)
+
+	if decisionID != 0 {
+		decision := new(domain.FraudDecisionEntity)
+		db := f.DB.WithContext(ctx)
+		if err := f.FraudDecisionRepository.FindByID(db, decision, decisionID); err != nil {
+			f.Log.WithError(err).Warnf("Failed to find fraud decision: %+v", err)
+			return nil
+		}
+		now := time.Now()
+		decision.ChallengePassedAt = &now
+		if err := f.FraudDecisionRepository.Update(db, decision); err != nil {
+			f.Log.WithError(err).Warnf("Failed to save fraud decision: %+v", err)
+		}
+	}
+
+	return nil
+}
+
+// FindRules implements domain.FraudUseCase.
+func (f *FraudUseCase) FindRules(ctx context.Context) ([]dto.FraudRuleData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var rules []domain.FraudRuleEntity
+	if err := f.FraudRuleRepository.FindAll(f.DB.WithContext(c).Order("id"), &rules); err != nil {
+		f.Log.WithError(err).Warnf("Failed to query fraud rules: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.FraudRuleData, 0, len(rules))
+	for i := range rules {
+		result = append(result, *toFraudRuleData(&rules[i]))
+	}
+
+	return result, nil
+}
+
+// UpdateRule implements domain.FraudUseCase.
+func (f *FraudUseCase) UpdateRule(ctx context.Context, req *dto.UpdateFraudRuleRequest, ruleID int64, actorID int64) (*dto.FraudRuleData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(f.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := f.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	rule := new(domain.FraudRuleEntity)
+	if err := f.FraudRuleRepository.FindByID(tx, rule, ruleID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "Rule not found")
+	}
+	before := toFraudRuleData(rule)
+
+	if req.Action != "" {
+		rule.Action = req.Action
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.Params != nil {
+		params, err := json.Marshal(domain.FraudRuleParams(*req.Params))
+		if err != nil {
+			f.Log.WithError(err).Warnf("Failed to encode fraud rule params: %+v", err)
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		rule.Params = string(params)
+	}
+	rule.UpdatedBy = &actorID
+
+	if err := f.FraudRuleRepository.Update(tx, rule); err != nil {
+		f.Log.WithError(err).Warnf("Failed to save fraud rule: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	after := toFraudRuleData(rule)
+
+	if err := f.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		ActorID:    actorID,
+		Action:     domain.AuditFraudRuleUpdated,
+		TargetType: domain.AuditTargetFraudRule,
+		TargetID:   strconv.FormatInt(rule.ID, 10),
+		Before:     before,
+		After:      after,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		f.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		f.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return after, nil
+}
+
+// FindDecisions implements domain.FraudUseCase.
+func (f *FraudUseCase) FindDecisions(ctx context.Context, req *dto.FraudDecisionListRequest, actorID int64) ([]dto.FraudDecisionData, *dto.PageMetadata, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(f.Validate, req); len(validationErrors) > 0 {
+		return nil, nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	req.Page, req.Size = util.NormalizePage(req.Page, req.Size)
+
+	filter := &domain.FraudDecisionFilter{
+		UserID:  req.UserID,
+		Outcome: req.Outcome,
+	}
+	if req.Reviewed != "" {
+		reviewed := req.Reviewed == "true"
+		filter.Reviewed = &reviewed
+	}
+
+	tx := f.DB.WithContext(c)
+
+	if err := f.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:  req.UserID,
+		ActorID: actorID,
+		Action:  domain.AuditAdminFraudSearched,
+		Metadata: map[string]any{
+			"outcome":  req.Outcome,
+			"reviewed": req.Reviewed,
+			"page":     req.Page,
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		f.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	var decisions []domain.FraudDecisionEntity
+	total, err := f.FraudDecisionRepository.FindPage(tx, &decisions, filter, req.Page, req.Size)
+	if err != nil {
+		f.Log.WithError(err).Warnf("Failed to query fraud decisions: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.FraudDecisionData, 0, len(decisions))
+	for i := range decisions {
+		result = append(result, *toFraudDecisionData(&decisions[i]))
+	}
+
+	return result, util.NewPageMetadata(req.Page, req.Size, total), nil
+}
+
+// ReviewDecision implements domain.FraudUseCase.
+func (f *FraudUseCase) ReviewDecision(ctx context.Context, req *dto.ReviewFraudDecisionRequest, decisionID int64, actorID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(f.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := f.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	decision := new(domain.FraudDecisionEntity)
+	if err := f.FraudDecisionRepository.FindByID(tx, decision, decisionID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "Decision not found")
+	}
+	if decision.ReviewedAt != nil {
+		return domain.NewError(fiber.StatusConflict, "Decision has already been reviewed")
+	}
+	// Staff do not sign off on their own transfers
+	if decision.UserID == actorID {
+		return domain.NewError(fiber.StatusForbidden, "You cannot review your own transfers")
+	}
+
+	now := time.Now()
+	decision.ReviewedBy = &actorID
+	decision.ReviewedAt = &now
+	decision.ReviewNote = req.Note
+	if err := f.FraudDecisionRepository.Update(tx, decision); err != nil {
+		f.Log.WithError(err).Warnf("Failed to save fraud decision: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := f.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     decision.UserID,
+		ActorID:    actorID,
+		Action:     domain.AuditFraudReviewed,
+		TargetType: domain.AuditTargetFraudCase,
+		TargetID:   strconv.FormatInt(decision.ID, 10),
+		Metadata:   map[string]any{"note": req.Note},
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		f.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		f.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// sendChallenge delivers the code by SMS or email in the user's language.
+func (f *FraudUseCase) sendChallenge(ctx context.Context, user *domain.UserEntity, channel string, code string) error {
+	data := map[string]any{
+		"Name":             user.FullName,
+		"OTP":              code,
+		"ExpiresInMinutes": int(fraudChallengeTTL.Minutes()),
+	}
+
+	if channel == fraudChannelSMS {
+		body, err := f.Template.RenderSMS(user.Language, "transfer_challenge", data)
+		if err != nil {
+			return err
+		}
+		return f.SMS.Send(ctx, &domain.SMSMessage{
+			To:   user.Phone,
+			Body: body,
+		})
+	}
+
+	email, err := f.Template.RenderEmail(user.Language, "transfer_challenge", data)
+	if err != nil {
+		return err
+	}
+	return f.Email.Send(ctx, &domain.EmailMessage{
+		To:      []string{user.Email},
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	})
+}
+
+func fraudChallengeKey(key string) string {
+	return "fraud_challenge:" + key
+}
+
+func fraudChallengeIssuedKey(key string) string {
+	return "fraud_challenge_issued:" + key
+}
+
+func toFraudRuleData(rule *domain.FraudRuleEntity) *dto.FraudRuleData {
+	params := new(domain.FraudRuleParams)
+	_ = json.Unmarshal([]byte(rule.Params), params)
+
+	return &dto.FraudRuleData{
+		ID:          rule.ID,
+		Code:        rule.Code,
+		Type:        rule.Type,
+		Action:      rule.Action,
+		Params:      dto.FraudRuleParams(*params),
+		Description: rule.Description,
+		Enabled:     rule.Enabled,
+		UpdatedBy:   rule.UpdatedBy,
+		UpdatedAt:   rule.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toFraudDecisionData(decision *domain.FraudDecisionEntity) *dto.FraudDecisionData {
+	var matched []string
+	_ = json.Unmarshal([]byte(decision.MatchedRules), &matched)
+
+	data := &dto.FraudDecisionData{
+		ID:           decision.ID,
+		UserID:       decision.UserID,
+		WalletID:     decision.WalletID,
+		SessionID:    decision.SessionID,
+		Stage:        decision.Stage,
+		DofNumber:    decision.DofNumber,
+		Amount:       decision.Amount,
+		Currency:     decision.Currency,
+		Outcome:      decision.Outcome,
+		MatchedRules: matched,
+		IPAddress:    decision.IPAddress,
+		ReviewedBy:   decision.ReviewedBy,
+		ReviewNote:   decision.ReviewNote,
+		CreatedAt:    decision.CreatedAt.Format(time.RFC3339),
+	}
+	if decision.ChallengePassedAt != nil {
+		data.ChallengePassedAt = decision.ChallengePassedAt.Format(time.RFC3339)
+	}
+	if decision.ReviewedAt != nil {
+		data.ReviewedAt = decision.ReviewedAt.Format(time.RFC3339)
+	}
+	return data
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+// fraudTransactions answers the history lookups the rules make and remembers
+// which wallet they were asked about.
+type fraudTransactions struct {
+	domain.TransactionRepository
+	outCount    int64
+	beneficiary int64
+	toppedUp    int64
+	walletIDs   []int64
+}
+
+func (r *fraudTransactions) CountOutByWalletIDSince(db *gorm.DB, walletID int64, since time.Time) (int64, error) {
+	r.walletIDs = append(r.walletIDs, walletID)
+	return r.outCount, nil
+}
+
+func (r *fraudTransactions) CountOutByWalletIDAndDofNumber(db *gorm.DB, walletID int64, dofNumber string) (int64, error) {
+	r.walletIDs = append(r.walletIDs, walletID)
+	return r.beneficiary, nil
+}
+
+func (r *fraudTransactions) SumInBySofNumberSince(db *gorm.DB, walletID int64, sofNumber string, since time.Time) (int64, error) {
+	r.walletIDs = append(r.walletIDs, walletID)
+	if sofNumber != domain.TopUpSofNumber {
+		return 0, nil
+	}
+	return r.toppedUp, nil
+}
+
+type fraudSessions struct {
+	domain.SessionRepository
+	sessions map[int64]time.Time
+}
+
+func (r *fraudSessions) FindByID(db *gorm.DB, session *domain.SessionEntity, id int64) error {
+	createdAt, ok := r.sessions[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	session.ID = id
+	session.CreatedAt = createdAt
+	return nil
+}
+
+func newFraudUseCase(transactions *fraudTransactions, sessions *fraudSessions) *FraudUseCase {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return &FraudUseCase{
+		Log:                   log,
+		TransactionRepository: transactions,
+		SessionRepository:     sessions,
+	}
+}
+
+func TestFraudMatches(t *testing.T) {
+	const walletID = 42
+
+	transfer := func(amount int64, currency string) *domain.FraudCheck {
+		return &domain.FraudCheck{
+			WalletID:  walletID,
+			SessionID: 1,
+			DofNumber: "1234567890",
+			Amount:    amount,
+			Currency:  currency,
+		}
+	}
+	rule := func(ruleType, params string) *domain.FraudRuleEntity {
+		return &domain.FraudRuleEntity{Code: ruleType, Type: ruleType, Params: params}
+	}
+
+	tests := []struct {
+		name         string
+		rule         *domain.FraudRuleEntity
+		check        *domain.FraudCheck
+		transactions fraudTransactions
+		sessions     map[int64]time.Time
+		want         bool
+	}{
+		{
+			name:         "velocity under the limit",
+			rule:         rule(domain.FraudRuleVelocity, `{"max_count": 3, "window_minutes": 60}`),
+			check:        transfer(10_000, domain.DefaultCurrency),
+			transactions: fraudTransactions{outCount: 2},
+			want:         false,
+		},
+		{
+			name:         "velocity counts the transfer being made",
+			rule:         rule(domain.FraudRuleVelocity, `{"max_count": 3, "window_minutes": 60}`),
+			check:        transfer(10_000, "USD"),
+			transactions: fraudTransactions{outCount: 3},
+			want:         true,
+		},
+		{
+			name:  "new device without a session",
+			rule:  rule(domain.FraudRuleNewDeviceAmount, `{"min_amount": 5000000, "device_age_hours": 24}`),
+			check: transfer(5_000_000, domain.DefaultCurrency),
+			want:  true,
+		},
+		{
+			name:     "new device signed in recently",
+			rule:     rule(domain.FraudRuleNewDeviceAmount, `{"min_amount": 5000000, "device_age_hours": 24}`),
+			check:    transfer(5_000_000, domain.DefaultCurrency),
+			sessions: map[int64]time.Time{1: time.Now().Add(-time.Hour)},
+			want:     true,
+		},
+		{
+			name:     "known device",
+			rule:     rule(domain.FraudRuleNewDeviceAmount, `{"min_amount": 5000000, "device_age_hours": 24}`),
+			check:    transfer(5_000_000, domain.DefaultCurrency),
+			sessions: map[int64]time.Time{1: time.Now().Add(-48 * time.Hour)},
+			want:     false,
+		},
+		{
+			name:  "new device below the amount",
+			rule:  rule(domain.FraudRuleNewDeviceAmount, `{"min_amount": 5000000, "device_age_hours": 24}`),
+			check: transfer(4_999_999, domain.DefaultCurrency),
+			want:  false,
+		},
+		{
+			name:  "first transfer to a beneficiary",
+			rule:  rule(domain.FraudRuleNewBeneficiary, `{"min_amount": 1000000}`),
+			check: transfer(1_000_000, domain.DefaultCurrency),
+			want:  true,
+		},
+		{
+			name:         "known beneficiary",
+			rule:         rule(domain.FraudRuleNewBeneficiary, `{"min_amount": 1000000}`),
+			check:        transfer(1_000_000, domain.DefaultCurrency),
+			transactions: fraudTransactions{beneficiary: 1},
+			want:         false,
+		},
+		{
+			name:  "amount rule ignores other currencies",
+			rule:  rule(domain.FraudRuleNewBeneficiary, `{"min_amount": 1000000}`),
+			check: transfer(1_000_000, "USD"),
+			want:  false,
+		},
+		{
+			name:         "cash out of most of a top up",
+			rule:         rule(domain.FraudRuleTopUpCashOut, `{"window_minutes": 60, "min_percent": 80}`),
+			check:        transfer(800_000, domain.DefaultCurrency),
+			transactions: fraudTransactions{toppedUp: 1_000_000},
+			want:         true,
+		},
+		{
+			name:         "cash out of part of a top up",
+			rule:         rule(domain.FraudRuleTopUpCashOut, `{"window_minutes": 60, "min_percent": 80}`),
+			check:        transfer(799_999, domain.DefaultCurrency),
+			transactions: fraudTransactions{toppedUp: 1_000_000},
+			want:         false,
+		},
+		{
+			name:  "cash out without a top up",
+			rule:  rule(domain.FraudRuleTopUpCashOut, `{"window_minutes": 60, "min_percent": 80}`),
+			check: transfer(800_000, domain.DefaultCurrency),
+			want:  false,
+		},
+		{
+			name:         "unreadable params",
+			rule:         rule(domain.FraudRuleVelocity, `{"max_count": "three"}`),
+			check:        transfer(10_000, domain.DefaultCurrency),
+			transactions: fraudTransactions{outCount: 10},
+			want:         false,
+		},
+		{
+			name:  "unknown type",
+			rule:  rule("night_owl", `{}`),
+			check: transfer(10_000, domain.DefaultCurrency),
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions := tt.transactions
+			f := newFraudUseCase(&transactions, &fraudSessions{sessions: tt.sessions})
+
+			got, err := f.matches(nil, tt.rule, tt.check)
+			if err != nil {
+				t.Fatalf("matches() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+			for _, id := range transactions.walletIDs {
+				if id != walletID {
+					t.Errorf("matches() looked up wallet %d, want %d", id, walletID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+const kycMaxDocumentSize = 5 << 20
+
+// kycDocumentTypes maps the accepted image content types to the extension
+// the document is stored with.
+var kycDocumentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+type KYCUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	UserRepository          domain.UserRepository
+	KYCSubmissionRepository domain.KYCSubmissionRepository
+	AuditUseCase            domain.AuditUseCase
+	NotificationUseCase     domain.NotificationUseCase
+	ObjectStore             domain.ObjectStore
+	Validate                *validator.Validate
+}
+
+func NewKYCUseCase(db *gorm.DB, log *logrus.Logger, userRepository domain.UserRepository, kycSubmissionRepository domain.KYCSubmissionRepository, auditUseCase domain.AuditUseCase, notificationUseCase domain.NotificationUseCase, objectStore domain.ObjectStore, validate *validator.Validate) domain.KYCUseCase {
+	return &KYCUseCase{
+		DB:                      db,
+		Log:                     log,
+		UserRepository:          userRepository,
+		KYCSubmissionRepository: kycSubmissionRepository,
+		AuditUseCase:            auditUseCase,
+		NotificationUseCase:     notificationUseCase,
+		ObjectStore:             objectStore,
+		Validate:                validate,
+	}
+}
+
+// Submit implements domain.KYCUseCase.
+func (k *KYCUseCase) Submit(ctx context.Context, req *dto.SubmitKYCRequest, userID int64) (*dto.KYCSubmissionData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(k.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	nik, err := util.ParseNIK(req.NIK)
+	if err != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid NIK")
+	}
+
+	idCardExt, err := kycDocumentExtension(req.IDCard)
+	if err != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("ID card %s", err))
+	}
+	selfieExt, err := kycDocumentExtension(req.Selfie)
+	if err != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("Selfie %s", err))
+	}
+
+	tx := k.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	// Lock the user so concurrent submissions are serialised
+	user := new(domain.UserEntity)
+	if err := k.UserRepository.FindByIDForUpdate(tx, user, userID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if user.KYCLevel >= domain.KYCLevelVerified {
+		return nil, domain.NewError(fiber.StatusConflict, "Your identity is already verified")
+	}
+
+	count, err := k.KYCSubmissionRepository.CountPendingByUserID(tx, userID)
+	if err != nil {
+		k.Log.WithError(err).Warnf("Failed to count pending submissions: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if count > 0 {
+		return nil, domain.NewError(fiber.StatusConflict, "A submission is already waiting for review")
+	}
+
+	count, err = k.KYCSubmissionRepository.CountApprovedByNIK(tx, req.NIK)
+	if err != nil {
+		k.Log.WithError(err).Warnf("Failed to count approved submissions: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if count > 0 {
+		return nil, domain.NewError(fiber.StatusConflict, "The NIK is already registered to another account")
+	}
+
+	// Store the documents under unguessable keys
+	prefix := fmt.Sprintf("kyc/%d/%s", userID, util.GenerateUUID())
+	submission := &domain.KYCSubmissionEntity{
+		UserID:    userID,
+		NIK:       req.NIK,
+		FullName:  req.FullName,
+		BirthDate: nik.BirthDate,
+		IDCardKey: prefix + "-" + domain.KYCDocumentIDCard + idCardExt,
+		SelfieKey: prefix + "-" + domain.KYCDocumentSelfie + selfieExt,
+		Status:    domain.KYCStatusPending,
+	}
+
+	if err := k.ObjectStore.Put(c, submission.IDCardKey, req.IDCard); err != nil {
+		k.Log.WithError(err).Warnf("Failed to store ID card: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if err := k.ObjectStore.Put(c, submission.SelfieKey, req.Selfie); err != nil {
+		k.Log.WithError(err).Warnf("Failed to store selfie: %+v", err)
+		k.deleteDocuments(submission)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := k.createSubmission(c, tx, submission, req); err != nil {
+		// The documents are useless without the submission
+		k.deleteDocuments(submission)
+		return nil, err
+	}
+
+	return toKYCSubmissionData(submission), nil
+}
+
+// createSubmission saves the submission and its audit entry, then commits.
+func (k *KYCUseCase) createSubmission(ctx context.Context, tx *gorm.DB, submission *domain.KYCSubmissionEntity, req *dto.SubmitKYCRequest) error {
+	if err := k.KYCSubmissionRepository.Create(tx, submission); err != nil {
+		k.Log.WithError(err).Warnf("Failed to create submission: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := k.AuditUseCase.Record(ctx, tx, &domain.AuditEvent{
+		UserID:    submission.UserID,
+		ActorID:   submission.UserID,
+		Action:    domain.AuditKYCSubmitted,
+		Metadata:  map[string]any{"submission_id": submission.ID},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		k.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		k.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// deleteDocuments removes the stored documents of a submission that was not
+// saved. Failures only leave orphaned files behind, so they are logged.
+func (k *KYCUseCase) deleteDocuments(submission *domain.KYCSubmissionEntity) {
+	for _, key := range []string{submission.IDCardKey, submission.SelfieKey} {
+		if err := k.ObjectStore.Delete(context.Background(), key); err != nil {
+			k.Log.WithError(err).Warnf("Failed to delete document %s: %+v", key, err)
+		}
+	}
+}
+
+// GetStatus implements domain.KYCUseCase.
+func (k *KYCUseCase) GetStatus(ctx context.Context, userID int64) (*dto.KYCStatusResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := k.DB.WithContext(c)
+
+	user := new(domain.UserEntity)
+	if err := k.UserRepository.FindByID(tx, user, userID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	response := &dto.KYCStatusResponse{
+		Level: user.KYCLevel,
+	}
+
+	submission := new(domain.KYCSubmissionEntity)
+	err := k.KYCSubmissionRepository.FindLatestByUserID(tx, submission, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		k.Log.WithError(err).Warnf("Failed to query submission: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if err == nil {
+		response.Submission = toKYCSubmissionData(submission)
+	}
+
+	return response, nil
+}
+
+// FindAll implements domain.KYCUseCase.
+func (k *KYCUseCase) FindAll(ctx context.Context, req *dto.KYCListRequest) ([]dto.KYCSubmissionData, *dto.PageMetadata, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(k.Validate, req); len(validationErrors) > 0 {
+		return nil, nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	req.Page, req.Size = util.NormalizePage(req.Page, req.Size)
+
+	var submissions []domain.KYCSubmissionEntity
+	total, err := k.KYCSubmissionRepository.FindPage(k.DB.WithContext(c), &submissions, req.Status, req.Page, req.Size)
+	if err != nil {
+		k.Log.WithError(err).Warnf("Failed to query submissions: %+v", err)
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.KYCSubmissionData, 0, len(submissions))
+	for i := range submissions {
+		result = append(result, *toKYCSubmissionData(&submissions[i]))
+	}
+
+	return result, util.NewPageMetadata(req.Page, req.Size, total), nil
+}
+
+// FindByID implements domain.KYCUseCase.
+func (k *KYCUseCase) FindByID(ctx context.Context, submissionID int64) (*dto.KYCSubmissionData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	submission := new(domain.KYCSubmissionEntity)
+	if err := k.KYCSubmissionRepository.FindByID(k.DB.WithContext(c), submission, submissionID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "Submission not found")
+	}
+
+	return toKYCSubmissionData(submission), nil
+}
+
+// GetDocument implements domain.KYCUseCase.
+func (k *KYCUseCase) GetDocument(ctx context.Context, submissionID int64, document string) (*dto.KYCDocumentFile, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	submission := new(domain.KYCSubmissionEntity)
+	if err := k.KYCSubmissionRepository.FindByID(k.DB.WithContext(c), submission, submissionID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "Submission not found")
+	}
+
+	var key string
+	switch document {
+	case domain.KYCDocumentIDCard:
+		key = submission.IDCardKey
+	case domain.KYCDocumentSelfie:
+		key = submission.SelfieKey
+	default:
+		return nil, domain.NewError(fiber.StatusNotFound, "Document not found")
+	}
+
+	content, err := k.ObjectStore.Get(c, key)
+	if errors.Is(err, domain.ErrObjectNotFound) {
+		return nil, domain.NewError(fiber.StatusNotFound, "Document not found")
+	}
+	if err != nil {
+		k.Log.WithError(err).Warnf("Failed to read document: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return &dto.KYCDocumentFile{
+		ContentType: http.DetectContentType(content),
+		Content:     content,
+	}, nil
+}
+
+// Approve implements domain.KYCUseCase.
+func (k *KYCUseCase) Approve(ctx context.Context, req *dto.ReviewKYCRequest, submissionID int64, reviewerID int64) error {
+	return k.review(ctx, req, submissionID, reviewerID, domain.KYCStatusApproved)
+}
+
+// Reject implements domain.KYCUseCase.
+func (k *KYCUseCase) Reject(ctx context.Context, req *dto.ReviewKYCRequest, submissionID int64, reviewerID int64) error {
+	if req.Reason == "" {
+		return domain.NewError(fiber.StatusBadRequest, "A reason is required to reject a submission")
+	}
+	return k.review(ctx, req, submissionID, reviewerID, domain.KYCStatusRejected)
+}
+
+// review moves a pending submission to its final status. An approval also
+// raises the user's KYC level.
+func (k *KYCUseCase) review(ctx context.Context, req *dto.ReviewKYCRequest, submissionID int64, reviewerID int64, status string) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(k.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := k.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	// Lock the submission so two reviewers cannot decide it at once
+	submission := new(domain.KYCSubmissionEntity)
+	if err := k.KYCSubmissionRepository.FindByIDForUpdate(tx, submission, submissionID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "Submission not found")
+	}
+
+	if submission.Status != domain.KYCStatusPending {
+		return domain.NewError(fiber.StatusConflict, "The submission has already been reviewed")
+	}
+	if submission.UserID == reviewerID {
+		return domain.NewError(fiber.StatusForbidden, "You cannot review your own submission")
+	}
+
+	user := new(domain.UserEntity)
+	if err := k.UserRepository.FindByIDForUpdate(tx, user, submission.UserID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	action := domain.AuditKYCRejected
+	if status == domain.KYCStatusApproved {
+		action = domain.AuditKYCApproved
+
+		count, err := k.KYCSubmissionRepository.CountApprovedByNIK(tx, submission.NIK)
+		if err != nil {
+			k.Log.WithError(err).Warnf("Failed to count approved submissions: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+		if count > 0 {
+			return domain.NewError(fiber.StatusConflict, "The NIK is already registered to another account")
+		}
+
+		user.KYCLevel = domain.KYCLevelVerified
+		if err := k.UserRepository.Update(tx, user); err != nil {
+			k.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	now := time.Now()
+	submission.Status = status
+	submission.RejectionReason = req.Reason
+	submission.ReviewedBy = &reviewerID
+	submission.ReviewedAt = &now
+	if err := k.KYCSubmissionRepository.Update(tx, submission); err != nil {
+		k.Log.WithError(err).Warnf("Failed to save submission: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := k.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:  submission.UserID,
+		ActorID: reviewerID,
+		Action:  action,
+		Metadata: map[string]any{
+			"submission_id": submission.ID,
+			"reason":        req.Reason,
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		k.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		k.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	event := domain.NotificationKYCRejected
+	if status == domain.KYCStatusApproved {
+		event = domain.NotificationKYCApproved
+	}
+	if err := k.NotificationUseCase.Notify(c, submission.UserID, event, map[string]any{
+		"Reason": req.Reason,
+	}); err != nil {
+		k.Log.WithError(err).Warn("Failed to create KYC notification")
+	}
+
+	return nil
+}
+
+// kycDocumentExtension checks that an uploaded document is a JPEG or PNG
+// image within the size limit, judging by its content rather than the
+// client's claims.
+func kycDocumentExtension(content []byte) (string, error) {
+	if len(content) > kycMaxDocumentSize {
+		return "", errors.New("must not be larger than 5 MB")
+	}
+
+	ext, ok := kycDocumentTypes[http.DetectContentType(content)]
+	if !ok {
+		return "", errors.New("must be a JPEG or PNG image")
+	}
+	return ext, nil
+}
+
+func toKYCSubmissionData(submission *domain.KYCSubmissionEntity) *dto.KYCSubmissionData {
+	return &dto.KYCSubmissionData{
+		ID:              submission.ID,
+		UserID:          submission.UserID,
+		NIK:             submission.NIK,
+		FullName:        submission.FullName,
+		BirthDate:       submission.BirthDate.Format(time.DateOnly),
+		Status:          submission.Status,
+		RejectionReason: submission.RejectionReason,
+		ReviewedBy:      submission.ReviewedBy,
+		ReviewedAt:      formatOptionalTime(submission.ReviewedAt),
+		CreatedAt:       submission.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       submission.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -10,21 +12,38 @@ import (
 	"gorm.io/gorm"
 	"riz.it/domped/app/domain"
 	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
 )
 
+// maxPushFailures is the number of consecutive delivery failures after which
+// a device token is considered dead and pruned.
+const maxPushFailures = 5
+
 type NotificationUseCase struct {
-	DB                     *gorm.DB
-	Log                    *logrus.Logger
-	NotificationRepository domain.NotificationRepository
-	Validate               *validator.Validate
+	DB                               *gorm.DB
+	Log                              *logrus.Logger
+	NotificationRepository           domain.NotificationRepository
+	NotificationPreferenceRepository domain.NotificationPreferenceRepository
+	DeviceTokenRepository            domain.DeviceTokenRepository
+	UserRepository                   domain.UserRepository
+	PushUtil                         domain.Push
+	Email                            domain.Email
+	Template                         domain.Template
+	Validate                         *validator.Validate
 }
 
-func NewNotificationUseCase(db *gorm.DB, log *logrus.Logger, notificationRepository domain.NotificationRepository, validate *validator.Validate) domain.NotificationUseCase {
+func NewNotificationUseCase(db *gorm.DB, log *logrus.Logger, notificationRepository domain.NotificationRepository, notificationPreferenceRepository domain.NotificationPreferenceRepository, deviceTokenRepository domain.DeviceTokenRepository, userRepository domain.UserRepository, pushUtil domain.Push, email domain.Email, template domain.Template, validate *validator.Validate) domain.NotificationUseCase {
 	return &NotificationUseCase{
-		DB:                     db,
-		Log:                    log,
-		NotificationRepository: notificationRepository,
-		Validate:               validate,
+		DB:                               db,
+		Log:                              log,
+		NotificationRepository:           notificationRepository,
+		NotificationPreferenceRepository: notificationPreferenceRepository,
+		DeviceTokenRepository:            deviceTokenRepository,
+		UserRepository:                   userRepository,
+		PushUtil:                         pushUtil,
+		Email:                            email,
+		Template:                         template,
+		Validate:                         validate,
 	}
 }
 
@@ -68,3 +87,266 @@ func (n *NotificationUseCase) FindByUserID(ctx context.Context, userID int64) (*
 
 	return &result, nil
 }
+
+// GetPreferences implements domain.NotificationUseCase.
+func (n *NotificationUseCase) GetPreferences(ctx context.Context, userID int64) (*[]dto.NotificationPreferenceData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	preferences, err := n.findPreferences(n.DB.WithContext(c), userID)
+	if err != nil {
+		n.Log.WithError(err).Warn("Failed to query notification preferences")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return toNotificationPreferenceData(preferences), nil
+}
+
+// UpdatePreferences implements domain.NotificationUseCase.
+func (n *NotificationUseCase) UpdatePreferences(ctx context.Context, req *dto.UpdateNotificationPreferencesRequest, userID int64) (*[]dto.NotificationPreferenceData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(n.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	// Mandatory event types cannot have any channel turned off
+	for _, p := range req.Preferences {
+		if domain.IsMandatoryNotification(p.EventType) && !(p.InApp && p.Email && p.Push) {
+			return nil, domain.NewError(fiber.StatusBadRequest, "Security alerts cannot be disabled")
+		}
+	}
+
+	tx := n.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	preferences, err := n.findPreferences(tx, userID)
+	if err != nil {
+		n.Log.WithError(err).Warn("Failed to query notification preferences")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	for _, p := range req.Preferences {
+		for i := range preferences {
+			preference := &preferences[i]
+			if preference.EventType != p.EventType {
+				continue
+			}
+
+			preference.InApp = p.InApp
+			preference.Email = p.Email
+			preference.Push = p.Push
+
+			if preference.ID == 0 {
+				err = n.NotificationPreferenceRepository.Create(tx, preference)
+			} else {
+				err = n.NotificationPreferenceRepository.Update(tx, preference)
+			}
+			if err != nil {
+				n.Log.WithError(err).Warn("Failed to save notification preference")
+				return nil, domain.NewError(fiber.StatusInternalServerError)
+			}
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		n.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return toNotificationPreferenceData(preferences), nil
+}
+
+// Notify implements domain.NotificationUseCase. The notification copy is
+// rendered from the event template in the user's language and delivered on
+// the channels the user has enabled for the event type.
+func (n *NotificationUseCase) Notify(ctx context.Context, userID int64, event string, data any) error {
+	tx := n.DB.WithContext(ctx)
+
+	// Retrieve the user to resolve their language and email address
+	user := new(domain.UserEntity)
+	if err := n.UserRepository.FindByID(tx, user, userID); err != nil {
+		return err
+	}
+
+	preference, err := n.findPreference(tx, userID, domain.NotificationEventType(event))
+	if err != nil {
+		return err
+	}
+
+	content, err := n.Template.RenderNotification(user.Language, event, data)
+	if err != nil {
+		return err
+	}
+
+	notification := domain.NotificationEntity{
+		UserID: userID,
+		Title:  content.Title,
+		Body:   content.Body,
+		IsRead: false,
+		Status: 1,
+	}
+
+	if preference.InApp {
+		if err := n.NotificationRepository.Create(tx, &notification); err != nil {
+			return err
+		}
+	}
+
+	if preference.Push {
+		n.Push(notification)
+	}
+
+	if preference.Email {
+		go n.emailNotification(*user, notification)
+	}
+
+	return nil
+}
+
+// Push implements domain.NotificationUseCase. Delivery runs in the background
+// so callers are never blocked by the push provider.
+func (n *NotificationUseCase) Push(notifications ...domain.NotificationEntity) {
+	go func() {
+		c, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, notification := range notifications {
+			n.pushToDevices(c, notification)
+		}
+	}()
+}
+
+func (n *NotificationUseCase) pushToDevices(c context.Context, notification domain.NotificationEntity) {
+	tx := n.DB.WithContext(c)
+
+	tokens := new([]domain.DeviceTokenEntity)
+	if err := n.DeviceTokenRepository.FindByUserID(tx, tokens, notification.UserID); err != nil {
+		n.Log.WithError(err).Warn("Failed to query device tokens")
+		return
+	}
+
+	message := &domain.PushMessage{
+		Title: notification.Title,
+		Body:  notification.Body,
+		Data:  map[string]string{},
+	}
+
+	// Notifications with in-app delivery disabled are never stored
+	if notification.ID != 0 {
+		message.Data["notification_id"] = strconv.FormatInt(notification.ID, 10)
+	}
+
+	for _, token := range *tokens {
+		err := n.PushUtil.Send(c, token.Token, message)
+		now := time.Now()
+
+		switch {
+		case err == nil:
+			token.FailureCount = 0
+			token.LastError = ""
+			token.LastSuccessAt = &now
+		case errors.Is(err, domain.ErrInvalidPushToken):
+			n.pruneDeviceToken(tx, &token)
+			continue
+		default:
+			n.Log.WithError(err).WithField("device_token_id", token.ID).Warn("Failed to push notification")
+			token.FailureCount++
+			token.LastError = err.Error()
+			token.LastFailureAt = &now
+			if token.FailureCount >= maxPushFailures {
+				n.pruneDeviceToken(tx, &token)
+				continue
+			}
+		}
+
+		if err := n.DeviceTokenRepository.Update(tx, &token); err != nil {
+			n.Log.WithError(err).Warn("Failed to update device token")
+		}
+	}
+}
+
+func (n *NotificationUseCase) pruneDeviceToken(tx *gorm.DB, token *domain.DeviceTokenEntity) {
+	n.Log.WithField("device_token_id", token.ID).Info("Pruning device token")
+	if err := n.DeviceTokenRepository.Delete(tx, token); err != nil {
+		n.Log.WithError(err).Warn("Failed to delete device token")
+	}
+}
+
+func (n *NotificationUseCase) emailNotification(user domain.UserEntity, notification domain.NotificationEntity) {
+	email, err := n.Template.RenderEmail(user.Language, "notification", map[string]any{
+		"Name":  user.FullName,
+		"Title": notification.Title,
+		"Body":  notification.Body,
+	})
+	if err != nil {
+		n.Log.WithError(err).Warn("Failed to render notification email")
+		return
+	}
+
+	if err := n.Email.Send(context.Background(), &domain.EmailMessage{
+		To:      []string{user.Email},
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	}); err != nil {
+		n.Log.WithError(err).Warn("Failed to send notification email")
+	}
+}
+
+// findPreferences returns the user's preference for every event type,
+// filling in defaults for the ones that have never been configured.
+func (n *NotificationUseCase) findPreferences(tx *gorm.DB, userID int64) ([]domain.NotificationPreferenceEntity, error) {
+	stored := new([]domain.NotificationPreferenceEntity)
+	if err := n.NotificationPreferenceRepository.FindByUserID(tx, stored, userID); err != nil {
+		return nil, err
+	}
+
+	byType := make(map[string]domain.NotificationPreferenceEntity, len(*stored))
+	for _, p := range *stored {
+		byType[p.EventType] = p
+	}
+
+	preferences := make([]domain.NotificationPreferenceEntity, 0, len(domain.NotificationEventTypes))
+	for _, eventType := range domain.NotificationEventTypes {
+		if p, ok := byType[eventType]; ok {
+			preferences = append(preferences, p)
+			continue
+		}
+		preferences = append(preferences, domain.DefaultNotificationPreference(userID, eventType))
+	}
+
+	return preferences, nil
+}
+
+func (n *NotificationUseCase) findPreference(tx *gorm.DB, userID int64, eventType string) (*domain.NotificationPreferenceEntity, error) {
+	preference := domain.DefaultNotificationPreference(userID, eventType)
+	if domain.IsMandatoryNotification(eventType) {
+		return &preference, nil
+	}
+
+	err := n.NotificationPreferenceRepository.FindByUserIDAndEventType(tx, &preference, userID, eventType)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return &preference, nil
+}
+
+func toNotificationPreferenceData(preferences []domain.NotificationPreferenceEntity) *[]dto.NotificationPreferenceData {
+	result := make([]dto.NotificationPreferenceData, 0, len(preferences))
+	for _, p := range preferences {
+		result = append(result, dto.NotificationPreferenceData{
+			EventType: p.EventType,
+			InApp:     p.InApp,
+			Email:     p.Email,
+			Push:      p.Push,
+			Mandatory: domain.IsMandatoryNotification(p.EventType),
+		})
+	}
+	return &result
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -19,15 +20,21 @@ type PinRecoveryUseCase struct {
 	Log                   *logrus.Logger
 	WalletRepository      domain.WalletRepository
 	PinRecoveryRepository domain.PinRecoveryRepository
+	NotificationUseCase   domain.NotificationUseCase
+	AuditUseCase          domain.AuditUseCase
+	TokenDenylist         domain.TokenDenylist
 	Validate              *validator.Validate
 }
 
-func NewPinRecoveryUseCase(db *gorm.DB, log *logrus.Logger, walletRepository domain.WalletRepository, pinRecoveryRepository domain.PinRecoveryRepository, validate *validator.Validate) domain.PinRecoveryUseCase {
+func NewPinRecoveryUseCase(db *gorm.DB, log *logrus.Logger, walletRepository domain.WalletRepository, pinRecoveryRepository domain.PinRecoveryRepository, notificationUseCase domain.NotificationUseCase, auditUseCase domain.AuditUseCase, tokenDenylist domain.TokenDenylist, validate *validator.Validate) domain.PinRecoveryUseCase {
 	return &PinRecoveryUseCase{
 		DB:                    db,
 		Log:                   log,
 		WalletRepository:      walletRepository,
 		PinRecoveryRepository: pinRecoveryRepository,
+		NotificationUseCase:   notificationUseCase,
+		AuditUseCase:          auditUseCase,
+		TokenDenylist:         tokenDenylist,
 		Validate:              validate,
 	}
 }
@@ -86,18 +93,48 @@ func (p *PinRecoveryUseCase) SetupWalletPIN(ctx context.Context, req *dto.SetupW
 	}
 
 	// Update pin code
+	changed := wallet.WalletPin != ""
 	wallet.WalletPin = hashedPin
 	if err := p.WalletRepository.Update(tx, wallet); err != nil {
 		p.Log.WithError(err).Warnf("Failed to update wallet pin: %+v", err)
 		return domain.NewError(fiber.StatusInternalServerError)
 	}
 
+	// The PIN itself is never logged, only whether one was set
+	if err := p.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    userID,
+		Action:     domain.AuditPinChanged,
+		TargetType: domain.AuditTargetWallet,
+		TargetID:   strconv.FormatInt(wallet.ID, 10),
+		Before:     map[string]any{"pin_set": changed},
+		After:      map[string]any{"pin_set": true},
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		p.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
 		p.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
 		return domain.NewError(fiber.StatusInternalServerError)
 	}
 
+	// Changing an existing PIN signs out outstanding access tokens, so
+	// every device has to refresh before using the wallet again
+	if changed {
+		if err := p.TokenDenylist.DenyUserTokensBefore(c, userID, time.Now()); err != nil {
+			p.Log.WithError(err).Warn("Failed to denylist access tokens")
+		}
+	}
+
+	// Alert the owner that the PIN was changed
+	if err := p.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityPinChanged, nil); err != nil {
+		p.Log.WithError(err).Warn("Failed to create PIN change notification")
+	}
+
 	// Return nil error
 	return nil
 }
@@ -0,0 +1,437 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+const (
+	statementPeriodFormat = "2006-01"
+
+	// Wallets loaded at a time by the monthly batch
+	statementBatchSize = 100
+)
+
+type StatementUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	Config                  *config.Config
+	UserRepository          domain.UserRepository
+	WalletRepository        domain.WalletRepository
+	WalletBalanceRepository domain.WalletBalanceRepository
+	TransactionRepository   domain.TransactionRepository
+	EmailUtil               domain.Email
+	Template                domain.Template
+	Validate                *validator.Validate
+	Redis                   *redis.Client
+}
+
+func NewStatementUseCase(db *gorm.DB, log *logrus.Logger, config *config.Config, userRepository domain.UserRepository, walletRepository domain.WalletRepository, walletBalanceRepository domain.WalletBalanceRepository, transactionRepository domain.TransactionRepository, emailUtil domain.Email, template domain.Template, validate *validator.Validate, redis *redis.Client) domain.StatementUseCase {
+	return &StatementUseCase{
+		DB:                      db,
+		Log:                     log,
+		Config:                  config,
+		UserRepository:          userRepository,
+		WalletRepository:        walletRepository,
+		WalletBalanceRepository: walletBalanceRepository,
+		TransactionRepository:   transactionRepository,
+		EmailUtil:               emailUtil,
+		Template:                template,
+		Validate:                validate,
+		Redis:                   redis,
+	}
+}
+
+// Generate implements domain.StatementUseCase.
+func (s *StatementUseCase) Generate(ctx context.Context, req *dto.StatementRequest, userID int64) (*dto.StatementFile, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	start, err := s.validate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the wallet based on userID
+	wallet := new(domain.WalletEntity)
+	if err := s.WalletRepository.FindByUserID(s.DB.WithContext(c), wallet, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusNotFound, "Wallet not found")
+		}
+		s.Log.WithError(err).Warn("Failed to query wallet")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	balance, err := s.findBalance(s.DB.WithContext(c), wallet, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.render(s.DB.WithContext(c), wallet, balance, start, req.Format)
+	if err != nil {
+		s.Log.WithError(err).Error("Failed to generate statement")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return file, nil
+}
+
+// Email implements domain.StatementUseCase.
+func (s *StatementUseCase) Email(ctx context.Context, req *dto.StatementRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	start, err := s.validate(req)
+	if err != nil {
+		return err
+	}
+
+	tx := s.DB.WithContext(c)
+
+	// Retrieve the wallet based on userID
+	wallet := new(domain.WalletEntity)
+	if err := s.WalletRepository.FindByUserID(tx, wallet, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.NewError(fiber.StatusNotFound, "Wallet not found")
+		}
+		s.Log.WithError(err).Warn("Failed to query wallet")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	balance, err := s.findBalance(tx, wallet, req.Currency)
+	if err != nil {
+		return err
+	}
+
+	if err := s.emailStatement(c, tx, wallet, balance, start, req.Format); err != nil {
+		s.Log.WithError(err).Error("Failed to email statement")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// SendMonthlyStatements implements domain.StatementUseCase. It emails last
+// month's PDF statement of the default currency balance to every active
+// user. Each wallet is claimed for a few minutes before its statement is
+// sent, kept for the period once sent and released when sending fails, so
+// every run picks up what failed or crashed in the previous ones, and
+// instances running at once never send the same statement. The period is
+// marked done once a run gets through every wallet.
+func (s *StatementUseCase) SendMonthlyStatements(ctx context.Context) {
+	if !s.Config.Statement.BatchEnabled {
+		return
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+	period := start.Format(statementPeriodFormat)
+	batchKey := "statement:batch:" + period
+
+	done, err := s.Redis.Exists(ctx, batchKey).Result()
+	if err != nil {
+		s.Log.WithError(err).Warn("Failed to check statement batch")
+		return
+	}
+	if done > 0 {
+		return
+	}
+
+	s.Log.WithField("period", period).Info("Sending monthly statements")
+
+	tx := s.DB.WithContext(ctx)
+
+	sent, failed := 0, 0
+	var afterID int64
+	for {
+		// Pockets get no statement of their own; moves in and out of them
+		// show on the main wallet's
+		wallets := new([]domain.WalletEntity)
+		if err := s.WalletRepository.FindMainAfterID(tx, wallets, afterID, statementBatchSize); err != nil {
+			s.Log.WithError(err).Error("Failed to query wallets")
+			return
+		}
+
+		for _, wallet := range *wallets {
+			if ctx.Err() != nil {
+				return
+			}
+			afterID = wallet.ID
+
+			claimKey := batchKey + ":" + strconv.FormatInt(wallet.ID, 10)
+			claimed, err := s.Redis.SetNX(ctx, claimKey, now.Unix(), 10*time.Minute).Result()
+			if err != nil {
+				s.Log.WithError(err).WithField("wallet_id", wallet.ID).Warn("Failed to claim monthly statement")
+				failed++
+				continue
+			}
+			if !claimed {
+				continue
+			}
+
+			if err := s.sendMonthlyStatement(ctx, tx, &wallet, start); err != nil {
+				s.Log.WithError(err).WithField("wallet_id", wallet.ID).Warn("Failed to email monthly statement")
+				if err := s.Redis.Del(context.Background(), claimKey).Err(); err != nil {
+					s.Log.WithError(err).WithField("wallet_id", wallet.ID).Warn("Failed to release monthly statement")
+				}
+				failed++
+				continue
+			}
+			if err := s.Redis.Expire(ctx, claimKey, 45*24*time.Hour).Err(); err != nil {
+				s.Log.WithError(err).WithField("wallet_id", wallet.ID).Warn("Failed to record monthly statement")
+			}
+			sent++
+		}
+
+		if len(*wallets) < statementBatchSize {
+			break
+		}
+	}
+
+	// Wallets that failed are tried again on the next run
+	if failed == 0 {
+		if err := s.Redis.Set(ctx, batchKey, now.Unix(), 45*24*time.Hour).Err(); err != nil {
+			s.Log.WithError(err).Warn("Failed to mark statement batch done")
+		}
+	}
+
+	s.Log.WithFields(logrus.Fields{
+		"period": period,
+		"sent":   sent,
+		"failed": failed,
+	}).Info("Monthly statements sent")
+}
+
+func (s *StatementUseCase) sendMonthlyStatement(ctx context.Context, tx *gorm.DB, wallet *domain.WalletEntity, start time.Time) error {
+	balance := new(domain.WalletBalanceEntity)
+	if err := s.WalletBalanceRepository.FindByWalletIDAndCurrency(tx, balance, wallet.ID, domain.DefaultCurrency); err != nil {
+		return err
+	}
+
+	return s.emailStatement(ctx, tx, wallet, balance, start, "pdf")
+}
+
+func (s *StatementUseCase) validate(req *dto.StatementRequest) (time.Time, error) {
+	// Validate the incoming request data
+	if validationErrors := util.Validate(s.Validate, req); len(validationErrors) > 0 {
+		return time.Time{}, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	start, err := time.ParseInLocation(statementPeriodFormat, req.Period, time.Local)
+	if err != nil {
+		return time.Time{}, domain.NewError(fiber.StatusBadRequest, "Invalid period")
+	}
+
+	if start.After(time.Now()) {
+		return time.Time{}, domain.NewError(fiber.StatusBadRequest, "Statement period has not started yet")
+	}
+
+	if req.Currency == "" {
+		req.Currency = domain.DefaultCurrency
+	}
+	if _, ok := domain.FindCurrency(req.Currency); !ok {
+		return time.Time{}, domain.NewError(fiber.StatusBadRequest, "Currency is not supported")
+	}
+
+	return start, nil
+}
+
+func (s *StatementUseCase) findBalance(tx *gorm.DB, wallet *domain.WalletEntity, currency string) (*domain.WalletBalanceEntity, error) {
+	balance := new(domain.WalletBalanceEntity)
+	if err := s.WalletBalanceRepository.FindByWalletIDAndCurrency(tx, balance, wallet.ID, currency); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusNotFound, fmt.Sprintf("You do not hold a %s balance", currency))
+		}
+		s.Log.WithError(err).Warn("Failed to query wallet balance")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	return balance, nil
+}
+
+func (s *StatementUseCase) emailStatement(ctx context.Context, tx *gorm.DB, wallet *domain.WalletEntity, balance *domain.WalletBalanceEntity, start time.Time, format string) error {
+	user := new(domain.UserEntity)
+	if err := s.UserRepository.FindByID(tx, user, wallet.UserID); err != nil {
+		return err
+	}
+
+	if !user.IsActive {
+		return nil
+	}
+
+	file, err := s.render(tx, wallet, balance, start, format)
+	if err != nil {
+		return err
+	}
+
+	email, err := s.Template.RenderEmail(user.Language, "statement", map[string]any{
+		"Name":         user.FullName,
+		"WalletNumber": wallet.WalletNumber,
+		"Period":       start.Format(statementPeriodFormat),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.EmailUtil.Send(ctx, &domain.EmailMessage{
+		To:      []string{user.Email},
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+		Attachments: []domain.EmailAttachment{
+			{
+				Filename:    file.Filename,
+				ContentType: file.ContentType,
+				Content:     file.Content,
+			},
+		},
+	})
+}
+
+func (s *StatementUseCase) render(tx *gorm.DB, wallet *domain.WalletEntity, balance *domain.WalletBalanceEntity, start time.Time, format string) (*dto.StatementFile, error) {
+	statement, err := s.build(tx, wallet, balance, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	filename := "statement-" + wallet.WalletNumber + "-" + balance.Currency + "-" + start.Format(statementPeriodFormat)
+
+	if format == "csv" {
+		content, err := util.RenderStatementCSV(statement)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.StatementFile{
+			Filename:    filename + ".csv",
+			ContentType: "text/csv",
+			Content:     content,
+		}, nil
+	}
+
+	return &dto.StatementFile{
+		Filename:    filename + ".pdf",
+		ContentType: "application/pdf",
+		Content:     util.RenderStatementPDF(statement),
+	}, nil
+}
+
+// build reconstructs the movements of one currency balance between start
+// and end. The opening balance is derived from the current balance by
+// undoing every movement since the start of the period.
+func (s *StatementUseCase) build(tx *gorm.DB, wallet *domain.WalletEntity, current *domain.WalletBalanceEntity, start, end time.Time) (*domain.Statement, error) {
+	netSinceStart, err := s.TransactionRepository.SumNetByWalletIDSince(tx, wallet.ID, current.Currency, start)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := new([]domain.TransactionEntity)
+	if err := s.TransactionRepository.FindByWalletIDBetween(tx, transactions, wallet.ID, current.Currency, start, end); err != nil {
+		return nil, err
+	}
+
+	owner := new(domain.UserEntity)
+	if err := s.UserRepository.FindByID(tx, owner, wallet.UserID); err != nil {
+		return nil, err
+	}
+
+	// Resolve counterparty names in one query
+	numbers := []string{}
+	for _, t := range *transactions {
+		numbers = append(numbers, t.SofNumber, t.DofNumber)
+	}
+	names := map[string]string{}
+	if len(numbers) > 0 {
+		counterparties := new([]domain.WalletEntity)
+		if err := s.WalletRepository.FindByWalletNumbers(tx, counterparties, numbers); err != nil {
+			return nil, err
+		}
+		for _, w := range *counterparties {
+			switch {
+			case w.IsPocket():
+				names[w.WalletNumber] = w.Name
+			case w.User != nil:
+				names[w.WalletNumber] = w.User.FullName
+			}
+		}
+	}
+
+	statement := &domain.Statement{
+		WalletNumber:   wallet.WalletNumber,
+		OwnerName:      owner.FullName,
+		Currency:       current.Currency,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: current.Balance - netSinceStart,
+		GeneratedAt:    time.Now(),
+	}
+
+	balance := statement.OpeningBalance
+	for _, t := range *transactions {
+		entry := domain.StatementEntry{
+			TransactionID: t.ID,
+			TransactionAt: t.TransactionAt,
+			Amount:        t.Amount,
+		}
+
+		switch {
+		case t.TransactionType == domain.TransactionIn && t.SofNumber == domain.TopUpSofNumber:
+			entry.Direction = domain.StatementIn
+			entry.Description = "Top up"
+			entry.Counterparty = t.SofNumber
+		case t.TransactionType == domain.TransactionIn && t.SofNumber == domain.ConversionNumber:
+			entry.Direction = domain.StatementIn
+			entry.Description = "Conversion in"
+			entry.Counterparty = t.SofNumber
+		case t.TransactionType == domain.TransactionIn && t.SofNumber == domain.PocketMoveNumber:
+			entry.Direction = domain.StatementIn
+			entry.Description = "Pocket move in"
+			entry.Counterparty = t.SofNumber
+		case t.TransactionType == domain.TransactionIn:
+			entry.Direction = domain.StatementIn
+			entry.Description = "Transfer in"
+			entry.Counterparty = t.SofNumber
+		case t.DofNumber == domain.ConversionNumber:
+			entry.Direction = domain.StatementOut
+			entry.Description = "Conversion out"
+			entry.Counterparty = t.DofNumber
+		case t.DofNumber == domain.PocketMoveNumber:
+			entry.Direction = domain.StatementOut
+			entry.Description = "Pocket move out"
+			entry.Counterparty = t.DofNumber
+		default:
+			entry.Direction = domain.StatementOut
+			entry.Description = "Transfer out"
+			entry.Counterparty = t.DofNumber
+		}
+		entry.CounterpartyName = names[entry.Counterparty]
+
+		if entry.Direction == domain.StatementIn {
+			balance += t.Amount
+			statement.TotalIn += t.Amount
+		} else {
+			balance -= t.Amount
+			statement.TotalOut += t.Amount
+		}
+		entry.Balance = balance
+
+		statement.Entries = append(statement.Entries, entry)
+	}
+
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"riz.it/domped/app/domain"
+)
+
+type statementTransactions struct {
+	domain.TransactionRepository
+	netSinceStart int64
+	period        []domain.TransactionEntity
+}
+
+func (r *statementTransactions) SumNetByWalletIDSince(db *gorm.DB, walletID int64, currency string, since time.Time) (int64, error) {
+	return r.netSinceStart, nil
+}
+
+func (r *statementTransactions) FindByWalletIDBetween(db *gorm.DB, transactions *[]domain.TransactionEntity, walletID int64, currency string, from, to time.Time) error {
+	*transactions = r.period
+	return nil
+}
+
+type statementUsers struct {
+	domain.UserRepository
+}
+
+func (r *statementUsers) FindByID(db *gorm.DB, user *domain.UserEntity, id int64) error {
+	user.ID = id
+	user.FullName = "Budi Santoso"
+	return nil
+}
+
+type statementWallets struct {
+	domain.WalletRepository
+}
+
+func (r *statementWallets) FindByWalletNumbers(db *gorm.DB, wallets *[]domain.WalletEntity, walletNumbers []string) error {
+	*wallets = []domain.WalletEntity{
+		{WalletNumber: "2000000001", User: &domain.UserEntity{FullName: "Siti Rahayu"}},
+	}
+	return nil
+}
+
+func TestStatementBuildBalances(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	// The wallet holds 900 now. Since the start of January it took in 500
+	// and paid out 150 (the last 50 of it in February), so it opened the
+	// month with 550.
+	transactions := &statementTransactions{
+		netSinceStart: 350,
+		period: []domain.TransactionEntity{
+			{ID: 1, SofNumber: domain.TopUpSofNumber, DofNumber: "1000000001", Amount: 300, TransactionType: domain.TransactionIn, TransactionAt: start.Add(time.Hour)},
+			{ID: 2, SofNumber: "1000000001", DofNumber: "2000000001", Amount: 100, TransactionType: domain.TransactionOut, TransactionAt: start.Add(2 * time.Hour)},
+			{ID: 3, SofNumber: "2000000001", DofNumber: "1000000001", Amount: 200, TransactionType: domain.TransactionIn, TransactionAt: start.Add(3 * time.Hour)},
+		},
+	}
+	s := &StatementUseCase{
+		UserRepository:        &statementUsers{},
+		WalletRepository:      &statementWallets{},
+		TransactionRepository: transactions,
+	}
+
+	wallet := &domain.WalletEntity{ID: 1, UserID: 7, WalletNumber: "1000000001"}
+	current := &domain.WalletBalanceEntity{WalletID: 1, Currency: domain.DefaultCurrency, Balance: 900}
+
+	statement, err := s.build(nil, wallet, current, start, end)
+	if err != nil {
+		t.Fatalf("build() error = %v", err)
+	}
+
+	if statement.OpeningBalance != 550 {
+		t.Errorf("OpeningBalance = %d, want 550", statement.OpeningBalance)
+	}
+	if statement.ClosingBalance != 950 {
+		t.Errorf("ClosingBalance = %d, want 950", statement.ClosingBalance)
+	}
+	if statement.TotalIn != 500 || statement.TotalOut != 100 {
+		t.Errorf("TotalIn, TotalOut = %d, %d, want 500, 100", statement.TotalIn, statement.TotalOut)
+	}
+	if statement.OwnerName != "Budi Santoso" {
+		t.Errorf("OwnerName = %q, want %q", statement.OwnerName, "Budi Santoso")
+	}
+
+	want := []struct {
+		direction    string
+		counterparty string
+		name         string
+		balance      int64
+	}{
+		{domain.StatementIn, domain.TopUpSofNumber, "", 850},
+		{domain.StatementOut, "2000000001", "Siti Rahayu", 750},
+		{domain.StatementIn, "2000000001", "Siti Rahayu", 950},
+	}
+	if len(statement.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(statement.Entries), len(want))
+	}
+	for i, w := range want {
+		got := statement.Entries[i]
+		if got.Direction != w.direction || got.Counterparty != w.counterparty || got.CounterpartyName != w.name || got.Balance != w.balance {
+			t.Errorf("entry %d = %s %s %q balance %d, want %s %s %q balance %d",
+				i, got.Direction, got.Counterparty, got.CounterpartyName, got.Balance,
+				w.direction, w.counterparty, w.name, w.balance)
+		}
+	}
+}
@@ -3,39 +3,43 @@ package usecase
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"riz.it/domped/app/domain"
 	"riz.it/domped/app/dto"
 	"riz.it/domped/app/util"
 )
 
 type TopUpUseCase struct {
-	DB                     *gorm.DB
-	Log                    *logrus.Logger
-	NotificationRepository domain.NotificationRepository
-	MidtransUtil           domain.Midtrans
-	TopUpRepository        domain.TopUpRepository
-	WalletRepository       domain.WalletRepository
-	TransactionRepository  domain.TransactionRepository
-	Validate               *validator.Validate
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	NotificationUseCase     domain.NotificationUseCase
+	AuditUseCase            domain.AuditUseCase
+	MidtransUtil            domain.Midtrans
+	TopUpRepository         domain.TopUpRepository
+	WalletRepository        domain.WalletRepository
+	WalletBalanceRepository domain.WalletBalanceRepository
+	TransactionRepository   domain.TransactionRepository
+	Validate                *validator.Validate
 }
 
-func NewTopUpUseCase(db *gorm.DB, log *logrus.Logger, notificationRepository domain.NotificationRepository, midtransUtil domain.Midtrans, topUpRepository domain.TopUpRepository, walletRepository domain.WalletRepository, transactionRepository domain.TransactionRepository, validate *validator.Validate) domain.TopUpUseCase {
+func NewTopUpUseCase(db *gorm.DB, log *logrus.Logger, notificationUseCase domain.NotificationUseCase, auditUseCase domain.AuditUseCase, midtransUtil domain.Midtrans, topUpRepository domain.TopUpRepository, walletRepository domain.WalletRepository, walletBalanceRepository domain.WalletBalanceRepository, transactionRepository domain.TransactionRepository, validate *validator.Validate) domain.TopUpUseCase {
 	return &TopUpUseCase{
-		Log:                    log,
-		DB:                     db,
-		NotificationRepository: notificationRepository,
-		MidtransUtil:           midtransUtil,
-		TopUpRepository:        topUpRepository,
-		WalletRepository:       walletRepository,
-		TransactionRepository:  transactionRepository,
-		Validate:               validate,
+		Log:                     log,
+		DB:                      db,
+		NotificationUseCase:     notificationUseCase,
+		AuditUseCase:            auditUseCase,
+		MidtransUtil:            midtransUtil,
+		TopUpRepository:         topUpRepository,
+		WalletRepository:        walletRepository,
+		WalletBalanceRepository: walletBalanceRepository,
+		TransactionRepository:   transactionRepository,
+		Validate:                validate,
 	}
 }
 
@@ -50,14 +54,29 @@ func (t *TopUpUseCase) InitializeTopUp(ctx context.Context, req *dto.TopUpReques
 		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
 	}
 
-	topup := &domain.TopUpEntity{
-		ID:     util.GenerateUUID(),
-		UserID: userID,
-		Amount: req.Amount,
-		Status: 0,
+	tx := t.DB.WithContext(ctx)
+
+	// Refuse to take money the wallet could not be credited with
+	wallet := new(domain.WalletEntity)
+	if err := t.WalletRepository.FindByUserID(tx, wallet, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusNotFound, "Wallet not found")
+		}
+		t.Log.WithError(err).Error("Failed to fetch wallet details")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if !wallet.CanCredit() {
+		return nil, walletStatusError(wallet)
 	}
 
-	tx := t.DB.WithContext(ctx)
+	// The payment gateway only takes rupiah
+	topup := &domain.TopUpEntity{
+		ID:       util.GenerateUUID(),
+		UserID:   userID,
+		Amount:   req.Amount,
+		Currency: domain.DefaultCurrency,
+		Status:   domain.TopUpStatusPending,
+	}
 
 	if err := t.TopUpRepository.Create(tx, topup); err != nil {
 		t.Log.WithError(err).Error("Failed to create top-up")
@@ -73,7 +92,8 @@ func (t *TopUpUseCase) InitializeTopUp(ctx context.Context, req *dto.TopUpReques
 	}, nil
 }
 
-// TopUpConfirmed implements domain.TopUpUseCase.
+// TopUpConfirmed implements domain.TopUpUseCase. Only a pending top-up is
+// credited or held, so a callback delivered again changes nothing.
 func (t *TopUpUseCase) TopUpConfirmed(c context.Context, id string) error {
 	ctx, cancel := context.WithTimeout(c, 10*time.Second)
 	defer cancel()
@@ -82,10 +102,11 @@ func (t *TopUpUseCase) TopUpConfirmed(c context.Context, id string) error {
 	t.Log.WithField("topup_id", id).Info("Fetching top-up details")
 
 	tx := t.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
 
-	// Find top-up by UUID
+	// Find top-up by UUID, locked so concurrent callbacks settle it once
 	topup := new(domain.TopUpEntity)
-	err := t.TopUpRepository.FindByUUID(tx, topup, id)
+	err := t.TopUpRepository.FindByUUID(tx.Clauses(clause.Locking{Strength: "UPDATE"}), topup, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			t.Log.WithField("topup_id", id).Warn("Top-up not found")
@@ -95,41 +116,183 @@ func (t *TopUpUseCase) TopUpConfirmed(c context.Context, id string) error {
 		return domain.NewError(fiber.StatusInternalServerError)
 	}
 
+	if topup.Status != domain.TopUpStatusPending {
+		t.Log.WithFields(logrus.Fields{
+			"topup_id": topup.ID,
+			"status":   topup.Status,
+		}).Info("Top-up already settled, ignoring callback")
+		return nil
+	}
+
 	t.Log.WithField("user_id", topup.UserID).Info("Fetching wallet details")
-	// Find wallet by user ID
-	wallet := new(domain.WalletEntity)
-	err = t.WalletRepository.FindByUserID(tx, wallet, topup.UserID)
+	wallet, balance, err := t.findWallet(tx, topup)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			t.Log.WithField("user_id", topup.UserID).Warn("Wallet not found")
-			return domain.NewError(fiber.StatusNotFound, "Wallet not found")
-		}
-		t.Log.WithError(err).Error("Failed to fetch wallet details")
-		return domain.NewError(fiber.StatusInternalServerError)
+		return err
 	}
 
 	// Log current values for debugging
 	t.Log.WithFields(logrus.Fields{
 		"topup_id":        topup.ID,
 		"wallet_id":       wallet.ID,
-		"current_balance": wallet.Balance,
+		"current_balance": balance.Balance,
 		"topup_amount":    topup.Amount,
 	}).Info("Retrieved top-up and wallet data")
 
-	// Update top-up status
-	t.Log.WithField("topup_id", id).Info("Updating top-up status")
-	topup.Status = 1
-	err = t.TopUpRepository.Update(tx, topup)
+	// The payment has been taken, so a wallet frozen or closed since the
+	// top-up started gets it held for staff instead of credited
+	if !wallet.CanCredit() {
+		t.Log.WithFields(logrus.Fields{
+			"topup_id":      topup.ID,
+			"wallet_id":     wallet.ID,
+			"wallet_status": wallet.Status,
+		}).Warn("Holding top-up for a wallet that cannot be credited")
+
+		topup.Status = domain.TopUpStatusHeld
+		if err := t.TopUpRepository.Update(tx, topup); err != nil {
+			t.Log.WithError(err).Error("Failed to update top-up status")
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+		if err := t.recordTopUp(ctx, tx, &domain.AuditEvent{Action: domain.AuditTopUpHeld}, topup, balance, balance.Balance); err != nil {
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			t.Log.WithError(err).Error("Failed to commit top-up transaction")
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+		return nil
+	}
+
+	previous := balance.Balance
+	if err := t.credit(tx, topup, wallet, balance); err != nil {
+		return err
+	}
+
+	if err := t.recordTopUp(ctx, tx, &domain.AuditEvent{Action: domain.AuditTopUpConfirmed}, topup, balance, previous); err != nil {
+		return err
+	}
+
+	// Log before commit
+	t.Log.WithFields(logrus.Fields{
+		"topup_id":  topup.ID,
+		"wallet_id": wallet.ID,
+		"amount":    topup.Amount,
+	}).Info("Ready to commit transaction")
+
+	// Commit transaction
+	t.Log.Info("Committing transaction")
+	if err := tx.Commit().Error; err != nil {
+		t.Log.WithError(err).Error("Failed to commit top-up transaction")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Send notification once the credit is committed, so the balance lock
+	// is not held while it is sent
+	t.Log.Info("Sending notification after top-up")
+	t.notificationAfterTopUp(c, *wallet, domain.NewMoney(topup.Amount, topup.Currency))
+
+	t.Log.Info("TopUpConfirmed process completed successfully")
+	return nil
+}
+
+// ReleaseTopUp implements domain.TopUpUseCase.
+func (t *TopUpUseCase) ReleaseTopUp(ctx context.Context, req *dto.ReleaseTopUpRequest, id string, actorID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(t.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := t.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	topup := new(domain.TopUpEntity)
+	if err := t.TopUpRepository.FindByUUID(tx.Clauses(clause.Locking{Strength: "UPDATE"}), topup, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.NewError(fiber.StatusNotFound, "Top-up not found")
+		}
+		t.Log.WithError(err).Error("Failed to fetch top-up details")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	if topup.Status != domain.TopUpStatusHeld {
+		return domain.NewError(fiber.StatusBadRequest, "Only a held top-up can be released")
+	}
+
+	wallet, balance, err := t.findWallet(tx, topup)
 	if err != nil {
+		return err
+	}
+
+	// Staff unfreeze the wallet first; a closed one is refunded instead
+	if !wallet.CanCredit() {
+		return walletStatusError(wallet)
+	}
+
+	previous := balance.Balance
+	if err := t.credit(tx, topup, wallet, balance); err != nil {
+		return err
+	}
+
+	if err := t.recordTopUp(c, tx, &domain.AuditEvent{
+		ActorID:   actorID,
+		Action:    domain.AuditTopUpReleased,
+		Metadata:  map[string]any{"reason": req.Reason},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}, topup, balance, previous); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		t.Log.WithError(err).Error("Failed to commit top-up transaction")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	t.notificationAfterTopUp(c, *wallet, domain.NewMoney(topup.Amount, topup.Currency))
+
+	return nil
+}
+
+// findWallet loads the main wallet the top-up goes to, share-locked so its
+// status can't change until the top-up is settled, and its balance in the
+// top-up's currency, locked for the credit.
+func (t *TopUpUseCase) findWallet(tx *gorm.DB, topup *domain.TopUpEntity) (*domain.WalletEntity, *domain.WalletBalanceEntity, error) {
+	wallet := new(domain.WalletEntity)
+	if err := t.WalletRepository.FindByUserID(tx.Clauses(clause.Locking{Strength: "SHARE"}), wallet, topup.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Log.WithField("user_id", topup.UserID).Warn("Wallet not found")
+			return nil, nil, domain.NewError(fiber.StatusNotFound, "Wallet not found")
+		}
+		t.Log.WithError(err).Error("Failed to fetch wallet details")
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Every wallet holds the currency top-ups are paid in
+	balance := new(domain.WalletBalanceEntity)
+	if err := t.WalletBalanceRepository.FindByWalletIDAndCurrency(tx.Clauses(clause.Locking{Strength: "UPDATE"}), balance, wallet.ID, topup.Currency); err != nil {
+		t.Log.WithError(err).Error("Failed to fetch wallet balance")
+		return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return wallet, balance, nil
+}
+
+// credit marks the top-up successful and adds it to the wallet balance.
+func (t *TopUpUseCase) credit(tx *gorm.DB, topup *domain.TopUpEntity, wallet *domain.WalletEntity, balance *domain.WalletBalanceEntity) error {
+	// Update top-up status
+	t.Log.WithField("topup_id", topup.ID).Info("Updating top-up status")
+	topup.Status = domain.TopUpStatusSuccess
+	if err := t.TopUpRepository.Update(tx, topup); err != nil {
 		t.Log.WithError(err).Error("Failed to update top-up status")
 		return domain.NewError(fiber.StatusInternalServerError)
 	}
 
 	// Update wallet balance
 	t.Log.WithField("user_id", wallet.UserID).Info("Updating wallet balance")
-	wallet.Balance += topup.Amount
-	err = t.WalletRepository.Update(tx, wallet)
-	if err != nil {
+	balance.Balance += topup.Amount
+	if err := t.WalletBalanceRepository.Update(tx, balance); err != nil {
 		t.Log.WithError(err).Error("Failed to update wallet balance")
 		return domain.NewError(fiber.StatusInternalServerError)
 	}
@@ -137,68 +300,58 @@ func (t *TopUpUseCase) TopUpConfirmed(c context.Context, id string) error {
 	// Log updated wallet balance
 	t.Log.WithFields(logrus.Fields{
 		"wallet_id":   wallet.ID,
-		"new_balance": wallet.Balance,
+		"new_balance": balance.Balance,
 	}).Info("Wallet balance updated successfully")
 
 	// Create transaction record
 	t.Log.Info("Creating transaction record")
 	transaction := &domain.TransactionEntity{
 		WalletID:        wallet.ID,
-		SofNumber:       "00",
+		SofNumber:       domain.TopUpSofNumber,
 		DofNumber:       wallet.WalletNumber,
 		Amount:          topup.Amount,
-		TransactionType: "D",
+		Currency:        topup.Currency,
+		TransactionType: domain.TransactionIn,
 	}
-	if err = t.TransactionRepository.Create(tx, transaction); err != nil {
+	if err := t.TransactionRepository.Create(tx, transaction); err != nil {
 		t.Log.WithError(err).Error("Failed to create transaction")
 		return domain.NewError(fiber.StatusInternalServerError)
 	}
 
-	// Send notification
-	t.Log.Info("Sending notification after top-up")
-	t.notificationAfterTopUp(c, *wallet, topup.Amount)
-
-	// Log before commit
-	t.Log.WithFields(logrus.Fields{
-		"topup_id":  topup.ID,
-		"wallet_id": wallet.ID,
-		"amount":    topup.Amount,
-	}).Info("Ready to commit transaction")
-
-	// Commit transaction
-	t.Log.Info("Committing transaction")
-	if err := tx.Commit().Error; err != nil {
-		t.Log.WithError(err).Error("Failed to commit top-up transaction")
-		return domain.NewError(fiber.StatusInternalServerError)
-	}
-
-	t.Log.Info("TopUpConfirmed process completed successfully")
 	return nil
 }
 
-func (t *TopUpUseCase) notificationAfterTopUp(c context.Context, wallet domain.WalletEntity, amount int64) {
-	tx := t.DB.WithContext(c).Begin()
+// recordTopUp completes the event with the top-up and balance and writes the
+// audit entry. Entries from the payment gateway have no actor.
+func (t *TopUpUseCase) recordTopUp(ctx context.Context, tx *gorm.DB, event *domain.AuditEvent, topup *domain.TopUpEntity, balance *domain.WalletBalanceEntity, previous int64) error {
+	if event.Metadata == nil {
+		event.Metadata = map[string]any{}
+	}
+	event.Metadata["wallet_id"] = balance.WalletID
+	event.Metadata["amount"] = topup.Amount
+	event.Metadata["currency"] = topup.Currency
 
-	formattedAmount := util.CurrencyFormat(float64(amount))
+	event.UserID = topup.UserID
+	event.TargetType = domain.AuditTargetTopUp
+	event.TargetID = topup.ID
+	event.Before = map[string]any{"balance": previous}
+	event.After = map[string]any{"balance": balance.Balance, "status": topup.Status}
 
-	notification := domain.NotificationEntity{
-		UserID: wallet.UserID,
-		Title:  "TopUp Berhasil",
-		Body:   fmt.Sprintf("TopUp senilai %s berhasil dilakukan.", formattedAmount),
-		IsRead: false,
-		Status: 1,
+	if err := t.AuditUseCase.Record(ctx, tx, event); err != nil {
+		t.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
 	}
+	return nil
+}
 
-	if err := t.NotificationRepository.Create(tx, &notification); err != nil {
-		t.Log.WithError(err).Error("Failed to create sender notification")
-		tx.Rollback()
-		return
+func (t *TopUpUseCase) notificationAfterTopUp(c context.Context, wallet domain.WalletEntity, amount domain.Money) {
+	data := map[string]any{
+		"Amount":       amount.Amount,
+		"Currency":     amount.Currency,
+		"WalletNumber": wallet.WalletNumber,
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		t.Log.WithError(err).Error("Failed to commit notification transaction")
-		tx.Rollback()
-		return
+	if err := t.NotificationUseCase.Notify(c, wallet.UserID, domain.NotificationTopUpSuccess, data); err != nil {
+		t.Log.WithError(err).Error("Failed to create top-up notification")
 	}
-
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -12,30 +13,45 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"riz.it/domped/app/domain"
 	"riz.it/domped/app/dto"
 	"riz.it/domped/app/util"
 )
 
 type TransactionUseCase struct {
-	DB                     *gorm.DB
-	Log                    *logrus.Logger
-	WalletRepository       domain.WalletRepository
-	TransactionRepository  domain.TransactionRepository
-	NotificationRepository domain.NotificationRepository
-	Validate               *validator.Validate
-	Redis                  *redis.Client
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	WalletRepository        domain.WalletRepository
+	WalletBalanceRepository domain.WalletBalanceRepository
+	TransactionRepository   domain.TransactionRepository
+	NotificationUseCase     domain.NotificationUseCase
+	AuditUseCase            domain.AuditUseCase
+	FraudUseCase            domain.FraudUseCase
+	Validate                *validator.Validate
+	Redis                   *redis.Client
 }
 
-func NewTransactionUseCase(db *gorm.DB, log *logrus.Logger, walletRepository domain.WalletRepository, transactionRepository domain.TransactionRepository, notificationRepository domain.NotificationRepository, validate *validator.Validate, redis *redis.Client) domain.TransactionUseCase {
+// transferInquiry is what an inquiry stores for the transfer to be executed.
+type transferInquiry struct {
+	dto.TransferInquiryRequest
+	// Challenged is set when the inquiry asked for a verification code,
+	// which execute then insists on
+	Challenged bool `json:"challenged,omitempty"`
+}
+
+func NewTransactionUseCase(db *gorm.DB, log *logrus.Logger, walletRepository domain.WalletRepository, walletBalanceRepository domain.WalletBalanceRepository, transactionRepository domain.TransactionRepository, notificationUseCase domain.NotificationUseCase, auditUseCase domain.AuditUseCase, fraudUseCase domain.FraudUseCase, validate *validator.Validate, redis *redis.Client) domain.TransactionUseCase {
 	return &TransactionUseCase{
-		DB:                     db,
-		Log:                    log,
-		WalletRepository:       walletRepository,
-		TransactionRepository:  transactionRepository,
-		NotificationRepository: notificationRepository,
-		Validate:               validate,
-		Redis:                  redis,
+		DB:                      db,
+		Log:                     log,
+		WalletRepository:        walletRepository,
+		WalletBalanceRepository: walletBalanceRepository,
+		TransactionRepository:   transactionRepository,
+		NotificationUseCase:     notificationUseCase,
+		AuditUseCase:            auditUseCase,
+		FraudUseCase:            fraudUseCase,
+		Validate:                validate,
+		Redis:                   redis,
 	}
 }
 
@@ -50,6 +66,14 @@ func (t *TransactionUseCase) TransferInquiry(ctx context.Context, req *dto.Trans
 		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
 	}
 
+	// Amounts are in rupiah unless the request says otherwise
+	if req.Currency == "" {
+		req.Currency = domain.DefaultCurrency
+	}
+	if _, ok := domain.FindCurrency(req.Currency); !ok {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Currency is not supported")
+	}
+
 	// Retrieve source wallet based on userID
 	wallet := new(domain.WalletEntity)
 	if err := t.WalletRepository.FindByUserID(t.DB.WithContext(c), wallet, userID); err != nil {
@@ -79,17 +103,63 @@ func (t *TransactionUseCase) TransferInquiry(ctx context.Context, req *dto.Trans
 		t.Log.WithError(err).Warn("Failed to query destination wallet")
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
+	if dofWallet.IsPocket() {
+		return nil, domain.NewError(fiber.StatusNotFound, "Destination wallet not found")
+	}
+
+	// Money only moves between wallets whose status allows it
+	if !wallet.CanDebit() {
+		return nil, walletStatusError(wallet)
+	}
+	if !dofWallet.CanCredit() {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Destination wallet cannot receive transfers")
+	}
+
+	// The money leaves from the chosen pocket; the checks above are the
+	// main wallet's, whose pin and status its pockets follow
+	source, err := t.findSource(t.DB.WithContext(c), wallet, req.PocketID)
+	if err != nil {
+		return nil, err
+	}
+
+	sofBalance, _, err := t.findBalances(t.DB.WithContext(c), source, dofWallet, req.Currency)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if balance is sufficient
-	if wallet.Balance < req.Amount {
+	if sofBalance.Balance < req.Amount {
 		return nil, domain.NewError(fiber.StatusBadRequest, "Balance is insufficient")
 	}
 
+	// Run the fraud rules before anything is reserved for the transfer
+	check, err := t.FraudUseCase.Evaluate(c, &domain.FraudCheck{
+		Stage:     domain.FraudStageInquiry,
+		UserID:    userID,
+		WalletID:  wallet.ID,
+		SessionID: req.SessionID,
+		DofNumber: dofWallet.WalletNumber,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	})
+	if err != nil {
+		t.Log.WithError(err).Warnf("Failed to evaluate fraud rules: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if check.Outcome == domain.FraudBlock {
+		return nil, domain.NewError(fiber.StatusForbidden, "Transfer declined for security reasons")
+	}
+
 	// Generate inquiry key and serialize request
 	inquiryKey := util.GenerateRandomString(32)
 	ttl := time.Hour * 24
 
-	inquiryData, err := json.Marshal(req)
+	inquiryData, err := json.Marshal(&transferInquiry{
+		TransferInquiryRequest: *req,
+		Challenged:             check.Outcome == domain.FraudChallenge,
+	})
 	if err != nil {
 		t.Log.WithError(err).Warn("Failed to serialize inquiry data")
 		return nil, domain.NewError(fiber.StatusInternalServerError, "Failed to process inquiry")
@@ -102,10 +172,18 @@ func (t *TransactionUseCase) TransferInquiry(ctx context.Context, req *dto.Trans
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
-	// Return the inquiry key
-	return &dto.TransferInquiryResponse{
+	// A risky transfer has to be confirmed with a code sent to the user
+	response := &dto.TransferInquiryResponse{
 		InquiryKey: inquiryKey,
-	}, nil
+	}
+	if check.Outcome == domain.FraudChallenge {
+		if response.Challenge, err = t.FraudUseCase.IssueChallenge(c, inquiryKey, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Return the inquiry key
+	return response, nil
 }
 
 // TransferExecute implements domain.TransactionUseCase.
@@ -132,19 +210,27 @@ func (t *TransactionUseCase) TransferExecute(ctx context.Context, req *dto.Trans
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
-	inquiryData := new(dto.TransferInquiryRequest)
+	inquiryData := new(transferInquiry)
 	if err := json.Unmarshal([]byte(data), &inquiryData); err != nil {
 		t.Log.WithError(err).Error("Failed to deserialize inquiry data")
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
+	if inquiryData.Currency == "" {
+		inquiryData.Currency = domain.DefaultCurrency
+	}
 
 	// Start a transaction to ensure atomicity of user update
 	tx := t.DB.WithContext(c).Begin()
 	defer tx.Rollback()
 
+	// Both wallet rows are share-locked so a status change, such as the
+	// account being closed, waits for the transfer or is seen by it. The
+	// session keeps one lookup's conditions out of the next.
+	shared := tx.Clauses(clause.Locking{Strength: "SHARE"}).Session(&gorm.Session{})
+
 	// Retrieve source wallet based on userID
 	wallet := new(domain.WalletEntity)
-	if err := t.WalletRepository.FindByUserID(tx, wallet, userID); err != nil {
+	if err := t.WalletRepository.FindByUserID(shared, wallet, userID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, domain.NewError(fiber.StatusNotFound, "Source wallet not found")
 		}
@@ -154,13 +240,24 @@ func (t *TransactionUseCase) TransferExecute(ctx context.Context, req *dto.Trans
 
 	// Retrieve destination wallet based on account number
 	dofWallet := new(domain.WalletEntity)
-	if err := t.WalletRepository.FindByWalletNumber(tx, dofWallet, inquiryData.AccountNumber); err != nil {
+	if err := t.WalletRepository.FindByWalletNumber(shared, dofWallet, inquiryData.AccountNumber); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, domain.NewError(fiber.StatusNotFound, "Destination wallet not found")
 		}
 		t.Log.WithError(err).Warn("Failed to query destination wallet")
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
+	if dofWallet.IsPocket() {
+		return nil, domain.NewError(fiber.StatusNotFound, "Destination wallet not found")
+	}
+
+	// Money only moves between wallets whose status allows it
+	if !wallet.CanDebit() {
+		return nil, walletStatusError(wallet)
+	}
+	if !dofWallet.CanCredit() {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Destination wallet cannot receive transfers")
+	}
 
 	// Check if pin code is valid
 	if !util.VerifyPassword(wallet.WalletPin, req.PinCode) {
@@ -168,15 +265,67 @@ func (t *TransactionUseCase) TransferExecute(ctx context.Context, req *dto.Trans
 		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid pin code")
 	}
 
+	// From here on the transfer runs against the chosen pocket
+	source, err := t.findSource(tx, wallet, inquiryData.PocketID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock both balances so the amount is checked against what is moved;
+	// the session keeps one lookup's conditions out of the next
+	sofBalance, dofBalance, err := t.findBalances(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Session(&gorm.Session{}), source, dofWallet, inquiryData.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if sofBalance.Balance < inquiryData.Amount {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Balance is insufficient")
+	}
+
+	// The rules are checked again, since other transfers may have gone out
+	// since the inquiry
+	check, err := t.FraudUseCase.Evaluate(c, &domain.FraudCheck{
+		Stage:     domain.FraudStageExecute,
+		UserID:    userID,
+		WalletID:  wallet.ID,
+		SessionID: req.SessionID,
+		DofNumber: dofWallet.WalletNumber,
+		Amount:    inquiryData.Amount,
+		Currency:  inquiryData.Currency,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	})
+	if err != nil {
+		t.Log.WithError(err).Warnf("Failed to evaluate fraud rules: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if check.Outcome == domain.FraudBlock {
+		t.Redis.Del(c, req.InquiryKey)
+		return nil, domain.NewError(fiber.StatusForbidden, "Transfer declined for security reasons")
+	}
+	if inquiryData.Challenged || check.Outcome == domain.FraudChallenge {
+		// Without a code, one is sent and the transfer waits for it
+		if req.OTPCode == "" {
+			challenge, err := t.FraudUseCase.IssueChallenge(c, req.InquiryKey, userID)
+			if err != nil {
+				return nil, err
+			}
+			return nil, domain.NewError(fiber.StatusPreconditionRequired, fmt.Sprintf("Enter the verification code sent by %s to complete this transfer", challenge.Channel))
+		}
+		if err := t.FraudUseCase.VerifyChallenge(c, req.InquiryKey, req.OTPCode, check.DecisionID); err != nil {
+			return nil, err
+		}
+	}
+
 	now := time.Now()
 
 	// Transaction
 	debitTransaction := domain.TransactionEntity{
 		WalletID:        dofWallet.ID,
-		SofNumber:       wallet.WalletNumber,
+		SofNumber:       source.WalletNumber,
 		DofNumber:       dofWallet.WalletNumber,
-		TransactionType: "D",
+		TransactionType: domain.TransactionIn,
 		Amount:          inquiryData.Amount,
+		Currency:        inquiryData.Currency,
 		TransactionAt:   now,
 	}
 
@@ -186,11 +335,12 @@ func (t *TransactionUseCase) TransferExecute(ctx context.Context, req *dto.Trans
 	}
 
 	creditTransaction := domain.TransactionEntity{
-		WalletID:        wallet.ID,
-		SofNumber:       wallet.WalletNumber,
+		WalletID:        source.ID,
+		SofNumber:       source.WalletNumber,
 		DofNumber:       dofWallet.WalletNumber,
-		TransactionType: "C",
+		TransactionType: domain.TransactionOut,
 		Amount:          inquiryData.Amount,
+		Currency:        inquiryData.Currency,
 		TransactionAt:   now,
 	}
 
@@ -199,18 +349,47 @@ func (t *TransactionUseCase) TransferExecute(ctx context.Context, req *dto.Trans
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
-	wallet.Balance -= inquiryData.Amount
-	if err := t.WalletRepository.Update(tx, wallet); err != nil {
+	before := map[string]any{
+		"sof_balance": sofBalance.Balance,
+		"dof_balance": dofBalance.Balance,
+	}
+
+	sofBalance.Balance -= inquiryData.Amount
+	if err := t.WalletBalanceRepository.Update(tx, sofBalance); err != nil {
 		t.Log.WithError(err).Error("Failed to update wallet balance")
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
-	dofWallet.Balance += inquiryData.Amount
-	if err := t.WalletRepository.Update(tx, dofWallet); err != nil {
+	dofBalance.Balance += inquiryData.Amount
+	if err := t.WalletBalanceRepository.Update(tx, dofBalance); err != nil {
 		t.Log.WithError(err).Error("Failed to update wallet balance")
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
+	if err := t.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    userID,
+		Action:     domain.AuditTransfer,
+		TargetType: domain.AuditTargetTransaction,
+		TargetID:   strconv.FormatInt(creditTransaction.ID, 10),
+		Metadata: map[string]any{
+			"sof_number": source.WalletNumber,
+			"dof_number": dofWallet.WalletNumber,
+			"amount":     inquiryData.Amount,
+			"currency":   inquiryData.Currency,
+		},
+		Before: before,
+		After: map[string]any{
+			"sof_balance": sofBalance.Balance,
+			"dof_balance": dofBalance.Balance,
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		t.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
 	// Delete the OTP from Redis as it is no longer needed
 	delInquiry := t.Redis.Del(c, req.InquiryKey)
 	if err := delInquiry.Err(); err != nil {
@@ -224,57 +403,102 @@ func (t *TransactionUseCase) TransferExecute(ctx context.Context, req *dto.Trans
 		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
 
-	t.notificationAfterTransfer(c, *wallet, *dofWallet, inquiryData.Amount)
+	t.notificationAfterTransfer(c, *source, *dofWallet, domain.NewMoney(inquiryData.Amount, inquiryData.Currency))
 
 	return &dto.TransferExecuteResponse{
 		InquiryKey: req.InquiryKey,
 		Information: dto.TransferData{
-			SofNumber:     wallet.WalletNumber,
+			SofNumber:     source.WalletNumber,
 			DofNumber:     dofWallet.WalletNumber,
 			Amount:        inquiryData.Amount,
+			Currency:      inquiryData.Currency,
 			TransactionAt: now.String(),
 			Status:        "success",
 		},
 	}, nil
 
 }
-func (t *TransactionUseCase) notificationAfterTransfer(c context.Context, sofWallet domain.WalletEntity, dofWallet domain.WalletEntity, amount int64) {
-	tx := t.DB.WithContext(c).Begin()
+func (t *TransactionUseCase) notificationAfterTransfer(c context.Context, sofWallet domain.WalletEntity, dofWallet domain.WalletEntity, amount domain.Money) {
+	data := map[string]any{
+		"Amount":    amount.Amount,
+		"Currency":  amount.Currency,
+		"SofNumber": sofWallet.WalletNumber,
+		"DofNumber": dofWallet.WalletNumber,
+	}
 
-	formattedAmount := util.CurrencyFormat(float64(amount))
+	if err := t.NotificationUseCase.Notify(c, sofWallet.UserID, domain.NotificationTransferSent, data); err != nil {
+		t.Log.WithError(err).Error("Failed to create sender notification")
+	}
 
-	notificationSender := domain.NotificationEntity{
-		UserID: sofWallet.UserID,
-		Title:  "Transfer Berhasil",
-		Body:   fmt.Sprintf("Transfer senilai %s berhasil dilakukan.", formattedAmount),
-		IsRead: false,
-		Status: 1,
+	if err := t.NotificationUseCase.Notify(c, dofWallet.UserID, domain.NotificationTransferReceived, data); err != nil {
+		t.Log.WithError(err).Error("Failed to create receiver notification")
 	}
+}
 
-	notificationReceiver := domain.NotificationEntity{
-		UserID: dofWallet.UserID,
-		Title:  "Dana Diterima",
-		Body:   fmt.Sprintf("Dana senilai %s telah diterima.", formattedAmount),
-		IsRead: false,
-		Status: 1,
+// findBalances loads the balances a transfer moves money between. The
+// destination has to hold the currency already, as a transfer never converts
+// it. Rows are read lower wallet first, so two opposite transfers locking
+// them cannot deadlock.
+func (t *TransactionUseCase) findBalances(db *gorm.DB, wallet *domain.WalletEntity, dofWallet *domain.WalletEntity, currency string) (*domain.WalletBalanceEntity, *domain.WalletBalanceEntity, error) {
+	sofBalance, dofBalance := new(domain.WalletBalanceEntity), new(domain.WalletBalanceEntity)
+
+	lookups := []struct {
+		walletID int64
+		balance  *domain.WalletBalanceEntity
+		missing  string
+	}{
+		{wallet.ID, sofBalance, fmt.Sprintf("You do not hold a %s balance", currency)},
+		{dofWallet.ID, dofBalance, fmt.Sprintf("Destination wallet does not accept %s, convert the amount to a currency it holds first", currency)},
+	}
+	if dofWallet.ID < wallet.ID {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
 	}
 
-	if err := t.NotificationRepository.Create(tx, &notificationSender); err != nil {
-		t.Log.WithError(err).Error("Failed to create sender notification")
-		tx.Rollback()
-		return
+	for _, lookup := range lookups {
+		if err := t.WalletBalanceRepository.FindByWalletIDAndCurrency(db, lookup.balance, lookup.walletID, currency); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, domain.NewError(fiber.StatusBadRequest, lookup.missing)
+			}
+			t.Log.WithError(err).Warn("Failed to query wallet balance")
+			return nil, nil, domain.NewError(fiber.StatusInternalServerError)
+		}
 	}
 
-	if err := t.NotificationRepository.Create(tx, &notificationReceiver); err != nil {
-		t.Log.WithError(err).Error("Failed to create receiver notification")
-		tx.Rollback()
-		return
+	return sofBalance, dofBalance, nil
+}
+
+// findSource resolves the wallet a transfer takes the money from: the main
+// wallet, or one of its pockets when pocketID is set. A locked pocket
+// cannot be spent from.
+func (t *TransactionUseCase) findSource(db *gorm.DB, wallet *domain.WalletEntity, pocketID int64) (*domain.WalletEntity, error) {
+	if pocketID == 0 {
+		return wallet, nil
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		t.Log.WithError(err).Error("Failed to commit transaction")
-		tx.Rollback()
-		return
+	pocket := new(domain.WalletEntity)
+	if err := t.WalletRepository.FindPocketByID(db, pocket, wallet.ID, pocketID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusNotFound, "Pocket not found")
+		}
+		t.Log.WithError(err).Warn("Failed to query pocket")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
 	}
+	if pocket.IsLocked(time.Now()) {
+		return nil, domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("The pocket is locked until %s", pocket.LockedUntil.Format(pocketDateFormat)))
+	}
+
+	return pocket, nil
+}
 
+// walletStatusError explains to the owner why their wallet cannot be used
+// for a money movement.
+func walletStatusError(wallet *domain.WalletEntity) error {
+	switch wallet.Status {
+	case domain.WalletStatusClosed:
+		return domain.NewError(fiber.StatusForbidden, "Your wallet is closed")
+	case domain.WalletStatusFrozenDebit:
+		return domain.NewError(fiber.StatusForbidden, "Your wallet is frozen for outgoing transactions, please contact customer support")
+	default:
+		return domain.NewError(fiber.StatusForbidden, "Your wallet is frozen, please contact customer support")
+	}
 }
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+const recoveryCodeCount = 10
+
+type TwoFactorUseCase struct {
+	DB                     *gorm.DB
+	Log                    *logrus.Logger
+	Config                 *config.Config
+	UserRepository         domain.UserRepository
+	RecoveryCodeRepository domain.RecoveryCodeRepository
+	NotificationUseCase    domain.NotificationUseCase
+	AuthUseCase            domain.AuthUseCase
+	TOTP                   domain.TOTP
+	Validate               *validator.Validate
+}
+
+func NewTwoFactorUseCase(db *gorm.DB, log *logrus.Logger, config *config.Config, userRepository domain.UserRepository, recoveryCodeRepository domain.RecoveryCodeRepository, notificationUseCase domain.NotificationUseCase, authUseCase domain.AuthUseCase, totp domain.TOTP, validate *validator.Validate) domain.TwoFactorUseCase {
+	return &TwoFactorUseCase{
+		DB:                     db,
+		Log:                    log,
+		Config:                 config,
+		UserRepository:         userRepository,
+		RecoveryCodeRepository: recoveryCodeRepository,
+		NotificationUseCase:    notificationUseCase,
+		AuthUseCase:            authUseCase,
+		TOTP:                   totp,
+		Validate:               validate,
+	}
+}
+
+// Enroll implements domain.TwoFactorUseCase. It stores a new pending secret
+// and recovery codes; two-factor authentication is only enforced once a code
+// from the authenticator app is confirmed.
+func (t *TwoFactorUseCase) Enroll(ctx context.Context, userID int64) (*dto.EnrollTwoFactorResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := t.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := t.UserRepository.FindByID(tx, user, userID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if user.TwoFactorEnabledAt != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Two-factor authentication is already enabled")
+	}
+
+	secret, err := t.TOTP.GenerateSecret()
+	if err != nil {
+		t.Log.WithError(err).Error("Failed to generate two-factor secret")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Only the encrypted secret is stored
+	sealed, err := t.TOTP.Seal(secret)
+	if err != nil {
+		t.Log.WithError(err).Error("Failed to seal two-factor secret")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	user.TwoFactorSecret = sealed
+	user.TwoFactorLastCounter = 0
+	if err := t.UserRepository.Update(tx, user); err != nil {
+		t.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Replace any codes from an earlier enrolment
+	if err := t.RecoveryCodeRepository.DeleteByUserID(tx, user.ID); err != nil {
+		t.Log.WithError(err).Warnf("Failed to delete recovery codes: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code := util.GenerateRecoveryCode()
+		if err := t.RecoveryCodeRepository.Create(tx, &domain.RecoveryCodeEntity{
+			UserID:   user.ID,
+			CodeHash: util.HashToken(util.NormalizeRecoveryCode(code)),
+		}); err != nil {
+			t.Log.WithError(err).Warnf("Failed to create recovery code: %+v", err)
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		codes = append(codes, code)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		t.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	issuer := t.Config.Server.Name
+	if issuer == "" {
+		issuer = "Domped"
+	}
+
+	return &dto.EnrollTwoFactorResponse{
+		Secret:        secret,
+		URI:           t.TOTP.URI(secret, issuer, user.Email),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// Confirm implements domain.TwoFactorUseCase.
+func (t *TwoFactorUseCase) Confirm(ctx context.Context, req *dto.ConfirmTwoFactorRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(t.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := t.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := t.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if user.TwoFactorEnabledAt != nil {
+		return domain.NewError(fiber.StatusBadRequest, "Two-factor authentication is already enabled")
+	}
+	if user.TwoFactorSecret == "" {
+		return domain.NewError(fiber.StatusBadRequest, "Two-factor authentication has not been enrolled")
+	}
+
+	// Wrong codes count toward the login lockout, so the endpoint can't be
+	// used to guess codes instead
+	if err := t.AuthUseCase.CheckLockout(c, user, req.IPAddress); err != nil {
+		return err
+	}
+
+	secret, err := t.TOTP.Open(user.TwoFactorSecret)
+	if err != nil {
+		t.Log.WithError(err).Error("Failed to open two-factor secret")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// The code proves the authenticator app holds the secret
+	counter, ok := t.TOTP.Validate(secret, req.Code)
+	if !ok {
+		t.AuthUseCase.RecordFailure(c, user, req.IPAddress, req.UserAgent)
+		return domain.NewError(fiber.StatusBadRequest, "Invalid authentication code")
+	}
+	t.AuthUseCase.ClearFailures(c, user)
+
+	now := time.Now()
+	user.TwoFactorEnabledAt = &now
+	user.TwoFactorLastCounter = counter
+	if err := t.UserRepository.Update(tx, user); err != nil {
+		t.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		t.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := t.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityTwoFactorOn, nil); err != nil {
+		t.Log.WithError(err).Warn("Failed to create two-factor notification")
+	}
+
+	return nil
+}
+
+// Disable implements domain.TwoFactorUseCase. The password and a current
+// second factor have to be entered again so neither a stolen access token
+// nor a leaked password alone can turn the second factor off.
+func (t *TwoFactorUseCase) Disable(ctx context.Context, req *dto.DisableTwoFactorRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(t.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := t.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := t.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	// Wrong passwords and codes count toward the login lockout, so the
+	// endpoint can't be used to guess either instead
+	if err := t.AuthUseCase.CheckLockout(c, user, req.IPAddress); err != nil {
+		return err
+	}
+
+	if !util.VerifyPassword(user.Password, req.Password) {
+		t.AuthUseCase.RecordFailure(c, user, req.IPAddress, req.UserAgent)
+		return domain.NewError(fiber.StatusUnauthorized, "Invalid password")
+	}
+
+	if user.TwoFactorEnabledAt == nil && user.TwoFactorSecret == "" {
+		return domain.NewError(fiber.StatusBadRequest, "Two-factor authentication is not enabled")
+	}
+
+	// Accept an authenticator code from a time step after the last one
+	// used, or an unused recovery code
+	verified := false
+	if req.Code != "" {
+		secret, err := t.TOTP.Open(user.TwoFactorSecret)
+		if err != nil {
+			t.Log.WithError(err).Error("Failed to open two-factor secret")
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+		counter, ok := t.TOTP.Validate(secret, req.Code)
+		verified = ok && counter > user.TwoFactorLastCounter
+	} else {
+		var err error
+		verified, err = t.RecoveryCodeRepository.MarkUsed(tx, user.ID, util.HashToken(util.NormalizeRecoveryCode(req.RecoveryCode)))
+		if err != nil {
+			t.Log.WithError(err).Warnf("Failed to consume recovery code: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+	if !verified {
+		t.AuthUseCase.RecordFailure(c, user, req.IPAddress, req.UserAgent)
+		return domain.NewError(fiber.StatusBadRequest, "Invalid authentication code")
+	}
+	t.AuthUseCase.ClearFailures(c, user)
+
+	wasEnabled := user.TwoFactorEnabledAt != nil
+	user.TwoFactorSecret = ""
+	user.TwoFactorEnabledAt = nil
+	user.TwoFactorLastCounter = 0
+	if err := t.UserRepository.Update(tx, user); err != nil {
+		t.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := t.RecoveryCodeRepository.DeleteByUserID(tx, user.ID); err != nil {
+		t.Log.WithError(err).Warnf("Failed to delete recovery codes: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		t.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if wasEnabled {
+		if err := t.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityTwoFactorOff, nil); err != nil {
+			t.Log.WithError(err).Warn("Failed to create two-factor notification")
+		}
+	}
+
+	return nil
+}
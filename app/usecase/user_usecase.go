@@ -0,0 +1,579 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+type UserUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	Config                  *config.Config
+	UserRepository          domain.UserRepository
+	WalletRepository        domain.WalletRepository
+	WalletBalanceRepository domain.WalletBalanceRepository
+	SessionRepository       domain.SessionRepository
+	AuditUseCase            domain.AuditUseCase
+	NotificationUseCase     domain.NotificationUseCase
+	TokenDenylist           domain.TokenDenylist
+	Email                   domain.Email
+	Template                domain.Template
+	Validate                *validator.Validate
+	Redis                   *redis.Client
+}
+
+func NewUserUseCase(db *gorm.DB, log *logrus.Logger, config *config.Config, userRepository domain.UserRepository, walletRepository domain.WalletRepository, walletBalanceRepository domain.WalletBalanceRepository, sessionRepository domain.SessionRepository, auditUseCase domain.AuditUseCase, notificationUseCase domain.NotificationUseCase, tokenDenylist domain.TokenDenylist, email domain.Email, template domain.Template, validate *validator.Validate, redis *redis.Client) domain.UserUseCase {
+	return &UserUseCase{
+		DB:                      db,
+		Log:                     log,
+		Config:                  config,
+		UserRepository:          userRepository,
+		WalletRepository:        walletRepository,
+		WalletBalanceRepository: walletBalanceRepository,
+		SessionRepository:       sessionRepository,
+		AuditUseCase:            auditUseCase,
+		NotificationUseCase:     notificationUseCase,
+		TokenDenylist:           tokenDenylist,
+		Email:                   email,
+		Template:                template,
+		Validate:                validate,
+		Redis:                   redis,
+	}
+}
+
+// GetProfile implements domain.UserUseCase.
+func (u *UserUseCase) GetProfile(ctx context.Context, userID int64) (*dto.ProfileResponse, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := u.DB.WithContext(c)
+
+	// Retrieve the user based on userID
+	user := new(domain.UserEntity)
+	if err := u.UserRepository.FindByID(tx, user, userID); err != nil {
+		return nil, domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	response := &dto.ProfileResponse{
+		User: toUserData(user),
+	}
+
+	// The wallet only exists once the email has been verified
+	wallet := new(domain.WalletEntity)
+	err := u.WalletRepository.FindByUserID(tx, wallet, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		u.Log.WithError(err).Warn("Failed to query wallet")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if err == nil {
+		balances := new([]domain.WalletBalanceEntity)
+		if err := u.WalletBalanceRepository.FindByWalletID(tx, balances, wallet.ID); err != nil {
+			u.Log.WithError(err).Warn("Failed to query wallet balances")
+			return nil, domain.NewError(fiber.StatusInternalServerError)
+		}
+		response.Wallet = toWalletData(wallet, *balances)
+	}
+
+	return response, nil
+}
+
+// UpdateProfile implements domain.UserUseCase.
+func (u *UserUseCase) UpdateProfile(ctx context.Context, req *dto.UpdateProfileRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(u.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := u.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	// Retrieve the user based on userID
+	user := new(domain.UserEntity)
+	if err := u.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	changes := map[string]any{}
+
+	if req.FullName != "" && req.FullName != user.FullName {
+		changes["full_name"] = map[string]any{"old": user.FullName, "new": req.FullName}
+		user.FullName = req.FullName
+	}
+
+	if req.Phone != "" {
+		phone, err := util.NormalizePhone(req.Phone)
+		if err != nil {
+			return domain.NewError(fiber.StatusBadRequest, "Invalid phone number")
+		}
+
+		if phone != user.Phone {
+			count, err := u.UserRepository.CountByVerifiedPhone(tx, phone)
+			if err != nil {
+				u.Log.WithError(err).Warnf("Failed to count phone: %+v", err)
+				return domain.NewError(fiber.StatusInternalServerError)
+			}
+			if count > 0 {
+				return domain.NewError(fiber.StatusConflict, "The phone number is already in use")
+			}
+
+			// The new number has to be verified before it can be used
+			// to log in
+			changes["phone"] = map[string]any{"old": user.Phone, "new": phone}
+			user.Phone = phone
+			user.PhoneVerifiedAt = nil
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := u.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   userID,
+		Action:    domain.AuditProfileUpdated,
+		Metadata:  changes,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		u.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	_, fullName := changes["full_name"]
+	_, phone := changes["phone"]
+	if err := u.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityProfile, map[string]any{
+		"FullName": fullName,
+		"Phone":    phone,
+	}); err != nil {
+		u.Log.WithError(err).Warn("Failed to create profile notification")
+	}
+
+	return nil
+}
+
+// UpdateLanguage implements domain.UserUseCase.
+func (u *UserUseCase) UpdateLanguage(ctx context.Context, req *dto.UpdateLanguageRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(u.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := u.DB.WithContext(c)
+
+	// Retrieve the user based on userID
+	user := new(domain.UserEntity)
+	if err := u.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	// Store the new language preference
+	user.Language = req.Language
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// ChangeEmail implements domain.UserUseCase. The new address only replaces
+// the current one once the code sent to it is confirmed.
+func (u *UserUseCase) ChangeEmail(ctx context.Context, req *dto.ChangeEmailRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(u.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := u.DB.WithContext(c)
+
+	user := new(domain.UserEntity)
+	if err := u.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if !util.VerifyPassword(user.Password, req.Password) {
+		return domain.NewError(fiber.StatusUnauthorized, "Invalid password")
+	}
+
+	if req.Email == user.Email {
+		return domain.NewError(fiber.StatusBadRequest, "The new email is the same as the current one")
+	}
+
+	count, err := u.UserRepository.CountByEmail(tx, req.Email)
+	if err != nil {
+		u.Log.WithError(err).Warnf("Failed to count email: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	if count > 0 {
+		return domain.NewError(fiber.StatusConflict, "The email address is already in use")
+	}
+
+	claimed, err := u.Redis.SetNX(c, emailChangeCooldownKey(userID), 1, u.otpCooldown()).Result()
+	if err != nil {
+		u.Log.WithError(err).Warnf("Failed to check OTP cooldown: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	if !claimed {
+		return domain.NewError(fiber.StatusTooManyRequests, "Please wait before requesting a new code")
+	}
+
+	otpCode := util.GenerateRandomCode(int64(util.ParseIntOrDefault(u.Config.Auth.OTPLength, 6)))
+
+	// A new request replaces any pending one
+	key := emailChangeKey(userID)
+	pipe := u.Redis.TxPipeline()
+	pipe.Del(c, key)
+	pipe.HSet(c, key, "email", req.Email, "code_hash", util.HashToken(otpCode))
+	pipe.Expire(c, key, u.otpTTL())
+	if _, err := pipe.Exec(c); err != nil {
+		u.Log.WithError(err).Warnf("Failed to store OTP in Redis: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	email, err := u.Template.RenderEmail(user.Language, "email_change", map[string]any{
+		"Name":             user.FullName,
+		"Email":            req.Email,
+		"OTP":              otpCode,
+		"ExpiresInMinutes": int(u.otpTTL().Minutes()),
+	})
+	if err != nil {
+		u.Log.WithError(err).Warnf("Failed to render email change email: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := u.Email.Send(c, &domain.EmailMessage{
+		To:      []string{req.Email},
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	}); err != nil {
+		u.Log.WithError(err).Warnf("Failed to send email change email: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := u.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   userID,
+		Action:    domain.AuditEmailChangeRequested,
+		Metadata:  map[string]any{"new_email": req.Email},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		u.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+	}
+
+	// Warn the current address in case someone else is behind the request
+	if err := u.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityEmailRequest, map[string]any{
+		"NewEmail": req.Email,
+	}); err != nil {
+		u.Log.WithError(err).Warn("Failed to create email change notification")
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange implements domain.UserUseCase.
+func (u *UserUseCase) ConfirmEmailChange(ctx context.Context, req *dto.ConfirmEmailChangeRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(u.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	invalid := domain.NewError(fiber.StatusBadRequest, "Invalid or expired code")
+
+	key := emailChangeKey(userID)
+	pending, err := u.Redis.HGetAll(c, key).Result()
+	if err != nil || pending["code_hash"] == "" {
+		return invalid
+	}
+
+	// Count the attempt before comparing so guesses are always limited
+	attempts, err := u.Redis.HIncrBy(c, key, "attempts", 1).Result()
+	if err != nil {
+		u.Log.WithError(err).Warnf("Failed to count OTP attempts: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(req.OTP)), []byte(pending["code_hash"])) != 1 {
+		if attempts >= int64(util.ParseIntOrDefault(u.Config.Auth.OTPMaxAttempts, 5)) {
+			u.Redis.Del(c, key)
+			return domain.NewError(fiber.StatusBadRequest, "Too many invalid attempts, please request a new code")
+		}
+		return invalid
+	}
+
+	tx := u.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := u.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	// The address may have been taken while the code was pending
+	count, err := u.UserRepository.CountByEmail(tx, pending["email"])
+	if err != nil {
+		u.Log.WithError(err).Warnf("Failed to count email: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+	if count > 0 {
+		u.Redis.Del(c, key)
+		return domain.NewError(fiber.StatusConflict, "The email address is already in use")
+	}
+
+	oldEmail := user.Email
+	now := time.Now()
+	user.Email = pending["email"]
+	user.EmailVerifiedAt = &now
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := u.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   userID,
+		Action:    domain.AuditEmailChanged,
+		Metadata:  map[string]any{"old": oldEmail, "new": user.Email},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		u.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	u.Redis.Del(c, key)
+
+	if err := u.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityEmail, map[string]any{
+		"OldEmail": oldEmail,
+		"NewEmail": user.Email,
+	}); err != nil {
+		u.Log.WithError(err).Warn("Failed to create email change notification")
+	}
+
+	return nil
+}
+
+// ChangePassword implements domain.UserUseCase. Every other session is
+// signed out; the session making the change stays signed in.
+func (u *UserUseCase) ChangePassword(ctx context.Context, req *dto.ChangePasswordRequest, userID int64, sessionID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(u.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := u.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	user := new(domain.UserEntity)
+	if err := u.UserRepository.FindByID(tx, user, userID); err != nil {
+		return domain.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if !util.VerifyPassword(user.Password, req.CurrentPassword) {
+		return domain.NewError(fiber.StatusUnauthorized, "Invalid password")
+	}
+
+	// Hash the new password for storage
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		u.Log.WithError(err).Warnf("Failed to hash password: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	user.Password = hashedPassword
+	if err := u.UserRepository.Update(tx, user); err != nil {
+		u.Log.WithError(err).Warnf("Failed to save user data: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	sessions := new([]domain.SessionEntity)
+	if err := u.SessionRepository.FindActiveByUserID(tx, sessions, userID); err != nil {
+		u.Log.WithError(err).Warn("Failed to query sessions")
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	revoked := []domain.SessionEntity{}
+	for _, session := range *sessions {
+		if session.ID == sessionID {
+			continue
+		}
+
+		session.RevokedAt = &now
+		session.RevokedReason = domain.SessionRevokedChange
+		if err := u.SessionRepository.Update(tx, &session); err != nil {
+			u.Log.WithError(err).Warnf("Failed to revoke session: %+v", err)
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+		revoked = append(revoked, session)
+	}
+
+	if err := u.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:    userID,
+		ActorID:   userID,
+		Action:    domain.AuditPasswordChanged,
+		Metadata:  map[string]any{"sessions_revoked": len(revoked)},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		u.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		u.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Cut off the access tokens of the signed out sessions right away
+	for _, session := range revoked {
+		if session.AccessExpiresAt == nil {
+			continue
+		}
+		if err := u.TokenDenylist.Deny(c, session.AccessTokenID, *session.AccessExpiresAt); err != nil {
+			u.Log.WithError(err).Warn("Failed to denylist access token")
+		}
+	}
+
+	if err := u.NotificationUseCase.Notify(c, userID, domain.NotificationSecurityPassword, map[string]any{
+		"Reset": false,
+	}); err != nil {
+		u.Log.WithError(err).Warn("Failed to create password change notification")
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) otpTTL() time.Duration {
+	return time.Minute * time.Duration(util.ParseIntOrDefault(u.Config.Auth.OTPTTL, 10))
+}
+
+func (u *UserUseCase) otpCooldown() time.Duration {
+	return time.Second * time.Duration(util.ParseIntOrDefault(u.Config.Auth.OTPResendCooldown, 60))
+}
+
+func emailChangeKey(userID int64) string {
+	return "email_change:" + strconv.FormatInt(userID, 10)
+}
+
+func emailChangeCooldownKey(userID int64) string {
+	return "email_change:cooldown:" + strconv.FormatInt(userID, 10)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func toUserData(user *domain.UserEntity) dto.UserData {
+	return dto.UserData{
+		ID:               user.ID,
+		FullName:         user.FullName,
+		Phone:            user.Phone,
+		Email:            user.Email,
+		EmailVerifiedAt:  formatOptionalTime(user.EmailVerifiedAt),
+		PhoneVerifiedAt:  formatOptionalTime(user.PhoneVerifiedAt),
+		TwoFactorEnabled: user.TwoFactorEnabledAt != nil,
+		KYCLevel:         user.KYCLevel,
+		Role:             user.Role,
+		CreatedAt:        user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        user.UpdatedAt.Format(time.RFC3339),
+		IsActive:         user.IsActive,
+		Language:         user.Language,
+	}
+}
+
+func toWalletData(wallet *domain.WalletEntity, balances []domain.WalletBalanceEntity) *dto.WalletData {
+	data := make([]dto.WalletBalanceData, 0, len(balances))
+	for i := range balances {
+		data = append(data, toWalletBalanceData(&balances[i]))
+	}
+
+	return &dto.WalletData{
+		ID:           wallet.ID,
+		WalletNumber: wallet.WalletNumber,
+		Balances:     data,
+		Status:       wallet.Status,
+		PinSet:       wallet.WalletPin != "",
+		CreatedAt:    wallet.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    wallet.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWalletBalanceData(balance *domain.WalletBalanceEntity) dto.WalletBalanceData {
+	data := dto.WalletBalanceData{
+		Currency:  balance.Currency,
+		Balance:   balance.Balance,
+		Formatted: util.FormatMoney(balance.Money()),
+	}
+	if currency, ok := domain.FindCurrency(balance.Currency); ok {
+		data.MinorUnits = currency.MinorUnits
+	}
+	return data
+}
+
+func toTransactionData(transaction *domain.TransactionEntity) dto.TransactionData {
+	return dto.TransactionData{
+		ID:              transaction.ID,
+		WalletID:        transaction.WalletID,
+		SofNumber:       transaction.SofNumber,
+		DofNumber:       transaction.DofNumber,
+		Amount:          transaction.Amount,
+		Currency:        transaction.Currency,
+		TransactionType: transaction.TransactionType,
+		TransactionAt:   transaction.TransactionAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,652 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
+	"riz.it/domped/app/util"
+)
+
+// maxPockets is how many open pockets a user can have at once.
+const maxPockets = 10
+
+const pocketDateFormat = "2006-01-02"
+
+type WalletUseCase struct {
+	DB                      *gorm.DB
+	Log                     *logrus.Logger
+	WalletRepository        domain.WalletRepository
+	WalletBalanceRepository domain.WalletBalanceRepository
+	TransactionRepository   domain.TransactionRepository
+	AuditUseCase            domain.AuditUseCase
+	Validate                *validator.Validate
+}
+
+func NewWalletUseCase(db *gorm.DB, log *logrus.Logger, walletRepository domain.WalletRepository, walletBalanceRepository domain.WalletBalanceRepository, transactionRepository domain.TransactionRepository, auditUseCase domain.AuditUseCase, validate *validator.Validate) domain.WalletUseCase {
+	return &WalletUseCase{
+		DB:                      db,
+		Log:                     log,
+		WalletRepository:        walletRepository,
+		WalletBalanceRepository: walletBalanceRepository,
+		TransactionRepository:   transactionRepository,
+		AuditUseCase:            auditUseCase,
+		Validate:                validate,
+	}
+}
+
+// FindBalances implements domain.WalletUseCase.
+func (w *WalletUseCase) FindBalances(ctx context.Context, userID int64) ([]dto.WalletBalanceData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := w.DB.WithContext(c)
+
+	wallet, err := w.findWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := new([]domain.WalletBalanceEntity)
+	if err := w.WalletBalanceRepository.FindByWalletID(tx, balances, wallet.ID); err != nil {
+		w.Log.WithError(err).Warn("Failed to query wallet balances")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.WalletBalanceData, 0, len(*balances))
+	for i := range *balances {
+		result = append(result, toWalletBalanceData(&(*balances)[i]))
+	}
+
+	return result, nil
+}
+
+// OpenBalance implements domain.WalletUseCase. It adds an empty balance in
+// another currency so the wallet can receive transfers in it.
+func (w *WalletUseCase) OpenBalance(ctx context.Context, req *dto.OpenBalanceRequest, userID int64) (*dto.WalletBalanceData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(w.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	if _, ok := domain.FindCurrency(req.Currency); !ok {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Currency is not supported")
+	}
+
+	tx := w.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	wallet, err := w.findWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet.Status != domain.WalletStatusActive {
+		return nil, walletStatusError(wallet)
+	}
+
+	balance := new(domain.WalletBalanceEntity)
+	err = w.WalletBalanceRepository.FindByWalletIDAndCurrency(tx, balance, wallet.ID, req.Currency)
+	if err == nil {
+		return nil, domain.NewError(fiber.StatusConflict, fmt.Sprintf("You already hold a %s balance", req.Currency))
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		w.Log.WithError(err).Warn("Failed to query wallet balance")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	balance = &domain.WalletBalanceEntity{
+		WalletID: wallet.ID,
+		Currency: req.Currency,
+	}
+	if err := w.WalletBalanceRepository.Create(tx, balance); err != nil {
+		w.Log.WithError(err).Warnf("Failed to create wallet balance: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		w.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := toWalletBalanceData(balance)
+	return &result, nil
+}
+
+func (w *WalletUseCase) findWallet(tx *gorm.DB, userID int64) (*domain.WalletEntity, error) {
+	wallet := new(domain.WalletEntity)
+	if err := w.WalletRepository.FindByUserID(tx, wallet, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusNotFound, "Wallet not found")
+		}
+		w.Log.WithError(err).Warn("Failed to query wallet")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	return wallet, nil
+}
+
+// FindPockets implements domain.WalletUseCase.
+func (w *WalletUseCase) FindPockets(ctx context.Context, userID int64) ([]dto.PocketData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := w.DB.WithContext(c)
+
+	wallet, err := w.findWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pockets := new([]domain.WalletEntity)
+	if err := w.WalletRepository.FindPocketsByParentID(tx, pockets, wallet.ID); err != nil {
+		w.Log.WithError(err).Warn("Failed to query pockets")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := make([]dto.PocketData, 0, len(*pockets))
+	for i := range *pockets {
+		pocket := &(*pockets)[i]
+		balance, err := w.findPocketBalance(tx, pocket)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, toPocketData(pocket, balance))
+	}
+
+	return result, nil
+}
+
+// OpenPocket implements domain.WalletUseCase. A pocket holds one currency
+// and gets its own wallet number, so it shows up by name on statements.
+func (w *WalletUseCase) OpenPocket(ctx context.Context, req *dto.PocketRequest, userID int64) (*dto.PocketData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(w.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	if req.Currency == "" {
+		req.Currency = domain.DefaultCurrency
+	}
+	if _, ok := domain.FindCurrency(req.Currency); !ok {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Currency is not supported")
+	}
+
+	lockedUntil, err := parsePocketLock(req.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := w.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	// Lock the main wallet so concurrent requests cannot pass the limit
+	wallet, err := w.findWallet(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Status != domain.WalletStatusActive {
+		return nil, walletStatusError(wallet)
+	}
+
+	pockets := new([]domain.WalletEntity)
+	if err := w.WalletRepository.FindPocketsByParentID(tx, pockets, wallet.ID); err != nil {
+		w.Log.WithError(err).Warn("Failed to query pockets")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if len(*pockets) >= maxPockets {
+		return nil, domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("You can have at most %d pockets", maxPockets))
+	}
+
+	// Generate wallet number
+	walletNumber, err := util.GenerateWalletNumber(8)
+	if err != nil {
+		w.Log.WithError(err).Error("Failed to generate wallet number")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Check if wallet number already exists
+	count, err := w.WalletRepository.CountByWalletNumber(tx, walletNumber)
+	if err != nil {
+		w.Log.WithError(err).Warnf("Failed to count wallet: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	if count > 0 {
+		walletNumber, _ = util.GenerateWalletNumber(8)
+	}
+
+	pocket := &domain.WalletEntity{
+		UserID:       userID,
+		WalletNumber: walletNumber,
+		Status:       domain.WalletStatusActive,
+		ParentID:     &wallet.ID,
+		Name:         req.Name,
+		TargetAmount: pocketTarget(req.TargetAmount),
+		LockedUntil:  lockedUntil,
+		Balances:     []domain.WalletBalanceEntity{{Currency: req.Currency}},
+	}
+	if err := w.WalletRepository.Create(tx, pocket); err != nil {
+		w.Log.WithError(err).Warnf("Failed to create pocket: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := w.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    userID,
+		Action:     domain.AuditPocketOpened,
+		TargetType: domain.AuditTargetWallet,
+		TargetID:   strconv.FormatInt(pocket.ID, 10),
+		Metadata: map[string]any{
+			"parent_id": wallet.ID,
+			"currency":  req.Currency,
+		},
+		After:     pocketAuditState(pocket),
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		w.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		w.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := toPocketData(pocket, &pocket.Balances[0])
+	return &result, nil
+}
+
+// UpdatePocket implements domain.WalletUseCase. A lock that is running can
+// be extended but not shortened or removed.
+func (w *WalletUseCase) UpdatePocket(ctx context.Context, req *dto.PocketRequest, pocketID int64, userID int64) (*dto.PocketData, error) {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(w.Validate, req); len(validationErrors) > 0 {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	lockedUntil, err := parsePocketLock(req.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := w.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	wallet, err := w.findWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pocket, err := w.findPocket(tx.Clauses(clause.Locking{Strength: "UPDATE"}), wallet, pocketID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if pocket.IsLocked(now) && (lockedUntil == nil || lockedUntil.Before(*pocket.LockedUntil)) {
+		return nil, domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("The pocket is locked until %s, the lock can only be extended", pocket.LockedUntil.Format(pocketDateFormat)))
+	}
+
+	before := pocketAuditState(pocket)
+
+	pocket.Name = req.Name
+	pocket.TargetAmount = pocketTarget(req.TargetAmount)
+	pocket.LockedUntil = lockedUntil
+	if err := w.WalletRepository.Update(tx, pocket); err != nil {
+		w.Log.WithError(err).Warnf("Failed to update pocket: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	balance, err := w.findPocketBalance(tx, pocket)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    userID,
+		Action:     domain.AuditPocketUpdated,
+		TargetType: domain.AuditTargetWallet,
+		TargetID:   strconv.FormatInt(pocket.ID, 10),
+		Before:     before,
+		After:      pocketAuditState(pocket),
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		w.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		w.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	result := toPocketData(pocket, balance)
+	return &result, nil
+}
+
+// ClosePocket implements domain.WalletUseCase. The pocket has to be emptied
+// first, which a running lock prevents.
+func (w *WalletUseCase) ClosePocket(ctx context.Context, req *dto.ClosePocketRequest, pocketID int64, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := w.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	wallet, err := w.findWallet(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	// The session keeps one lookup's conditions out of the next
+	locked := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Session(&gorm.Session{})
+	pocket, err := w.findPocket(locked, wallet, pocketID)
+	if err != nil {
+		return err
+	}
+
+	balance, err := w.findPocketBalance(locked, pocket)
+	if err != nil {
+		return err
+	}
+	if balance.Balance != 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Please move the pocket's money out before closing it")
+	}
+
+	now := time.Now()
+	pocket.Status = domain.WalletStatusClosed
+	pocket.StatusReason = "Pocket closed by the owner"
+	pocket.StatusChangedBy = &userID
+	pocket.StatusChangedAt = &now
+	pocket.ClosedAt = &now
+	if err := w.WalletRepository.Update(tx, pocket); err != nil {
+		w.Log.WithError(err).Warnf("Failed to close pocket: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := w.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    userID,
+		Action:     domain.AuditPocketClosed,
+		TargetType: domain.AuditTargetWallet,
+		TargetID:   strconv.FormatInt(pocket.ID, 10),
+		Before:     map[string]any{"status": domain.WalletStatusActive},
+		After:      map[string]any{"status": pocket.Status},
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}); err != nil {
+		w.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		w.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// MovePocketMoney implements domain.WalletUseCase. Money stays with the
+// user, so no pin is asked for; it moves in the pocket's currency.
+func (w *WalletUseCase) MovePocketMoney(ctx context.Context, req *dto.MovePocketMoneyRequest, userID int64) error {
+	// Set a timeout for the process
+	c, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Validate the incoming request data
+	if validationErrors := util.Validate(w.Validate, req); len(validationErrors) > 0 {
+		return domain.NewError(fiber.StatusBadRequest, "Invalid data provided", validationErrors)
+	}
+
+	tx := w.DB.WithContext(c).Begin()
+	defer tx.Rollback()
+
+	wallet, err := w.findWallet(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Pockets follow the main wallet's status
+	if !wallet.CanDebit() {
+		return walletStatusError(wallet)
+	}
+
+	source, currency, err := w.resolvePocket(tx, wallet, req.FromPocketID, "")
+	if err != nil {
+		return err
+	}
+	destination, currency, err := w.resolvePocket(tx, wallet, req.ToPocketID, currency)
+	if err != nil {
+		return err
+	}
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	now := time.Now()
+	if source.IsLocked(now) {
+		return domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("The pocket is locked until %s", source.LockedUntil.Format(pocketDateFormat)))
+	}
+
+	// Lock both balances, lower wallet first
+	sourceBalance, destinationBalance := new(domain.WalletBalanceEntity), new(domain.WalletBalanceEntity)
+	lookups := []struct {
+		wallet  *domain.WalletEntity
+		balance *domain.WalletBalanceEntity
+	}{
+		{source, sourceBalance},
+		{destination, destinationBalance},
+	}
+	if destination.ID < source.ID {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+	// The session keeps one lookup's conditions out of the next
+	locked := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Session(&gorm.Session{})
+	for _, lookup := range lookups {
+		if err := w.WalletBalanceRepository.FindByWalletIDAndCurrency(locked, lookup.balance, lookup.wallet.ID, currency); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.NewError(fiber.StatusBadRequest, fmt.Sprintf("You do not hold a %s balance", currency))
+			}
+			w.Log.WithError(err).Warn("Failed to query wallet balance")
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	if sourceBalance.Balance < req.Amount {
+		return domain.NewError(fiber.StatusBadRequest, "Balance is insufficient")
+	}
+
+	before := map[string]any{
+		"sof_balance": sourceBalance.Balance,
+		"dof_balance": destinationBalance.Balance,
+	}
+
+	sourceBalance.Balance -= req.Amount
+	destinationBalance.Balance += req.Amount
+	for _, balance := range []*domain.WalletBalanceEntity{sourceBalance, destinationBalance} {
+		if err := w.WalletBalanceRepository.Update(tx, balance); err != nil {
+			w.Log.WithError(err).Error("Failed to update wallet balance")
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	transactions := []domain.TransactionEntity{
+		{
+			WalletID:        source.ID,
+			SofNumber:       source.WalletNumber,
+			DofNumber:       domain.PocketMoveNumber,
+			TransactionType: domain.TransactionOut,
+			Amount:          req.Amount,
+			Currency:        currency,
+			TransactionAt:   now,
+		},
+		{
+			WalletID:        destination.ID,
+			SofNumber:       domain.PocketMoveNumber,
+			DofNumber:       destination.WalletNumber,
+			TransactionType: domain.TransactionIn,
+			Amount:          req.Amount,
+			Currency:        currency,
+			TransactionAt:   now,
+		},
+	}
+	for i := range transactions {
+		if err := w.TransactionRepository.Create(tx, &transactions[i]); err != nil {
+			w.Log.WithError(err).Error("Failed to create pocket transaction")
+			return domain.NewError(fiber.StatusInternalServerError)
+		}
+	}
+
+	if err := w.AuditUseCase.Record(c, tx, &domain.AuditEvent{
+		UserID:     userID,
+		ActorID:    userID,
+		Action:     domain.AuditPocketMoved,
+		TargetType: domain.AuditTargetTransaction,
+		TargetID:   strconv.FormatInt(transactions[0].ID, 10),
+		Metadata: map[string]any{
+			"sof_number": source.WalletNumber,
+			"dof_number": destination.WalletNumber,
+			"amount":     req.Amount,
+			"currency":   currency,
+		},
+		Before: before,
+		After: map[string]any{
+			"sof_balance": sourceBalance.Balance,
+			"dof_balance": destinationBalance.Balance,
+		},
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}); err != nil {
+		w.Log.WithError(err).Warnf("Failed to record audit log: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		w.Log.WithError(err).Warnf("Failed to commit transaction: %+v", err)
+		return domain.NewError(fiber.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (w *WalletUseCase) findPocket(tx *gorm.DB, wallet *domain.WalletEntity, pocketID int64) (*domain.WalletEntity, error) {
+	pocket := new(domain.WalletEntity)
+	if err := w.WalletRepository.FindPocketByID(tx, pocket, wallet.ID, pocketID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewError(fiber.StatusNotFound, "Pocket not found")
+		}
+		w.Log.WithError(err).Warn("Failed to query pocket")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	return pocket, nil
+}
+
+// findPocketBalance loads the single balance a pocket is opened with.
+func (w *WalletUseCase) findPocketBalance(tx *gorm.DB, pocket *domain.WalletEntity) (*domain.WalletBalanceEntity, error) {
+	balances := new([]domain.WalletBalanceEntity)
+	if err := w.WalletBalanceRepository.FindByWalletID(tx, balances, pocket.ID); err != nil || len(*balances) == 0 {
+		w.Log.WithError(err).WithField("wallet_id", pocket.ID).Warn("Failed to query pocket balance")
+		return nil, domain.NewError(fiber.StatusInternalServerError)
+	}
+	return &(*balances)[0], nil
+}
+
+// resolvePocket finds one side of a move, the main wallet for a zero ID,
+// along with the currency the move is made in. Pockets hold one currency,
+// so both pocket sides have to agree on it.
+func (w *WalletUseCase) resolvePocket(tx *gorm.DB, wallet *domain.WalletEntity, pocketID int64, currency string) (*domain.WalletEntity, string, error) {
+	if pocketID == 0 {
+		return wallet, currency, nil
+	}
+
+	pocket, err := w.findPocket(tx, wallet, pocketID)
+	if err != nil {
+		return nil, "", err
+	}
+	balance, err := w.findPocketBalance(tx, pocket)
+	if err != nil {
+		return nil, "", err
+	}
+	if currency != "" && currency != balance.Currency {
+		return nil, "", domain.NewError(fiber.StatusBadRequest, "Both pockets have to hold the same currency")
+	}
+
+	return pocket, balance.Currency, nil
+}
+
+// parsePocketLock reads a lock-until date, which has to be in the future.
+func parsePocketLock(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	lockedUntil, err := time.ParseInLocation(pocketDateFormat, value, time.Local)
+	if err != nil {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Invalid lock date")
+	}
+	if !lockedUntil.After(time.Now()) {
+		return nil, domain.NewError(fiber.StatusBadRequest, "Lock date has to be in the future")
+	}
+
+	return &lockedUntil, nil
+}
+
+func pocketTarget(amount int64) *int64 {
+	if amount == 0 {
+		return nil
+	}
+	return &amount
+}
+
+func pocketAuditState(pocket *domain.WalletEntity) map[string]any {
+	return map[string]any{
+		"name":          pocket.Name,
+		"target_amount": pocket.TargetAmount,
+		"locked_until":  pocket.LockedUntil,
+	}
+}
+
+func toPocketData(pocket *domain.WalletEntity, balance *domain.WalletBalanceEntity) dto.PocketData {
+	data := dto.PocketData{
+		ID:           pocket.ID,
+		WalletNumber: pocket.WalletNumber,
+		Name:         pocket.Name,
+		Currency:     balance.Currency,
+		Balance:      balance.Balance,
+		Formatted:    util.FormatMoney(balance.Money()),
+		TargetAmount: pocket.TargetAmount,
+		CreatedAt:    pocket.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    pocket.UpdatedAt.Format(time.RFC3339),
+	}
+	if pocket.LockedUntil != nil {
+		data.LockedUntil = pocket.LockedUntil.Format(pocketDateFormat)
+	}
+	return data
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+
+	"riz.it/domped/app/domain"
+)
+
+// RenderSuspiciousActivityCSV renders the reported alerts as a suspicious
+// activity report, one alert per row with its notes joined in order.
+func RenderSuspiciousActivityCSV(reports []domain.SuspiciousActivityReport) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"alert_id", "type", "detected_at", "reported_at", "full_name", "nik", "email", "phone", "wallet_number", "amount", "details", "notes"},
+	}
+
+	for _, r := range reports {
+		reportedAt := ""
+		if r.Alert.ClosedAt != nil {
+			reportedAt = r.Alert.ClosedAt.Format(time.RFC3339)
+		}
+
+		rows = append(rows, []string{
+			strconv.FormatInt(r.Alert.ID, 10),
+			r.Alert.Type,
+			r.Alert.CreatedAt.Format(time.RFC3339),
+			reportedAt,
+			r.FullName,
+			r.NIK,
+			r.Email,
+			r.Phone,
+			r.WalletNumber,
+			strconv.FormatInt(r.Alert.Amount, 10),
+			r.Alert.Details,
+			strings.Join(r.Notes, "\n"),
+		})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
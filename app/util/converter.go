@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	humanize "github.com/dustin/go-humanize"
+	"riz.it/domped/app/domain"
 )
 
 func ConvertToSpaced(input string) string {
@@ -37,8 +38,45 @@ func ExtractIDFromReference(input string) (int64, error) {
 	return userID, nil
 }
 
-func CurrencyFormat(amount float64) string {
-	humanizeValue := humanize.CommafWithDigits(amount, 0)
-	stringValue := strings.Replace(humanizeValue, ",", ".", -1)
-	return "Rp " + stringValue
+// FormatMoney formats an amount with its currency's symbol and separators,
+// such as "Rp 1.500.000" or "US$ 12.50". A currency that is not supported is
+// shown by its code, without decimals.
+func FormatMoney(money domain.Money) string {
+	currency, ok := domain.FindCurrency(money.Currency)
+	if !ok {
+		currency = domain.Currency{Code: money.Currency, Symbol: money.Currency, Thousands: ",", Decimal: "."}
+	}
+
+	amount, sign := money.Amount, ""
+	if amount < 0 {
+		amount, sign = -amount, "-"
+	}
+
+	scale := int64(1)
+	for range currency.MinorUnits {
+		scale *= 10
+	}
+
+	value := strings.ReplaceAll(humanize.Comma(amount/scale), ",", currency.Thousands)
+	if currency.MinorUnits > 0 {
+		value += currency.Decimal + fmt.Sprintf("%0*d", currency.MinorUnits, amount%scale)
+	}
+
+	return sign + currency.Symbol + " " + value
+}
+
+// ParseIntOrDefault parses a positive integer setting, returning fallback
+// when it is empty or invalid.
+func ParseIntOrDefault(value string, fallback int) int {
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return fallback
+	}
+	return parsed
+}
+
+// NormalizeRecoveryCode lower-cases a recovery code and strips the separator
+// and spaces users may type.
+func NormalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
 }
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"testing"
+
+	"riz.it/domped/app/domain"
+)
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		name  string
+		money domain.Money
+		want  string
+	}{
+		{"rupiah", domain.NewMoney(1500000, "IDR"), "Rp 1.500.000"},
+		{"zero rupiah", domain.NewMoney(0, "IDR"), "Rp 0"},
+		{"yen", domain.NewMoney(1000, "JPY"), "¥ 1,000"},
+		{"dollars and cents", domain.NewMoney(123456, "USD"), "US$ 1,234.56"},
+		{"cents only", domain.NewMoney(5, "USD"), "US$ 0.05"},
+		{"negative", domain.NewMoney(-250, "SGD"), "-S$ 2.50"},
+		{"euro separators", domain.NewMoney(100000, "EUR"), "€ 1.000,00"},
+		{"unknown currency", domain.NewMoney(1234, "XYZ"), "XYZ 1,234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatMoney(tt.money); got != tt.want {
+				t.Errorf("FormatMoney(%d %s) = %q, want %q", tt.money.Amount, tt.money.Currency, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,42 +1,276 @@
 package util
 
 import (
-	"fmt"
-	"net/smtp"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
 
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 	"riz.it/domped/app/config"
 	"riz.it/domped/app/domain"
 )
 
+const (
+	emailQueueKey      = "email:queue"
+	emailProcessingKey = "email:processing:"
+	emailWorkersKey    = "email:workers"
+	emailRetryKey      = "email:retry"
+	emailDeadLetterKey = "email:dead"
+
+	// How long a worker's processing list stays its own without a heartbeat
+	emailWorkerLease = time.Minute
+)
+
+// EmailUtil sends email through the configured transport. With EMAIL_QUEUE
+// set to "redis", Send enqueues the message while a worker is running and Run
+// delivers it in the background, retrying failed deliveries with exponential
+// backoff; otherwise Send delivers it right away. Each worker keeps the job
+// it is handling on its own processing list under a lease it renews, and the
+// lists of workers whose lease expired are put back onto the queue, so a job
+// in flight when a worker dies is sent again rather than lost.
 type EmailUtil struct {
-	Config *config.Config
+	Config      *config.Config
+	Transport   domain.EmailTransport
+	Redis       *redis.Client
+	Log         *logrus.Logger
+	Queued      bool
+	MaxAttempts int
+}
+
+type emailJob struct {
+	ID       string              `json:"id"`
+	Attempts int                 `json:"attempts"`
+	Message  domain.EmailMessage `json:"message"`
 }
 
-func NewEmailUtil(config *config.Config) domain.Email {
+func NewEmailUtil(config *config.Config, transport domain.EmailTransport, redis *redis.Client, log *logrus.Logger) *EmailUtil {
+	maxAttempts, _ := strconv.Atoi(config.Email.MaxAttempts)
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
 	return &EmailUtil{
-		Config: config,
+		Config:      config,
+		Transport:   transport,
+		Redis:       redis,
+		Log:         log,
+		Queued:      config.Email.Queue == "redis",
+		MaxAttempts: maxAttempts,
 	}
 }
 
 // Send implements domain.Email.
-func (e *EmailUtil) Send(to string, subject string, body string) error {
-	// Set up authentication information.
-	auth := smtp.PlainAuth("", e.Config.SMTP.User, e.Config.SMTP.Password, e.Config.SMTP.Host)
+func (e *EmailUtil) Send(ctx context.Context, msg *domain.EmailMessage) error {
+	if !e.Queued || !e.workerRunning(ctx) {
+		return e.Transport.Deliver(ctx, e.from(), msg)
+	}
+
+	return e.enqueue(ctx, &emailJob{
+		ID:      GenerateUUID(),
+		Message: *msg,
+	})
+}
+
+// Run implements domain.EmailQueue.
+func (e *EmailUtil) Run(ctx context.Context) {
+	if !e.Queued {
+		return
+	}
+
+	workerID := GenerateUUID()
+	processingKey := emailProcessingKey + workerID
+
+	e.Log.WithField("worker_id", workerID).Info("Email queue worker started")
+
+	for ctx.Err() == nil {
+		if err := e.renewLease(ctx, workerID); err != nil && ctx.Err() == nil {
+			e.Log.WithError(err).Warn("Failed to renew email worker lease")
+		}
 
-	// Construct the email message with proper headers for HTML content.
-	msg := []byte(
-		"From: " + e.Config.SMTP.User + "\n" +
-			"To: " + to + "\n" +
-			"Subject: " + subject + "\n" +
-			"Content-Type: text/html; charset=UTF-8\n\n" + // Add Content-Type header for HTML
-			body,
-	)
+		if err := e.recoverExpired(ctx); err != nil && ctx.Err() == nil {
+			e.Log.WithError(err).Warn("Failed to requeue unfinished emails")
+		}
 
-	// Send the email.
-	serverAddr := fmt.Sprintf("%s:%s", e.Config.SMTP.Host, e.Config.SMTP.Port)
-	if err := smtp.SendMail(serverAddr, auth, e.Config.SMTP.User, []string{to}, msg); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		if err := e.promoteRetries(ctx); err != nil && ctx.Err() == nil {
+			e.Log.WithError(err).Warn("Failed to promote email retries")
+		}
+
+		payload, err := e.Redis.BLMove(ctx, emailQueueKey, processingKey, "LEFT", "RIGHT", 5*time.Second).Result()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+				e.Log.WithError(err).Warn("Failed to pop email queue")
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+
+		job := new(emailJob)
+		if err := json.Unmarshal([]byte(payload), job); err != nil {
+			e.Log.WithError(err).Error("Failed to decode email job")
+		} else {
+			e.process(ctx, job)
+		}
+
+		// A shutdown mid-send leaves the job on the processing list to be
+		// requeued below; otherwise it is sent, rescheduled or dead-lettered
+		if ctx.Err() != nil {
+			break
+		}
+		if err := e.Redis.LRem(ctx, processingKey, 1, payload).Err(); err != nil {
+			e.Log.WithError(err).Warn("Failed to acknowledge email job")
+		}
+	}
+
+	// The worker's context is done, so it hands its list back with a fresh
+	// one; should that fail the list is recovered once the lease expires
+	c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := e.requeue(c, processingKey); err != nil {
+		e.Log.WithError(err).Warn("Failed to requeue unfinished emails")
+	} else if err := e.Redis.ZRem(c, emailWorkersKey, workerID).Err(); err != nil {
+		e.Log.WithError(err).Warn("Failed to release email worker lease")
+	}
+
+	e.Log.WithField("worker_id", workerID).Info("Email queue worker stopped")
+}
+
+func (e *EmailUtil) process(ctx context.Context, job *emailJob) {
+	c, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	err := e.Transport.Deliver(c, e.from(), &job.Message)
+	if err == nil {
+		return
+	}
+
+	job.Attempts++
+	log := e.Log.WithError(err).WithFields(logrus.Fields{
+		"email_id": job.ID,
+		"attempts": job.Attempts,
+	})
+
+	payload, marshalErr := json.Marshal(job)
+	if marshalErr != nil {
+		log.Error("Failed to encode email job")
+		return
+	}
+
+	if job.Attempts >= e.MaxAttempts {
+		log.Error("Email delivery failed permanently")
+		if err := e.Redis.RPush(ctx, emailDeadLetterKey, payload).Err(); err != nil {
+			e.Log.WithError(err).Error("Failed to store dead email")
+		}
+		return
+	}
+
+	// Back off 30s, 1m, 2m, ... before the next attempt
+	retryAt := time.Now().Add(30 * time.Second << (job.Attempts - 1))
+	log.Warn("Email delivery failed, scheduling retry")
+	if err := e.Redis.ZAdd(ctx, emailRetryKey, redis.Z{Score: float64(retryAt.Unix()), Member: payload}).Err(); err != nil {
+		e.Log.WithError(err).Error("Failed to schedule email retry")
+	}
+}
+
+// workerRunning reports whether a worker holds a live lease and will pick up
+// queued emails. When Redis can't tell, the email is sent right away.
+func (e *EmailUtil) workerRunning(ctx context.Context) bool {
+	count, err := e.Redis.ZCount(ctx, emailWorkersKey, strconv.FormatInt(time.Now().Unix(), 10), "+inf").Result()
+	if err != nil {
+		e.Log.WithError(err).Warn("Failed to look up email workers, sending directly")
+		return false
+	}
+	return count > 0
+}
+
+// renewLease extends the worker's hold on its processing list.
+func (e *EmailUtil) renewLease(ctx context.Context, workerID string) error {
+	expiresAt := time.Now().Add(emailWorkerLease)
+	return e.Redis.ZAdd(ctx, emailWorkersKey, redis.Z{Score: float64(expiresAt.Unix()), Member: workerID}).Err()
+}
+
+// recoverExpired puts the jobs of workers whose lease expired, having
+// stopped mid-send, back onto the queue. Those emails may be sent twice.
+func (e *EmailUtil) recoverExpired(ctx context.Context) error {
+	expired, err := e.Redis.ZRangeByScore(ctx, emailWorkersKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, workerID := range expired {
+		// Only the worker that removes the lease recovers its list
+		removed, err := e.Redis.ZRem(ctx, emailWorkersKey, workerID).Result()
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			continue
+		}
+		if err := e.requeue(ctx, emailProcessingKey+workerID); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// requeue moves every job on a processing list back onto the queue.
+func (e *EmailUtil) requeue(ctx context.Context, processingKey string) error {
+	for {
+		err := e.Redis.LMove(ctx, processingKey, emailQueueKey, "LEFT", "LEFT").Err()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// promoteRetries moves retries that are due back onto the queue.
+func (e *EmailUtil) promoteRetries(ctx context.Context) error {
+	due, err := e.Redis.ZRangeByScore(ctx, emailRetryKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, payload := range due {
+		// Only the worker that removes the entry requeues it
+		removed, err := e.Redis.ZRem(ctx, emailRetryKey, payload).Result()
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			continue
+		}
+		if err := e.Redis.RPush(ctx, emailQueueKey, payload).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *EmailUtil) enqueue(ctx context.Context, job *emailJob) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return e.Redis.RPush(ctx, emailQueueKey, payload).Err()
+}
+
+func (e *EmailUtil) from() string {
+	if e.Config.SMTP.From != "" {
+		return e.Config.SMTP.From
+	}
+	return e.Config.SMTP.User
+}
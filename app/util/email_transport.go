@@ -0,0 +1,180 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+)
+
+// NewEmailTransport returns the transport selected by EMAIL_TRANSPORT:
+// "file" appends to an mbox file, "memory" keeps messages in memory and
+// anything else delivers over SMTP.
+func NewEmailTransport(config *config.Config) domain.EmailTransport {
+	switch config.Email.Transport {
+	case "file":
+		path := config.Email.FilePath
+		if path == "" {
+			path = "tmp/mail.mbox"
+		}
+		return &FileEmailTransport{Path: path}
+	case "memory":
+		return &MemoryEmailTransport{}
+	default:
+		return &SMTPEmailTransport{Config: &config.SMTP}
+	}
+}
+
+// SMTPEmailTransport delivers over SMTP, upgrading the connection with
+// STARTTLS. Port 465 uses implicit TLS. A server that offers neither is
+// refused unless plaintext is explicitly allowed.
+type SMTPEmailTransport struct {
+	Config *config.SMTP
+}
+
+// Deliver implements domain.EmailTransport.
+func (s *SMTPEmailTransport) Deliver(ctx context.Context, from string, msg *domain.EmailMessage) error {
+	raw, err := BuildEmailMessage(from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.Config.Host, s.Config.Port)
+	tlsConfig := &tls.Config{ServerName: s.Config.Host}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	var conn net.Conn
+	if s.Config.Port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.Config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create smtp client: %w", err)
+	}
+	defer client.Close()
+
+	if s.Config.Port != "465" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("failed to start tls: %w", err)
+			}
+		} else if !s.Config.AllowPlaintext {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", s.Config.Host)
+		}
+	}
+
+	if ok, _ := client.Extension("AUTH"); ok && s.Config.User != "" {
+		auth := smtp.PlainAuth("", s.Config.User, s.Config.Password, s.Config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", to, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start data: %w", err)
+	}
+	if _, err := writer.Write(raw); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// FileEmailTransport appends every message to an mbox file, for development.
+type FileEmailTransport struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Deliver implements domain.EmailTransport.
+func (f *FileEmailTransport) Deliver(ctx context.Context, from string, msg *domain.EmailMessage) error {
+	now := time.Now()
+	raw, err := BuildEmailMessage(from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	buf.WriteString("From " + from + " " + now.Format(time.ANSIC) + "\n")
+	for _, line := range strings.Split(strings.ReplaceAll(string(raw), "\r\n", "\n"), "\n") {
+		// Escape lines that would be read as a message separator
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = ">" + line
+		}
+		buf.WriteString(line + "\n")
+	}
+	buf.WriteString("\n")
+
+	_, err = file.Write(buf.Bytes())
+	return err
+}
+
+// MemoryEmailTransport keeps delivered messages in memory, for tests.
+type MemoryEmailTransport struct {
+	mu       sync.Mutex
+	Messages []domain.EmailMessage
+}
+
+// Deliver implements domain.EmailTransport.
+func (m *MemoryEmailTransport) Deliver(ctx context.Context, from string, msg *domain.EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Messages = append(m.Messages, *msg)
+	return nil
+}
+
+// Delivered returns a copy of every message delivered so far.
+func (m *MemoryEmailTransport) Delivered() []domain.EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]domain.EmailMessage(nil), m.Messages...)
+}
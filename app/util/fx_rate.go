@@ -0,0 +1,176 @@
+package util
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+)
+
+// defaultFXRates are the rates used offline when FX_RATES_FILE is not set.
+//
+//go:embed fx_rates.json
+var defaultFXRates []byte
+
+// NewFXRateUtil returns the rate provider selected by FX_PROVIDER. Anything
+// other than "http" falls back to the static provider, which reads rates
+// from FX_RATES_FILE or the bundled table.
+func NewFXRateUtil(config *config.Config, log *logrus.Logger) domain.FXRates {
+	if config.FX.Provider != "http" {
+		return &StaticFXRateUtil{
+			Path: config.FX.RatesFile,
+		}
+	}
+
+	return &HTTPFXRateUtil{
+		Endpoint: config.FX.Endpoint,
+		APIKey:   config.FX.APIKey,
+		TTL:      time.Duration(ParseIntOrDefault(config.FX.RateTTL, 300)) * time.Second,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		Log:      log,
+	}
+}
+
+// fxRateTable lists what one unit of Base is worth in each currency. Rates
+// are decimal strings or numbers, kept exact as json.Number.
+type fxRateTable struct {
+	Base  string                 `json:"base"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+func parseFXRateTable(content []byte) (*fxRateTable, error) {
+	table := new(fxRateTable)
+	if err := json.Unmarshal(content, table); err != nil {
+		return nil, fmt.Errorf("failed to parse fx rates: %w", err)
+	}
+	return table, nil
+}
+
+// rate derives the cross rate between two currencies through the base.
+func (t *fxRateTable) rate(base, quote string) (*big.Rat, error) {
+	baseRate, err := t.lookup(base)
+	if err != nil {
+		return nil, err
+	}
+	quoteRate, err := t.lookup(quote)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(quoteRate, baseRate), nil
+}
+
+func (t *fxRateTable) lookup(currency string) (*big.Rat, error) {
+	if currency == t.Base {
+		return big.NewRat(1, 1), nil
+	}
+
+	value, ok := t.Rates[currency]
+	if !ok {
+		return nil, fmt.Errorf("no fx rate for %s", currency)
+	}
+	rate, ok := new(big.Rat).SetString(value.String())
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid fx rate for %s: %s", currency, value)
+	}
+	return rate, nil
+}
+
+// StaticFXRateUtil reads rates from a JSON file on every lookup, so the
+// file can be edited without a restart.
+type StaticFXRateUtil struct {
+	Path string
+}
+
+// Rate implements domain.FXRates.
+func (s *StaticFXRateUtil) Rate(ctx context.Context, base, quote string) (*big.Rat, error) {
+	content := defaultFXRates
+	if s.Path != "" {
+		var err error
+		if content, err = os.ReadFile(s.Path); err != nil {
+			return nil, fmt.Errorf("failed to read fx rates: %w", err)
+		}
+	}
+
+	table, err := parseFXRateTable(content)
+	if err != nil {
+		return nil, err
+	}
+	return table.rate(base, quote)
+}
+
+// HTTPFXRateUtil fetches the rate table from a provider endpoint and keeps
+// it for TTL.
+type HTTPFXRateUtil struct {
+	Endpoint string
+	APIKey   string
+	TTL      time.Duration
+	Client   *http.Client
+	Log      *logrus.Logger
+
+	mu        sync.Mutex
+	table     *fxRateTable
+	fetchedAt time.Time
+}
+
+// Rate implements domain.FXRates.
+func (h *HTTPFXRateUtil) Rate(ctx context.Context, base, quote string) (*big.Rat, error) {
+	table, err := h.rates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return table.rate(base, quote)
+}
+
+func (h *HTTPFXRateUtil) rates(ctx context.Context) (*fxRateTable, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.table != nil && time.Since(h.fetchedAt) < h.TTL {
+		return h.table, nil
+	}
+
+	table, err := h.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h.table, h.fetchedAt = table, time.Now()
+	h.Log.WithField("base", table.Base).Debug("Fetched fx rates")
+	return table, nil
+}
+
+func (h *HTTPFXRateUtil) fetch(ctx context.Context) (*fxRateTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.Endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if h.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.APIKey)
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fx rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("fx provider responded with status %d", resp.StatusCode)
+	}
+
+	table := new(fxRateTable)
+	if err := json.NewDecoder(resp.Body).Decode(table); err != nil {
+		return nil, fmt.Errorf("failed to parse fx rates: %w", err)
+	}
+	return table, nil
+}
@@ -80,3 +80,10 @@ func GenerateWalletNumber(length int) (string, error) {
 
 	return string(walletNumber), nil
 }
+
+// GenerateRecoveryCode generates a single-use two-factor recovery code in
+// the form xxxxx-xxxxx.
+func GenerateRecoveryCode() string {
+	code := GenerateRandomHex(10)
+	return code[:5] + "-" + code[5:]
+}
@@ -1,113 +1,353 @@
 package util
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
 	"riz.it/domped/app/config"
 	"riz.it/domped/app/domain"
+	"riz.it/domped/app/dto"
 )
 
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+
+	defaultJWTKeyDir      = "keys/jwt"
+	defaultJWTAlgorithm   = "EdDSA"
+	defaultJWTKeyRotation = 720
+
+	// How often an unknown kid may trigger a reload of the key directory,
+	// to pick up keys rotated by another instance
+	jwtKeyReloadInterval = 10 * time.Second
+)
+
+var errUnknownJWTKey = errors.New("unknown jwt key")
+
+// JWTUtil signs tokens with the newest private key in the keyset and
+// verifies them with any key in it, selected by the kid header. Keys are PEM
+// files in JWT_KEY_DIR shared by every instance; RotateKeys adds a new key
+// every JWT_KEY_ROTATION hours and removes keys no valid token can still be
+// signed with. A key is only ever used once it is stored, so an instance
+// that can't write the directory needs its keys provisioned in advance.
 type JWTUtil struct {
 	Config *config.Config
+	Log    *logrus.Logger
+
+	mu         sync.RWMutex
+	keys       map[string]*jwtKey
+	signingKey *jwtKey
+	loadedAt   time.Time
 }
 
-func NewJWTUtil(config *config.Config) domain.JWT {
-	return &JWTUtil{
+func NewJWTUtil(config *config.Config, log *logrus.Logger) domain.JWT {
+	j := &JWTUtil{
 		Config: config,
+		Log:    log,
 	}
+	if err := j.load(true); err != nil {
+		// Without a stored key to sign with no instance could verify our
+		// tokens; a key that is only due for rotation keeps signing until an
+		// instance that can write the key directory rotates it
+		if reloadErr := j.load(false); reloadErr != nil || j.signingKey == nil {
+			log.Fatalf("failed to load jwt keys: %v", err)
+		}
+		log.WithError(err).Warn("Failed to rotate jwt keys")
+	}
+
+	return j
 }
 
-// GenerateTokens implements domain.JWTHelper.
-func (j *JWTUtil) GenerateToken(userID int64) (string, string, error) {
-	iss := j.Config.Server.Host
-	aud := j.Config.Server.Host
+// GenerateToken implements domain.JWT.
+func (j *JWTUtil) GenerateToken(userID int64, sessionID int64) (*domain.TokenPair, error) {
 	accessExpTime, _ := strconv.Atoi(j.Config.Jwt.AccessTokenExp)
 	refreshExpTime, _ := strconv.Atoi(j.Config.Jwt.RefreshTokenExp)
-	exp := time.Now().Add(time.Hour * time.Duration(accessExpTime)).Unix()
-	expRefresh := time.Now().Add(time.Hour * time.Duration(refreshExpTime)).Unix()
-	sub := userID
+	now := time.Now()
 
-	accessKey := j.Config.Jwt.AccessTokenKey
-	refreshKey := j.Config.Jwt.RefreshTokenKey
-
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"iss": iss,
-			"aud": aud,
-			"exp": exp,
-			"sub": sub,
-		})
+	accessClaims := domain.TokenClaims{
+		ID:        GenerateUUID(),
+		UserID:    userID,
+		SessionID: sessionID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(time.Hour * time.Duration(accessExpTime)),
+	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"iss": iss,
-			"aud": aud,
-			"exp": expRefresh,
-			"sub": sub,
-		})
+	refreshClaims := domain.TokenClaims{
+		ID:        GenerateUUID(),
+		UserID:    userID,
+		SessionID: sessionID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(time.Hour * time.Duration(refreshExpTime)),
+	}
 
-	signedAccessToken, err := accessToken.SignedString([]byte(accessKey))
+	// Access tokens are meant for every service trusting our keys; refresh
+	// tokens are only ever presented back to us
+	signedAccessToken, err := j.sign(accessClaims, tokenTypeAccess, j.audience())
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	signedRefreshToken, err := refreshToken.SignedString([]byte(refreshKey))
+	signedRefreshToken, err := j.sign(refreshClaims, tokenTypeRefresh, j.issuer())
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	return signedAccessToken, signedRefreshToken, nil
+	return &domain.TokenPair{
+		AccessToken:   signedAccessToken,
+		AccessClaims:  accessClaims,
+		RefreshToken:  signedRefreshToken,
+		RefreshClaims: refreshClaims,
+	}, nil
+}
+
+// ValidateAccessToken implements domain.JWT.
+func (j *JWTUtil) ValidateAccessToken(tokenString string) (*domain.TokenClaims, error) {
+	return j.validate(tokenString, tokenTypeAccess, j.audience())
+}
+
+// ValidateRefreshToken implements domain.JWT.
+func (j *JWTUtil) ValidateRefreshToken(tokenString string) (*domain.TokenClaims, error) {
+	return j.validate(tokenString, tokenTypeRefresh, j.issuer())
+}
+
+// JWKS implements domain.JWT.
+func (j *JWTUtil) JWKS() *dto.JWKSResponse {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	result := &dto.JWKSResponse{Keys: []dto.JWK{}}
+	for _, key := range j.keys {
+		result.Keys = append(result.Keys, key.jwk())
+	}
 
+	return result
 }
 
-// ValidateAccessToken implements domain.JWTHelper.
-func (j *JWTUtil) ValidateAccessToken(tokenString string) (int64, error) {
+// RotateKeys implements domain.JWT.
+func (j *JWTUtil) RotateKeys(ctx context.Context) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.load(true); err != nil {
+		j.Log.WithError(err).Error("Failed to rotate jwt keys")
+	}
+}
+
+func (j *JWTUtil) sign(claims domain.TokenClaims, tokenType string, audience string) (string, error) {
+	j.mu.RLock()
+	key := j.signingKey
+	j.mu.RUnlock()
+
+	if key == nil {
+		return "", errors.New("no jwt signing key available")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm),
+		jwt.MapClaims{
+			"iss": j.issuer(),
+			"aud": audience,
+			"typ": tokenType,
+			"jti": claims.ID,
+			"sub": claims.UserID,
+			"sid": claims.SessionID,
+			"iat": claims.IssuedAt.Unix(),
+			"exp": claims.ExpiresAt.Unix(),
+		})
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+func (j *JWTUtil) validate(tokenString string, tokenType string, audience string) (*domain.TokenClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key := j.verificationKey(kid)
+		if key == nil {
+			return nil, errUnknownJWTKey
+		}
+
+		// The algorithm is bound to the key, never taken from the token
+		if token.Method.Alg() != key.Algorithm {
 			return nil, jwt.ErrInvalidKey
 		}
-		return []byte(j.Config.Jwt.AccessTokenKey), nil
+
+		return key.PublicKey, nil
 	})
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Konversi sub ke float64, lalu ubah ke int64
-		if sub, ok := claims["sub"].(float64); ok {
-			userID := int64(sub)
-			return userID, nil
-		}
-		return 0, jwt.ErrInvalidKey
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrInvalidKey
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenType {
+		return nil, jwt.ErrInvalidKey
+	}
+
+	if !claims.VerifyIssuer(j.issuer(), true) || !claims.VerifyAudience(audience, true) {
+		return nil, jwt.ErrInvalidKey
+	}
+
+	// Numeric claims are decoded as float64
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return nil, jwt.ErrInvalidKey
+	}
+
+	result := &domain.TokenClaims{
+		UserID: int64(sub),
+	}
+	result.ID, _ = claims["jti"].(string)
+	if sid, ok := claims["sid"].(float64); ok {
+		result.SessionID = int64(sid)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		result.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		result.ExpiresAt = time.Unix(int64(exp), 0)
 	}
 
-	return 0, jwt.ErrInvalidKey
+	return result, nil
 }
 
-// ValidateRefreshToken implements domain.JWTHelper.
-func (j *JWTUtil) ValidateRefreshToken(tokenString string) (int64, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrInvalidKey
-		}
-		return []byte(j.Config.Jwt.RefreshTokenKey), nil
-	})
+// verificationKey returns the key with the given ID, reloading the key
+// directory when the key is unknown.
+func (j *JWTUtil) verificationKey(kid string) *jwtKey {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	loadedAt := j.loadedAt
+	j.mu.RUnlock()
+
+	if ok || kid == "" || time.Since(loadedAt) < jwtKeyReloadInterval {
+		return key
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.load(false); err != nil {
+		j.Log.WithError(err).Warn("Failed to reload jwt keys")
+	}
+
+	return j.keys[kid]
+}
 
+// load replaces the keyset with the keys in the key directory. With rotate
+// set it also creates a signing key when the newest one is due for rotation
+// and removes retired keys. Callers must hold the write lock.
+func (j *JWTUtil) load(rotate bool) error {
+	now := time.Now()
+	dir := j.keyDir()
+
+	keys, err := loadJWTKeys(dir)
 	if err != nil {
-		return 0, err
+		return err
+	}
+
+	var signingKey *jwtKey
+	for _, key := range keys {
+		if key.PrivateKey != nil && (signingKey == nil || key.CreatedAt.After(signingKey.CreatedAt)) {
+			signingKey = key
+		}
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Konversi sub ke float64, lalu ubah ke int64
-		if sub, ok := claims["sub"].(float64); ok {
-			userID := int64(sub)
-			return userID, nil
+	rotation := j.rotation()
+	if rotate && (signingKey == nil || (rotation > 0 && now.Sub(signingKey.CreatedAt) >= rotation)) {
+		key, content, err := generateJWTKey(j.algorithm(), now)
+		if err != nil {
+			return err
+		}
+
+		// Other instances only learn of the key from the directory, so one
+		// that can't be stored is never used
+		key.Path = filepath.Join(dir, key.ID+".pem")
+		if err := writeJWTKey(key.Path, content); err != nil {
+			return fmt.Errorf("failed to store jwt key: %w", err)
 		}
-		return 0, jwt.ErrInvalidKey
+
+		keys = append(keys, key)
+		signingKey = key
+		j.Log.WithField("kid", key.ID).Info("Rotated jwt signing key")
 	}
 
-	return 0, jwt.ErrInvalidKey
+	j.keys = map[string]*jwtKey{}
+	for _, key := range keys {
+		// A key stops signing once the next one is created, at most an hour
+		// after it is due, and its last tokens expire a refresh token
+		// lifetime after that
+		if rotate && rotation > 0 && key != signingKey && key.PrivateKey != nil &&
+			now.Sub(key.CreatedAt) > rotation+j.refreshLifetime()+time.Hour {
+			if err := os.Remove(key.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				j.Log.WithError(err).WithField("kid", key.ID).Warn("Failed to remove retired jwt key")
+			}
+			continue
+		}
+		j.keys[key.ID] = key
+	}
+
+	j.signingKey = signingKey
+	j.loadedAt = now
+
+	return nil
+}
+
+func writeJWTKey(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+func (j *JWTUtil) keyDir() string {
+	if j.Config.Jwt.KeyDir == "" {
+		return defaultJWTKeyDir
+	}
+	return j.Config.Jwt.KeyDir
+}
+
+func (j *JWTUtil) algorithm() string {
+	if j.Config.Jwt.Algorithm == "" {
+		return defaultJWTAlgorithm
+	}
+	return j.Config.Jwt.Algorithm
+}
+
+// rotation returns the signing key lifetime; zero disables rotation.
+func (j *JWTUtil) rotation() time.Duration {
+	hours, err := strconv.Atoi(j.Config.Jwt.KeyRotation)
+	if err != nil {
+		hours = defaultJWTKeyRotation
+	}
+	return time.Hour * time.Duration(hours)
+}
+
+func (j *JWTUtil) refreshLifetime() time.Duration {
+	hours, _ := strconv.Atoi(j.Config.Jwt.RefreshTokenExp)
+	return time.Hour * time.Duration(hours)
+}
+
+func (j *JWTUtil) issuer() string {
+	if j.Config.Jwt.Issuer == "" {
+		return j.Config.Server.Host
+	}
+	return j.Config.Jwt.Issuer
+}
+
+func (j *JWTUtil) audience() string {
+	if j.Config.Jwt.Audience == "" {
+		return j.issuer()
+	}
+	return j.Config.Jwt.Audience
 }
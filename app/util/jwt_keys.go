@@ -0,0 +1,165 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"riz.it/domped/app/dto"
+)
+
+// jwtKey is one key of the signing keyset. Keys loaded from a public key file
+// can only verify tokens.
+type jwtKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+	CreatedAt  time.Time
+	Path       string
+}
+
+// loadJWTKeys reads every PEM file in dir. The file name without its
+// extension is the key ID and the file's modification time its age.
+func loadJWTKeys(dir string) ([]*jwtKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var keys []*jwtKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parseJWTKey(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt key %s: %w", entry.Name(), err)
+		}
+		key.ID = strings.TrimSuffix(entry.Name(), ".pem")
+		key.CreatedAt = info.ModTime()
+		key.Path = path
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func parseJWTKey(content []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &jwtKey{Algorithm: "RS256", PrivateKey: k, PublicKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &jwtKey{Algorithm: "RS256", PublicKey: k}, nil
+	case ed25519.PrivateKey:
+		return &jwtKey{Algorithm: "EdDSA", PrivateKey: k, PublicKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &jwtKey{Algorithm: "EdDSA", PublicKey: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// generateJWTKey creates a new private key for the algorithm, RS256 or
+// EdDSA, and returns it with its PKCS#8 PEM encoding.
+func generateJWTKey(algorithm string, now time.Time) (*jwtKey, []byte, error) {
+	key := &jwtKey{
+		ID:        now.UTC().Format("20060102T150405") + "-" + GenerateRandomHex(4),
+		Algorithm: algorithm,
+		CreatedAt: now,
+	}
+
+	switch algorithm {
+	case "RS256":
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, nil, err
+		}
+		key.PrivateKey, key.PublicKey = private, &private.PublicKey
+	case "EdDSA":
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		key.PrivateKey, key.PublicKey = private, public
+	default:
+		return nil, nil, fmt.Errorf("unsupported jwt algorithm %q", algorithm)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// jwk returns the public half of the key as a JSON Web Key.
+func (k *jwtKey) jwk() dto.JWK {
+	jwk := dto.JWK{
+		Kid: k.ID,
+		Use: "sig",
+		Alg: k.Algorithm,
+	}
+
+	switch public := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
@@ -0,0 +1,179 @@
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"riz.it/domped/app/domain"
+)
+
+// BuildEmailMessage encodes msg as an RFC 5322 message. Text and HTML bodies
+// are sent as multipart/alternative, wrapped in multipart/mixed when the
+// message has attachments.
+func BuildEmailMessage(from string, msg *domain.EmailMessage, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writeHeader(&buf, "From", from)
+	writeHeader(&buf, "To", strings.Join(msg.To, ", "))
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", messageID(from, now))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	bodyType, body, err := buildBody(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(msg.Attachments) == 0 {
+		writeHeader(&buf, "Content-Type", bodyType)
+		if !strings.HasPrefix(bodyType, "multipart/") {
+			writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		}
+		buf.WriteString("\r\n")
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	var mixed bytes.Buffer
+	writer := multipart.NewWriter(&mixed)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", bodyType)
+	if !strings.HasPrefix(bodyType, "multipart/") {
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+	}
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(body); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, attachment.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	writeHeader(&buf, "Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+	buf.WriteString("\r\n")
+	buf.Write(mixed.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// buildBody returns the content type and encoded body for the text parts.
+func buildBody(msg *domain.EmailMessage) (string, []byte, error) {
+	if msg.Text == "" || msg.HTML == "" {
+		contentType := "text/plain; charset=UTF-8"
+		content := msg.Text
+		if msg.HTML != "" {
+			contentType = "text/html; charset=UTF-8"
+			content = msg.HTML
+		}
+
+		body, err := encodeQuotedPrintable(content)
+		return contentType, body, err
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return "", nil, err
+		}
+
+		body, err := encodeQuotedPrintable(p.content)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := part.Write(body); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", nil, err
+	}
+
+	return "multipart/alternative; boundary=" + writer.Boundary(), buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+func messageID(from string, now time.Time) string {
+	host := "localhost"
+	if at := strings.LastIndex(from, "@"); at != -1 {
+		host = strings.Trim(from[at+1:], "> ")
+	}
+	return fmt.Sprintf("<%d.%s@%s>", now.UnixNano(), GenerateRandomHex(16), host)
+}
+
+func encodeQuotedPrintable(content string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := quotedprintable.NewWriter(&buf)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes content base64-encoded in lines of 76 characters.
+func writeBase64(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		if _, err := w.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := w.Write([]byte(encoded + "\r\n"))
+	return err
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidNIK = errors.New("invalid NIK")
+
+// NIK holds the data encoded in an Indonesian national identity number.
+type NIK struct {
+	ProvinceCode string
+	RegencyCode  string
+	DistrictCode string
+	BirthDate    time.Time
+	Female       bool
+}
+
+// ParseNIK validates the structure of a 16-digit NIK: a known province
+// code, non-zero regency and district codes, a real birth date (with 40
+// added to the day for women) and a non-zero serial number.
+func ParseNIK(nik string) (*NIK, error) {
+	if len(nik) != 16 {
+		return nil, ErrInvalidNIK
+	}
+	for _, r := range nik {
+		if r < '0' || r > '9' {
+			return nil, ErrInvalidNIK
+		}
+	}
+
+	province, _ := strconv.Atoi(nik[0:2])
+	if province < 11 || province > 96 {
+		return nil, ErrInvalidNIK
+	}
+	if nik[2:4] == "00" || nik[4:6] == "00" || nik[12:16] == "0000" {
+		return nil, ErrInvalidNIK
+	}
+
+	day, _ := strconv.Atoi(nik[6:8])
+	month, _ := strconv.Atoi(nik[8:10])
+	year, _ := strconv.Atoi(nik[10:12])
+
+	female := day > 40
+	if female {
+		day -= 40
+	}
+
+	// Two-digit years after the current one belong to the last century
+	now := time.Now()
+	year += 2000
+	if year > now.Year() {
+		year -= 100
+	}
+
+	birthDate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if day < 1 || month < 1 || month > 12 || birthDate.Day() != day || birthDate.After(now) {
+		return nil, ErrInvalidNIK
+	}
+
+	return &NIK{
+		ProvinceCode: nik[0:2],
+		RegencyCode:  nik[2:4],
+		DistrictCode: nik[4:6],
+		BirthDate:    birthDate,
+		Female:       female,
+	}, nil
+}
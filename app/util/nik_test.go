@@ -0,0 +1,69 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseNIK(t *testing.T) {
+	tests := []struct {
+		name      string
+		nik       string
+		birthDate time.Time
+		female    bool
+	}{
+		{"male", "3171011501900001", time.Date(1990, time.January, 15, 0, 0, 0, 0, time.UTC), false},
+		{"female", "3171015501900001", time.Date(1990, time.January, 15, 0, 0, 0, 0, time.UTC), true},
+		{"born this century", "3273022912000123", time.Date(2000, time.December, 29, 0, 0, 0, 0, time.UTC), false},
+		{"leap day", "1101012902040001", time.Date(2004, time.February, 29, 0, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nik, err := ParseNIK(tt.nik)
+			if err != nil {
+				t.Fatalf("ParseNIK(%s) error = %v", tt.nik, err)
+			}
+			if !nik.BirthDate.Equal(tt.birthDate) {
+				t.Errorf("BirthDate = %s, want %s", nik.BirthDate, tt.birthDate)
+			}
+			if nik.Female != tt.female {
+				t.Errorf("Female = %v, want %v", nik.Female, tt.female)
+			}
+			if nik.ProvinceCode != tt.nik[0:2] || nik.RegencyCode != tt.nik[2:4] || nik.DistrictCode != tt.nik[4:6] {
+				t.Errorf("region = %s/%s/%s, want the first six digits of %s", nik.ProvinceCode, nik.RegencyCode, nik.DistrictCode, tt.nik)
+			}
+		})
+	}
+}
+
+func TestParseNIKInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		nik  string
+	}{
+		{"too short", "317101150190000"},
+		{"too long", "31710115019000011"},
+		{"not a number", "31710115019A0001"},
+		{"unknown province", "1071011501900001"},
+		{"province out of range", "9771011501900001"},
+		{"zero regency", "3100011501900001"},
+		{"zero district", "3171001501900001"},
+		{"zero serial", "3171011501900000"},
+		{"zero day", "3171010001900001"},
+		{"day 32", "3171013201900001"},
+		{"female day 72", "3171017201900001"},
+		{"month 13", "3171011513900001"},
+		{"february 30", "3171013002900001"},
+		{"not a leap year", "3171012902010001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseNIK(tt.nik); !errors.Is(err, ErrInvalidNIK) {
+				t.Errorf("ParseNIK(%s) error = %v, want %v", tt.nik, err, ErrInvalidNIK)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+)
+
+const defaultStoragePath = "storage"
+
+// NewObjectStoreUtil returns the object store selected by STORAGE_PROVIDER.
+// Only the local filesystem store is available for now.
+func NewObjectStoreUtil(config *config.Config) domain.ObjectStore {
+	root := config.Storage.LocalPath
+	if root == "" {
+		root = defaultStoragePath
+	}
+
+	return &LocalObjectStoreUtil{
+		Root: root,
+	}
+}
+
+// LocalObjectStoreUtil stores objects as files below Root, readable only by
+// the service user.
+type LocalObjectStoreUtil struct {
+	Root string
+}
+
+// Put implements domain.ObjectStore.
+func (l *LocalObjectStoreUtil) Put(ctx context.Context, key string, content []byte) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get implements domain.ObjectStore.
+func (l *LocalObjectStoreUtil) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, domain.ErrObjectNotFound
+	}
+	return content, err
+}
+
+// Delete implements domain.ObjectStore.
+func (l *LocalObjectStoreUtil) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a key to a file below the root, refusing keys that would
+// escape it.
+func (l *LocalObjectStoreUtil) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.Root, clean), nil
+}
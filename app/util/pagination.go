@@ -0,0 +1,27 @@
+package util
+
+import "riz.it/domped/app/dto"
+
+const DefaultPageSize = 20
+
+// NormalizePage fills in the first page and the default size for a listing
+// request that left them out.
+func NormalizePage(page int, size int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = DefaultPageSize
+	}
+	return page, size
+}
+
+// NewPageMetadata describes the page of a listing holding total items.
+func NewPageMetadata(page int, size int, total int64) *dto.PageMetadata {
+	return &dto.PageMetadata{
+		Page:      page,
+		Size:      size,
+		TotalItem: total,
+		TotalPage: (total + int64(size) - 1) / int64(size),
+	}
+}
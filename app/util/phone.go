@@ -0,0 +1,44 @@
+package util
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultCallingCode is assumed for numbers written in national format.
+const DefaultCallingCode = "62"
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// NormalizePhone converts a phone number to E.164. Spaces, dashes, dots and
+// parentheses are ignored; numbers with a leading 0 or without a plus sign
+// are read as Indonesian numbers.
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+
+	var digits string
+	switch {
+	case strings.HasPrefix(phone, "+"):
+		digits = phone[1:]
+	case strings.HasPrefix(phone, "00"):
+		digits = phone[2:]
+	case strings.HasPrefix(phone, "0"):
+		digits = DefaultCallingCode + phone[1:]
+	case strings.HasPrefix(phone, DefaultCallingCode):
+		digits = phone
+	default:
+		digits = DefaultCallingCode + phone
+	}
+
+	// E.164 allows at most 15 digits and country codes never start with 0
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhone
+		}
+	}
+
+	return "+" + digits, nil
+}
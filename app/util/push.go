@@ -0,0 +1,174 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+)
+
+const defaultFCMEndpoint = "https://fcm.googleapis.com/fcm/send"
+
+// NewPushUtil returns the push provider selected by PUSH_PROVIDER: "fcm"
+// delivers through FCM and "fake" records messages in memory for local
+// setups. Anything else only logs, so a missing or mistyped provider shows
+// up instead of quietly filling memory.
+func NewPushUtil(config *config.Config, log *logrus.Logger) domain.Push {
+	switch config.Push.Provider {
+	case "fcm":
+		endpoint := config.Push.Endpoint
+		if endpoint == "" {
+			endpoint = defaultFCMEndpoint
+		}
+
+		return &FCMPushUtil{
+			Endpoint:  endpoint,
+			ServerKey: config.Push.ServerKey,
+			Client:    &http.Client{Timeout: 10 * time.Second},
+		}
+	case "fake":
+		return NewFakePushUtil()
+	default:
+		log.WithField("provider", config.Push.Provider).Warn("Unknown push provider, push messages will not be delivered")
+		return &LogPushUtil{Log: log}
+	}
+}
+
+// FCMPushUtil sends messages through an FCM-compatible HTTP endpoint.
+type FCMPushUtil struct {
+	Endpoint  string
+	ServerKey string
+	Client    *http.Client
+}
+
+type fcmRequest struct {
+	To           string            `json:"to"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmResponse struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+	Results []struct {
+		MessageID string `json:"message_id"`
+		Error     string `json:"error"`
+	} `json:"results"`
+}
+
+// Send implements domain.Push.
+func (f *FCMPushUtil) Send(ctx context.Context, token string, msg *domain.PushMessage) error {
+	payload, err := json.Marshal(&fcmRequest{
+		To: token,
+		Notification: fcmNotification{
+			Title: msg.Title,
+			Body:  msg.Body,
+		},
+		Data: msg.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+f.ServerKey)
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("push provider responded with status %d", resp.StatusCode)
+	}
+
+	result := new(fcmResponse)
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode push response: %w", err)
+	}
+
+	if result.Failure == 0 {
+		return nil
+	}
+
+	for _, r := range result.Results {
+		switch r.Error {
+		case "":
+			continue
+		case "NotRegistered", "InvalidRegistration", "MismatchSenderId":
+			return domain.ErrInvalidPushToken
+		default:
+			return fmt.Errorf("push provider rejected message: %s", r.Error)
+		}
+	}
+
+	return fmt.Errorf("push provider rejected message")
+}
+
+// LogPushUtil drops every message, logging it instead.
+type LogPushUtil struct {
+	Log *logrus.Logger
+}
+
+// Send implements domain.Push.
+func (l *LogPushUtil) Send(ctx context.Context, token string, msg *domain.PushMessage) error {
+	l.Log.WithField("title", msg.Title).Warn("Push message dropped, no push provider configured")
+	return nil
+}
+
+// FakePushUtil records messages in memory instead of delivering them. Tokens
+// listed in InvalidTokens are rejected with domain.ErrInvalidPushToken.
+type FakePushUtil struct {
+	mu            sync.Mutex
+	Sent          []FakePushMessage
+	InvalidTokens map[string]bool
+}
+
+type FakePushMessage struct {
+	Token   string
+	Message domain.PushMessage
+}
+
+func NewFakePushUtil() *FakePushUtil {
+	return &FakePushUtil{
+		InvalidTokens: map[string]bool{},
+	}
+}
+
+// Send implements domain.Push.
+func (f *FakePushUtil) Send(ctx context.Context, token string, msg *domain.PushMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.InvalidTokens[token] {
+		return domain.ErrInvalidPushToken
+	}
+
+	f.Sent = append(f.Sent, FakePushMessage{Token: token, Message: *msg})
+	return nil
+}
+
+// Messages returns a copy of every message recorded so far.
+func (f *FakePushUtil) Messages() []FakePushMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FakePushMessage(nil), f.Sent...)
+}
@@ -0,0 +1,95 @@
+package util
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+)
+
+// slidingWindowScript counts a request against the current fixed window
+// only if the estimate across both windows is still below the limit. The
+// previous window's count is weighted by how much of it still overlaps the
+// sliding window, which smooths out bursts at window boundaries.
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+local limit = tonumber(ARGV[1])
+local weighted = previous * tonumber(ARGV[3])
+
+if weighted + current >= limit then
+	return {0, math.ceil(weighted + current)}
+end
+
+current = redis.call("INCR", KEYS[1])
+if current == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2] * 2)
+end
+
+return {1, math.ceil(weighted + current)}
+`)
+
+type RateLimiterUtil struct {
+	Enabled  bool
+	Policies map[string]domain.RateLimitPolicy
+	Redis    *redis.Client
+}
+
+func NewRateLimiterUtil(config *config.Config, redis *redis.Client) domain.RateLimiter {
+	window := time.Second * time.Duration(ParseIntOrDefault(config.RateLimit.Window, 60))
+	policy := func(name string, value string, fallback int) domain.RateLimitPolicy {
+		return domain.RateLimitPolicy{
+			Name:   name,
+			Limit:  ParseIntOrDefault(value, fallback),
+			Window: window,
+		}
+	}
+
+	return &RateLimiterUtil{
+		Enabled: config.RateLimit.Enabled,
+		Policies: map[string]domain.RateLimitPolicy{
+			domain.RateLimitRead:     policy(domain.RateLimitRead, config.RateLimit.Read, 300),
+			domain.RateLimitWrite:    policy(domain.RateLimitWrite, config.RateLimit.Write, 60),
+			domain.RateLimitAuth:     policy(domain.RateLimitAuth, config.RateLimit.Auth, 20),
+			domain.RateLimitTransfer: policy(domain.RateLimitTransfer, config.RateLimit.Transfer, 10),
+		},
+		Redis: redis,
+	}
+}
+
+// Allow implements domain.RateLimiter using a sliding window counter, so
+// the limit holds across every instance sharing the Redis server.
+func (r *RateLimiterUtil) Allow(ctx context.Context, name string, key string) (*domain.RateLimitResult, error) {
+	policy, ok := r.Policies[name]
+	if !r.Enabled || !ok {
+		return nil, nil
+	}
+
+	window := policy.Window.Milliseconds()
+	now := time.Now().UnixMilli()
+	index := now / window
+	elapsed := now % window
+
+	// The hash tag keeps both windows in one cluster slot
+	prefix := "ratelimit:{" + policy.Name + ":" + key + "}:"
+	keys := []string{
+		prefix + strconv.FormatInt(index, 10),
+		prefix + strconv.FormatInt(index-1, 10),
+	}
+	weight := float64(window-elapsed) / float64(window)
+
+	values, err := slidingWindowScript.Run(ctx, r.Redis, keys, policy.Limit, window, strconv.FormatFloat(weight, 'f', 6, 64)).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RateLimitResult{
+		Allowed:   values[0] == 1,
+		Limit:     policy.Limit,
+		Remaining: max(policy.Limit-int(values[1]), 0),
+		Reset:     time.Duration(window-elapsed) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+)
+
+// NewSMSUtil returns the SMS provider selected by SMS_PROVIDER. Anything
+// other than "http" falls back to the in-memory fake, which logs each
+// message so codes can be read from the console during local development.
+func NewSMSUtil(config *config.Config, log *logrus.Logger) domain.SMS {
+	if config.SMS.Provider != "http" {
+		return NewFakeSMSUtil(log)
+	}
+
+	return &HTTPSMSUtil{
+		Endpoint: config.SMS.Endpoint,
+		APIKey:   config.SMS.APIKey,
+		Sender:   config.SMS.Sender,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// HTTPSMSUtil posts messages as JSON to an SMS gateway endpoint.
+type HTTPSMSUtil struct {
+	Endpoint string
+	APIKey   string
+	Sender   string
+	Client   *http.Client
+}
+
+type smsRequest struct {
+	From    string `json:"from,omitempty"`
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+// Send implements domain.SMS.
+func (h *HTTPSMSUtil) Send(ctx context.Context, msg *domain.SMSMessage) error {
+	payload, err := json.Marshal(&smsRequest{
+		From:    h.Sender,
+		To:      msg.To,
+		Message: msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+h.APIKey)
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms provider responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// FakeSMSUtil records messages in memory instead of delivering them.
+type FakeSMSUtil struct {
+	mu   sync.Mutex
+	Log  *logrus.Logger
+	Sent []domain.SMSMessage
+}
+
+func NewFakeSMSUtil(log *logrus.Logger) *FakeSMSUtil {
+	return &FakeSMSUtil{
+		Log: log,
+	}
+}
+
+// Send implements domain.SMS.
+func (f *FakeSMSUtil) Send(ctx context.Context, msg *domain.SMSMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Sent = append(f.Sent, *msg)
+	if f.Log != nil {
+		f.Log.WithField("to", msg.To).Infof("Fake SMS: %s", msg.Body)
+	}
+	return nil
+}
+
+// Messages returns a copy of every message recorded so far.
+func (f *FakeSMSUtil) Messages() []domain.SMSMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]domain.SMSMessage(nil), f.Sent...)
+}
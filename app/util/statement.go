@@ -0,0 +1,124 @@
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"riz.it/domped/app/domain"
+)
+
+const statementDateFormat = "2006-01-02 15:04"
+
+// RenderStatementCSV renders the statement as CSV, with the opening and
+// closing balances as the first and last rows.
+func RenderStatementCSV(s *domain.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"date", "transaction_id", "description", "direction", "counterparty_wallet", "counterparty_name", "currency", "amount", "balance"},
+		{s.PeriodStart.Format(statementDateFormat), "", "Opening balance", "", "", "", s.Currency, "", strconv.FormatInt(s.OpeningBalance, 10)},
+	}
+
+	for _, e := range s.Entries {
+		rows = append(rows, []string{
+			e.TransactionAt.Format(statementDateFormat),
+			strconv.FormatInt(e.TransactionID, 10),
+			e.Description,
+			e.Direction,
+			e.Counterparty,
+			e.CounterpartyName,
+			s.Currency,
+			strconv.FormatInt(e.Amount, 10),
+			strconv.FormatInt(e.Balance, 10),
+		})
+	}
+
+	rows = append(rows, []string{
+		s.PeriodEnd.Format(statementDateFormat), "", "Closing balance", "", "", "", s.Currency, "", strconv.FormatInt(s.ClosingBalance, 10),
+	})
+
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderStatementPDF renders the statement as a paginated A4 PDF.
+func RenderStatementPDF(s *domain.Statement) []byte {
+	const (
+		margin     = 40.0
+		fontSize   = 8.0
+		lineHeight = 12.0
+		rowFormat  = "%-16s %-22s %-28s %17s %17s"
+	)
+
+	pdf := NewPDF()
+	y := 0.0
+
+	newPage := func() {
+		pdf.AddPage()
+		y = pdf.Height - margin
+
+		pdf.Text(margin, y, 14, true, "Domped - Account Statement")
+		y -= lineHeight * 2
+		pdf.Text(margin, y, fontSize, false, fmt.Sprintf("Wallet number : %s", s.WalletNumber))
+		y -= lineHeight
+		pdf.Text(margin, y, fontSize, false, fmt.Sprintf("Account holder: %s", s.OwnerName))
+		y -= lineHeight
+		pdf.Text(margin, y, fontSize, false, fmt.Sprintf("Currency      : %s", s.Currency))
+		y -= lineHeight
+		pdf.Text(margin, y, fontSize, false, fmt.Sprintf("Period        : %s - %s", s.PeriodStart.Format("2006-01-02"), s.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02")))
+		y -= lineHeight * 2
+
+		pdf.Text(margin, y, fontSize, true, fmt.Sprintf(rowFormat, "Date", "Description", "Counterparty", "Amount", "Balance"))
+		y -= lineHeight / 2
+		pdf.Line(margin, y, pdf.Width-margin, y)
+		y -= lineHeight
+	}
+
+	row := func(bold bool, values ...any) {
+		if y < margin+lineHeight*2 {
+			newPage()
+		}
+		pdf.Text(margin, y, fontSize, bold, fmt.Sprintf(rowFormat, values...))
+		y -= lineHeight
+	}
+
+	newPage()
+	row(true, s.PeriodStart.Format(statementDateFormat), "Opening balance", "", "", FormatMoney(domain.NewMoney(s.OpeningBalance, s.Currency)))
+
+	for _, e := range s.Entries {
+		amount := FormatMoney(domain.NewMoney(e.Amount, s.Currency))
+		if e.Direction == domain.StatementOut {
+			amount = "-" + amount
+		}
+
+		counterparty := e.Counterparty
+		if e.CounterpartyName != "" {
+			counterparty += " " + e.CounterpartyName
+		}
+
+		row(false, e.TransactionAt.Format(statementDateFormat), truncate(e.Description, 22), truncate(counterparty, 28), amount, FormatMoney(domain.NewMoney(e.Balance, s.Currency)))
+	}
+
+	row(true, s.PeriodEnd.Format(statementDateFormat), "Closing balance", "", "", FormatMoney(domain.NewMoney(s.ClosingBalance, s.Currency)))
+
+	y -= lineHeight
+	row(false, "", "Total in", "", FormatMoney(domain.NewMoney(s.TotalIn, s.Currency)), "")
+	row(false, "", "Total out", "", FormatMoney(domain.NewMoney(s.TotalOut, s.Currency)), "")
+	row(false, "", "Generated at", s.GeneratedAt.Format(statementDateFormat), "", "")
+
+	return pdf.Bytes()
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length-1]) + "~"
+}
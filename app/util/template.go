@@ -0,0 +1,162 @@
+package util
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"text/template"
+
+	"riz.it/domped/app/domain"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// TemplateUtil renders notification, email and SMS copy from the per-locale files
+// under templates/<locale>/. A template missing from the requested locale is
+// looked up in domain.DefaultLocale instead.
+type TemplateUtil struct {
+	FS fs.FS
+}
+
+func NewTemplateUtil() domain.Template {
+	return &TemplateUtil{
+		FS: templateFS,
+	}
+}
+
+var templateFuncs = map[string]any{
+	"currency": currencyFunc,
+}
+
+// RenderNotification implements domain.Template.
+func (t *TemplateUtil) RenderNotification(locale string, name string, data any) (*domain.RenderedNotification, error) {
+	tmpl, err := t.parseText(locale, "notification/"+name+".tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	title, err := executeText(tmpl, "title", data)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := executeText(tmpl, "body", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RenderedNotification{
+		Title: title,
+		Body:  body,
+	}, nil
+}
+
+// RenderEmail implements domain.Template.
+func (t *TemplateUtil) RenderEmail(locale string, name string, data any) (*domain.RenderedEmail, error) {
+	tmpl, err := t.parseText(locale, "email/"+name+".tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := executeText(tmpl, "subject", data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The plain-text alternative is optional
+	var text string
+	if tmpl.Lookup("text") != nil {
+		if text, err = executeText(tmpl, "text", data); err != nil {
+			return nil, err
+		}
+	}
+
+	path, err := t.resolve(locale, "email/"+name+".html")
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.New(name).Funcs(templateFuncs).ParseFS(t.FS, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := html.ExecuteTemplate(&buf, name+".html", data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", path, err)
+	}
+
+	return &domain.RenderedEmail{
+		Subject: subject,
+		Text:    text,
+		HTML:    buf.String(),
+	}, nil
+}
+
+// RenderSMS implements domain.Template.
+func (t *TemplateUtil) RenderSMS(locale string, name string, data any) (string, error) {
+	tmpl, err := t.parseText(locale, "sms/"+name+".tmpl")
+	if err != nil {
+		return "", err
+	}
+
+	return executeText(tmpl, "text", data)
+}
+
+func (t *TemplateUtil) parseText(locale, name string) (*template.Template, error) {
+	path, err := t.resolve(locale, name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).ParseFS(t.FS, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+
+	return tmpl, nil
+}
+
+// resolve returns the path of the template for the locale, falling back to
+// the default locale when the locale does not provide it.
+func (t *TemplateUtil) resolve(locale, name string) (string, error) {
+	for _, l := range []string{locale, domain.DefaultLocale} {
+		path := "templates/" + l + "/" + name
+		if _, err := fs.Stat(t.FS, path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("template %s not found", name)
+}
+
+func executeText(tmpl *template.Template, name string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// currencyFunc formats an amount in the given currency, rupiah when none
+// is given.
+func currencyFunc(amount any, currency ...string) (string, error) {
+	code := domain.DefaultCurrency
+	if len(currency) > 0 && currency[0] != "" {
+		code = currency[0]
+	}
+
+	switch v := amount.(type) {
+	case int64:
+		return FormatMoney(domain.NewMoney(v, code)), nil
+	case int:
+		return FormatMoney(domain.NewMoney(int64(v), code)), nil
+	case float64:
+		return FormatMoney(domain.NewMoney(int64(v), code)), nil
+	default:
+		return "", fmt.Errorf("currency: unsupported amount type %T", amount)
+	}
+}
@@ -0,0 +1,140 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"riz.it/domped/app/config"
+	"riz.it/domped/app/domain"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPUtil implements RFC 6238 with HMAC-SHA1, the parameters every
+// authenticator app supports. Clock can be replaced to check codes at a
+// fixed time. Secrets are stored encrypted with AES-GCM under Key, the
+// base64-encoded 32 byte AUTH_TWO_FACTOR_KEY.
+type TOTPUtil struct {
+	Clock  func() time.Time
+	Period int64
+	Digits int
+	// Skew is the number of time steps accepted on either side of the
+	// current one, to allow for clock drift on the phone
+	Skew int64
+	Key  []byte
+}
+
+func NewTOTPUtil(config *config.Config) domain.TOTP {
+	// An invalid key is caught when a secret is sealed or opened
+	key, _ := base64.StdEncoding.DecodeString(config.Auth.TwoFactorKey)
+
+	return &TOTPUtil{
+		Clock:  time.Now,
+		Period: 30,
+		Digits: 6,
+		Skew:   1,
+		Key:    key,
+	}
+}
+
+// GenerateSecret implements domain.TOTP.
+func (t *TOTPUtil) GenerateSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// URI implements domain.TOTP. The result is the otpauth:// URI encoded in
+// enrolment QR codes.
+func (t *TOTPUtil) URI(secret string, issuer string, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(t.Digits))
+	query.Set("period", fmt.Sprint(t.Period))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Validate implements domain.TOTP.
+func (t *TOTPUtil) Validate(secret string, code string) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(code) != t.Digits {
+		return 0, false
+	}
+
+	current := t.Clock().Unix() / t.Period
+	for counter := current - t.Skew; counter <= current+t.Skew; counter++ {
+		if subtle.ConstantTimeCompare([]byte(t.Code(key, counter)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+// Seal implements domain.TOTP.
+func (t *TOTPUtil) Seal(secret string) (string, error) {
+	if len(t.Key) != 32 {
+		return "", errors.New("two-factor key must be 32 bytes")
+	}
+
+	sealed, err := EncryptAESGCM(t.Key, []byte(secret))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open implements domain.TOTP.
+func (t *TOTPUtil) Open(sealed string) (string, error) {
+	if len(t.Key) != 32 {
+		return "", errors.New("two-factor key must be 32 bytes")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := DecryptAESGCM(t.Key, raw)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// Code returns the code for a time step, as defined by RFC 4226.
+func (t *TOTPUtil) Code(key []byte, counter int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < t.Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", t.Digits, value%modulo)
+}
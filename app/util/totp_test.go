@@ -0,0 +1,106 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA-1 test vectors from RFC 6238, appendix B.
+func TestTOTPValidateRFC6238(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		totp := &TOTPUtil{
+			Clock:  func() time.Time { return time.Unix(tt.unix, 0) },
+			Period: 30,
+			Digits: 8,
+		}
+
+		counter, ok := totp.Validate(secret, tt.code)
+		if !ok {
+			t.Errorf("Validate(%d, %s) rejected a valid code", tt.unix, tt.code)
+			continue
+		}
+		if want := tt.unix / 30; counter != want {
+			t.Errorf("Validate(%d, %s) counter = %d, want %d", tt.unix, tt.code, counter, want)
+		}
+	}
+}
+
+func TestTOTPValidateSkew(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	totp := &TOTPUtil{
+		Clock:  func() time.Time { return time.Unix(1111111111, 0) },
+		Period: 30,
+		Digits: 8,
+		Skew:   1,
+	}
+	current := int64(1111111111 / 30)
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		name    string
+		code    string
+		valid   bool
+		counter int64
+	}{
+		{"previous step", totp.Code(key, current-1), true, current - 1},
+		{"current step", totp.Code(key, current), true, current},
+		{"next step", totp.Code(key, current+1), true, current + 1},
+		{"two steps back", totp.Code(key, current-2), false, 0},
+		{"two steps ahead", totp.Code(key, current+2), false, 0},
+		{"wrong length", "1234", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := totp.Validate(secret, tt.code)
+			if ok != tt.valid || counter != tt.counter {
+				t.Errorf("Validate(%s) = (%d, %v), want (%d, %v)", tt.code, counter, ok, tt.counter, tt.valid)
+			}
+		})
+	}
+}
+
+func TestTOTPSealOpen(t *testing.T) {
+	totp := &TOTPUtil{Key: make([]byte, 32)}
+
+	sealed, err := totp.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if sealed == "JBSWY3DPEHPK3PXP" {
+		t.Fatal("Seal stored the secret in the clear")
+	}
+	if len(sealed) > 255 {
+		t.Fatalf("sealed secret is %d characters, the column holds 255", len(sealed))
+	}
+
+	secret, err := totp.Open(sealed)
+	if err != nil || secret != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Open = %q, %v", secret, err)
+	}
+
+	// Another key must not open it
+	other := &TOTPUtil{Key: append(make([]byte, 31), 1)}
+	if _, err := other.Open(sealed); err == nil {
+		t.Error("Open succeeded with the wrong key")
+	}
+
+	// Nor may a missing key seal anything
+	if _, err := (&TOTPUtil{}).Seal("JBSWY3DPEHPK3PXP"); err == nil {
+		t.Error("Seal succeeded without a key")
+	}
+}
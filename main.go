@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	"riz.it/domped/app/config"
 	"riz.it/domped/app/injector"
@@ -13,6 +17,19 @@ func main() {
 	app := injector.InitializedApp()
 	cnf := config.Get()
 
+	// Run background workers until the process is asked to stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, worker := range app.Workers {
+		go worker.Run(ctx)
+	}
+
+	go func() {
+		<-ctx.Done()
+		app.Fiber.Shutdown()
+	}()
+
 	port, _ := strconv.Atoi(cnf.Server.Port)
 	err := app.Fiber.Listen(fmt.Sprintf(":%d", port))
 	if err != nil {